@@ -48,6 +48,37 @@ type APISIXConfigurationStatus struct {
 	// +listMapKey=type
 	// +kubebuilder:validation:MaxItems=8
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Consumers are the GatewayClasses and Gateways configured by the
+	// APISIXConfiguration, along with the state of the configuration for each
+	// of them.
+	//
+	// +optional
+	Consumers []APISIXConfigurationConsumerStatus `json:"consumers,omitempty"`
+}
+
+// APISIXConfigurationConsumerStatus describes the state of an
+// APISIXConfiguration for one of its consumers.
+type APISIXConfigurationConsumerStatus struct {
+	// Kind is the kind of the consumer.
+	Kind GatewayConfigureTargetKind `json:"kind"`
+
+	// Namespace is the namespace of the consumer, empty for cluster scoped
+	// consumers.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the consumer.
+	Name string `json:"name"`
+
+	// Conditions describe the state of the configuration for the consumer.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=8
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -88,10 +88,10 @@ func init() {
 	SchemeBuilder.Register(&ControlPlane{}, &ControlPlaneList{})
 }
 
-func (c *ControlPlane) GetCondition() []metav1.Condition  {
+func (c *ControlPlane) GetConditions() []metav1.Condition {
 	return c.Status.Conditions
 }
 
-func (c *ControlPlane) SetCondition(conditions []metav1.Condition)  {
+func (c *ControlPlane) SetConditions(conditions []metav1.Condition) {
 	c.Status.Conditions = conditions
-}
\ No newline at end of file
+}
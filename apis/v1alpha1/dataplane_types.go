@@ -17,7 +17,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -26,10 +29,498 @@ import (
 // DataPlaneSpec defines the desired state of DataPlane
 type DataPlaneSpec struct {
 	DataPlaneDeploymentOptions `json:",inline"`
+
+	// Network contains the network related options of the DataPlane.
+	//
+	// +optional
+	Network DataPlaneNetworkOptions `json:"network,omitempty"`
+
+	// ConfigCenter defines where the proxy loads its configuration from. When
+	// unset the proxy runs in standalone mode.
+	//
+	// +optional
+	ConfigCenter *DataPlaneConfigCenter `json:"configCenter,omitempty"`
+
+	// Config contains the options of the APISIX proxy, rendered into its
+	// configuration file.
+	//
+	// +optional
+	Config *DataPlaneConfig `json:"config,omitempty"`
 }
 
 type DataPlaneDeploymentOptions struct {
 	DeploymentOptions `json:",inline"`
+
+	// DeploymentMode defines how the pods of the DataPlane are deployed: by a
+	// Deployment, or by a DaemonSet running one proxy per node, which then
+	// listens on the proxy ports of the nodes. It defaults to Deployment.
+	//
+	// +optional
+	DeploymentMode DataPlaneDeploymentMode `json:"deploymentMode,omitempty"`
+
+	// Replicas is the number of pods of the DataPlane. It defaults to 1, and
+	// is ignored when the DataPlane is scaled horizontally. It can't be set
+	// in DaemonSet deployment mode.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Scaling contains the options scaling the DataPlane automatically.
+	//
+	// +optional
+	Scaling *DataPlaneScaling `json:"scaling,omitempty"`
+}
+
+// DataPlaneDeploymentMode is the kind of workload deploying the pods of a
+// DataPlane.
+//
+// +kubebuilder:validation:Enum=Deployment;DaemonSet
+type DataPlaneDeploymentMode string
+
+const (
+	// DataPlaneDeploymentModeDeployment deploys the pods of the DataPlane with
+	// a Deployment, exposed by its Service.
+	DataPlaneDeploymentModeDeployment DataPlaneDeploymentMode = "Deployment"
+
+	// DataPlaneDeploymentModeDaemonSet deploys one pod of the DataPlane per
+	// node with a DaemonSet, its proxy ports being exposed on the node.
+	DataPlaneDeploymentModeDaemonSet DataPlaneDeploymentMode = "DaemonSet"
+)
+
+// DataPlaneScaling contains the options scaling a DataPlane automatically.
+type DataPlaneScaling struct {
+	// Horizontal scales the pods of the DataPlane through a
+	// HorizontalPodAutoscaler owned by the DataPlane.
+	//
+	// +optional
+	Horizontal *HorizontalScaling `json:"horizontal,omitempty"`
+}
+
+// HorizontalScaling contains the options of the HorizontalPodAutoscaler of a
+// DataPlane. The resource utilization targets are relative to the resources
+// requested by the proxy container, see DeploymentOptions.PodTemplateSpec.
+type HorizontalScaling struct {
+	// MinReplicas is the lower limit of the number of pods. It defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit of the number of pods.
+	//
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// CPUUtilization is the target average CPU utilization of the pods, in
+	// percent of their requested CPU.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	CPUUtilization *int32 `json:"cpuUtilization,omitempty"`
+
+	// MemoryUtilization is the target average memory utilization of the pods,
+	// in percent of their requested memory.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MemoryUtilization *int32 `json:"memoryUtilization,omitempty"`
+
+	// CustomMetrics are the target average values of custom metrics
+	// describing the pods, served by the custom metrics API.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	CustomMetrics []CustomMetricTarget `json:"customMetrics,omitempty"`
+}
+
+// CustomMetricTarget is the target average value of a custom metric
+// describing the pods of a DataPlane.
+type CustomMetricTarget struct {
+	// Name is the name of the metric.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// AverageValue is the target value of the metric averaged across the
+	// pods.
+	AverageValue resource.Quantity `json:"averageValue"`
+}
+
+// DataPlaneNetworkOptions defines the network related options of a DataPlane.
+type DataPlaneNetworkOptions struct {
+	// Services contains the options of the Services exposing the DataPlane.
+	//
+	// +optional
+	Services *DataPlaneServices `json:"services,omitempty"`
+}
+
+// DataPlaneServices contains the options of the Services exposing a DataPlane.
+type DataPlaneServices struct {
+	// Ingress contains the options of the Service exposing the proxy to
+	// ingress traffic.
+	//
+	// +optional
+	Ingress *DataPlaneServiceOptions `json:"ingress,omitempty"`
+}
+
+// DataPlaneServiceOptions contains the options of a Service exposing a
+// DataPlane.
+type DataPlaneServiceOptions struct {
+	// Ports are the ports the proxy listens on and the Service exposes. When
+	// empty the default HTTP and HTTPS ports are used.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=port
+	// +listMapKey=protocol
+	Ports []DataPlaneServicePort `json:"ports,omitempty"`
+
+	// Type is the type of the Service. It defaults to LoadBalancer.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Annotations are set on the Service, e.g. to configure the load balancer
+	// provisioned for it by a cloud controller.
+	//
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ExternalTrafficPolicy defines how the Service routes external traffic:
+	// Local preserves the source IP of the clients by only routing it to the
+	// proxies of the node it's received on. It defaults to Cluster, and only
+	// applies to the NodePort and LoadBalancer Services.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Cluster;Local
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+
+	// LoadBalancerSourceRanges are the CIDRs the load balancer of the Service
+	// accepts traffic from. It only applies to LoadBalancer Services.
+	//
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+}
+
+// DataPlaneServicePort is a port exposed by the Service of a DataPlane, along
+// with the protocol the proxy serves on it.
+type DataPlaneServicePort struct {
+	// Port is the port exposed by the Service.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// Protocol is the protocol the proxy serves on the port.
+	Protocol ProxyProtocol `json:"protocol"`
+
+	// TargetPort is the port the proxy listens on to serve the port. When
+	// unset the proxy listens on the port itself, offset by 9000 for
+	// privileged ports (80 -> 9080).
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	TargetPort *int32 `json:"targetPort,omitempty"`
+
+	// NodePort is the port the port is exposed on by the nodes, for the
+	// NodePort and LoadBalancer Services. It's allocated by Kubernetes when
+	// unset.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	NodePort *int32 `json:"nodePort,omitempty"`
+}
+
+// ProxyProtocol is a protocol that the APISIX proxy can serve.
+//
+// +kubebuilder:validation:Enum=HTTP;HTTPS;TLS;TCP;UDP
+type ProxyProtocol string
+
+const (
+	// ProxyProtocolHTTP is served by the HTTP listeners of the proxy.
+	ProxyProtocolHTTP ProxyProtocol = "HTTP"
+
+	// ProxyProtocolHTTPS is served by the SSL listeners of the proxy.
+	ProxyProtocolHTTPS ProxyProtocol = "HTTPS"
+
+	// ProxyProtocolTLS is served by the stream proxy, terminating TLS.
+	ProxyProtocolTLS ProxyProtocol = "TLS"
+
+	// ProxyProtocolTCP is served by the stream proxy.
+	ProxyProtocolTCP ProxyProtocol = "TCP"
+
+	// ProxyProtocolUDP is served by the stream proxy.
+	ProxyProtocolUDP ProxyProtocol = "UDP"
+)
+
+// DataPlaneConfig contains the options of the APISIX proxy of a DataPlane.
+type DataPlaneConfig struct {
+	// Plugins are the plugins enabled on the proxy. When empty the plugins
+	// enabled by default by APISIX are.
+	//
+	// +optional
+	// +listType=set
+	Plugins []string `json:"plugins,omitempty"`
+
+	// WorkerProcesses is the number of nginx worker processes of the proxy,
+	// either a positive number or auto. Defaults to 2.
+	//
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	WorkerProcesses *intstr.IntOrString `json:"workerProcesses,omitempty"`
+
+	// Admin contains the options of the Admin API of the proxy.
+	//
+	// +optional
+	Admin *DataPlaneAdminAPIOptions `json:"admin,omitempty"`
+
+	// RealIP configures the proxy to take the address of the clients from a
+	// request header set by trusted proxies in front of it.
+	//
+	// +optional
+	RealIP *DataPlaneRealIPOptions `json:"realIP,omitempty"`
+
+	// Logging contains the logging options of the proxy.
+	//
+	// +optional
+	Logging *DataPlaneLoggingOptions `json:"logging,omitempty"`
+
+	// NginxSnippets are nginx configuration snippets injected into the nginx
+	// configuration generated by APISIX.
+	//
+	// +optional
+	NginxSnippets *DataPlaneNginxSnippets `json:"nginxSnippets,omitempty"`
+}
+
+// DataPlaneAdminAPIOptions contains the options of the Admin API of the
+// proxy of a DataPlane.
+type DataPlaneAdminAPIOptions struct {
+	// Port is the port the Admin API listens on. Defaults to 9444.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port *int32 `json:"port,omitempty"`
+
+	// AllowList are the IP addresses and CIDRs allowed to reach the Admin
+	// API. Any address is allowed when empty, the clients being authenticated
+	// with their mTLS certificate.
+	//
+	// +optional
+	AllowList []string `json:"allowList,omitempty"`
+}
+
+// DataPlaneRealIPOptions configures the proxy of a DataPlane to take the
+// address of the clients from a request header.
+type DataPlaneRealIPOptions struct {
+	// Header is the request header holding the address of the client.
+	//
+	// +kubebuilder:default=X-Real-IP
+	Header string `json:"header,omitempty"`
+
+	// Recursive makes the proxy take the last address of the header which
+	// isn't a trusted address, rather than the last address.
+	//
+	// +optional
+	Recursive bool `json:"recursive,omitempty"`
+
+	// TrustedAddresses are the IP addresses and CIDRs of the proxies trusted
+	// to set the header.
+	//
+	// +kubebuilder:validation:MinItems=1
+	TrustedAddresses []string `json:"trustedAddresses"`
+}
+
+// DataPlaneLoggingOptions contains the logging options of the proxy of a
+// DataPlane.
+type DataPlaneLoggingOptions struct {
+	// AccessLogFormat is the nginx format of the access log of the proxy.
+	//
+	// +optional
+	AccessLogFormat string `json:"accessLogFormat,omitempty"`
+
+	// AccessLogFormatEscape is the escaping of the variables of the access
+	// log format.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=default;json;none
+	AccessLogFormatEscape string `json:"accessLogFormatEscape,omitempty"`
+
+	// ErrorLogLevel is the level of the error log of the proxy.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=debug;info;notice;warn;error;crit;alert;emerg
+	ErrorLogLevel string `json:"errorLogLevel,omitempty"`
+}
+
+// DataPlaneNginxSnippets are nginx configuration snippets injected into the
+// nginx configuration of the proxy of a DataPlane.
+type DataPlaneNginxSnippets struct {
+	// Main is injected into the main block.
+	//
+	// +optional
+	Main string `json:"main,omitempty"`
+
+	// HTTP is injected into the http block.
+	//
+	// +optional
+	HTTP string `json:"http,omitempty"`
+
+	// HTTPServer is injected into the server block of the proxy listeners.
+	//
+	// +optional
+	HTTPServer string `json:"httpServer,omitempty"`
+
+	// HTTPAdmin is injected into the server block of the Admin API.
+	//
+	// +optional
+	HTTPAdmin string `json:"httpAdmin,omitempty"`
+
+	// Stream is injected into the stream block.
+	//
+	// +optional
+	Stream string `json:"stream,omitempty"`
+}
+
+// DataPlaneConfigCenter defines where the proxy of a DataPlane loads its
+// configuration from.
+type DataPlaneConfigCenter struct {
+	// Mode is the config center of the proxy: in standalone mode the
+	// configuration is pushed to the proxy by its ControlPlane, in etcd mode
+	// it's loaded from an etcd cluster.
+	//
+	// +kubebuilder:default=standalone
+	Mode ConfigCenterMode `json:"mode"`
+
+	// Etcd contains the options of the etcd cluster the configuration is
+	// loaded from. In etcd mode, either it or Managed is required.
+	//
+	// +optional
+	Etcd *EtcdConfigCenter `json:"etcd,omitempty"`
+
+	// Managed makes the operator provision the etcd cluster the configuration
+	// is loaded from, along with its certificates, in etcd mode. It can't be
+	// set along with Etcd.
+	//
+	// +optional
+	Managed *ManagedEtcdConfigCenter `json:"managed,omitempty"`
+}
+
+// ManagedEtcdConfigCenter contains the options of the etcd cluster provisioned
+// by the operator for a DataPlane.
+type ManagedEtcdConfigCenter struct {
+	// Replicas is the number of members of the etcd cluster. The members are
+	// bootstrapped statically, hence it can't be changed once the cluster is
+	// provisioned.
+	//
+	// +optional
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// ContainerImage is the container image of the etcd members.
+	//
+	// +optional
+	ContainerImage *string `json:"containerImage,omitempty"`
+
+	// Version is the version of the container image of the etcd members.
+	//
+	// +optional
+	Version *string `json:"version,omitempty"`
+
+	// Storage contains the options of the persistent volumes holding the data
+	// of the etcd members. The volumes are 1Gi volumes of the default
+	// StorageClass of the cluster when unset.
+	//
+	// +optional
+	Storage *EtcdStorage `json:"storage,omitempty"`
+}
+
+// EtcdStorage contains the options of the persistent volumes holding the data
+// of the managed etcd members.
+type EtcdStorage struct {
+	// Size is the size of the volume of each member.
+	Size resource.Quantity `json:"size"`
+
+	// StorageClassName is the StorageClass of the volumes, the default
+	// StorageClass of the cluster when unset.
+	//
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// ConfigCenterMode is a config center of the APISIX proxy.
+//
+// +kubebuilder:validation:Enum=standalone;etcd
+type ConfigCenterMode string
+
+const (
+	// ConfigCenterModeStandalone is the mode in which the proxy doesn't use
+	// any database, its configuration being pushed by its ControlPlane.
+	ConfigCenterModeStandalone ConfigCenterMode = "standalone"
+
+	// ConfigCenterModeEtcd is the traditional mode in which the proxy loads
+	// its configuration from etcd.
+	ConfigCenterModeEtcd ConfigCenterMode = "etcd"
+)
+
+// EtcdConfigCenter contains the options of the etcd cluster the proxy loads
+// its configuration from.
+type EtcdConfigCenter struct {
+	// Endpoints are the URLs of the etcd members, e.g. https://etcd:2379.
+	//
+	// +kubebuilder:validation:MinItems=1
+	Endpoints []string `json:"endpoints"`
+
+	// Prefix is the prefix of the keys holding the APISIX configuration in
+	// etcd. APISIX uses /apisix when unset.
+	//
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// TLS contains the TLS options of the connections to etcd.
+	//
+	// +optional
+	TLS *EtcdTLSConfig `json:"tls,omitempty"`
+
+	// AuthSecretRef references a Secret in the namespace of the DataPlane
+	// holding the username and password the proxy authenticates to etcd
+	// with, under the username and password keys.
+	//
+	// +optional
+	AuthSecretRef *corev1.LocalObjectReference `json:"authSecretRef,omitempty"`
+}
+
+// EtcdTLSConfig contains the TLS options of the connections to etcd.
+type EtcdTLSConfig struct {
+	// ClientCertificateSecretRef references a kubernetes.io/tls Secret in the
+	// namespace of the DataPlane holding the client certificate the proxy
+	// authenticates to etcd with.
+	//
+	// +optional
+	ClientCertificateSecretRef *corev1.LocalObjectReference `json:"clientCertificateSecretRef,omitempty"`
+
+	// CASecretRef references a Secret in the namespace of the DataPlane
+	// holding, under the ca.crt key, the CA the certificate of etcd is
+	// verified with.
+	//
+	// +optional
+	CASecretRef *corev1.LocalObjectReference `json:"caSecretRef,omitempty"`
+
+	// SNI is the server name sent to etcd during the TLS handshake.
+	//
+	// +optional
+	SNI string `json:"sni,omitempty"`
+
+	// InsecureSkipVerify disables the verification of the certificate of etcd.
+	//
+	// +optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
 }
 
 // DataPlaneStatus defines the observed state of DataPlane
@@ -41,6 +532,90 @@ type DataPlaneStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
 	Service string `json:"service,omitempty"`
+
+	// Addresses are the addresses the proxy of the DataPlane is reachable at
+	// through its Service: the ingress points of its load balancer, the
+	// addresses of the nodes running its pods if it's a NodePort Service, or
+	// its cluster IP.
+	//
+	// +optional
+	Addresses []DataPlaneAddress `json:"addresses,omitempty"`
+
+	// Replicas is the number of pods of the DataPlane, i.e. the number of
+	// nodes they're scheduled on in DaemonSet deployment mode.
+	//
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ReadyReplicas is the number of ready pods of the DataPlane.
+	//
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// Image is the image the proxy of the DataPlane is deployed with.
+	//
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// ObservedGeneration is the generation of the DataPlane its addresses,
+	// replicas and image were last reported for.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ConfigCenter is the status of the etcd cluster provisioned by the
+	// operator for the DataPlane, if any.
+	//
+	// +optional
+	ConfigCenter *DataPlaneConfigCenterStatus `json:"configCenter,omitempty"`
+}
+
+// DataPlaneAddress is an address the proxy of a DataPlane is reachable at.
+type DataPlaneAddress struct {
+	// Type is the type of the address.
+	Type DataPlaneAddressType `json:"type"`
+
+	// Value is the IP address or the hostname.
+	Value string `json:"value"`
+}
+
+// DataPlaneAddressType is the type of an address of a DataPlane. Its values
+// match the address types of the Gateway API.
+//
+// +kubebuilder:validation:Enum=IPAddress;Hostname
+type DataPlaneAddressType string
+
+const (
+	// DataPlaneAddressTypeIPAddress is the type of IPv4 and IPv6 addresses.
+	DataPlaneAddressTypeIPAddress DataPlaneAddressType = "IPAddress"
+	// DataPlaneAddressTypeHostname is the type of DNS names.
+	DataPlaneAddressTypeHostname DataPlaneAddressType = "Hostname"
+)
+
+// DataPlaneConfigCenterStatus is the status of the etcd cluster provisioned by
+// the operator for a DataPlane.
+type DataPlaneConfigCenterStatus struct {
+	// Endpoints are the URLs of the etcd members the proxy is configured with.
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	// ReadyMembers is the number of etcd members ready to serve requests.
+	ReadyMembers int32 `json:"readyMembers"`
+
+	// Members is the health of each etcd member.
+	//
+	// +optional
+	Members []EtcdMemberStatus `json:"members,omitempty"`
+}
+
+// EtcdMemberStatus is the health of a member of the etcd cluster provisioned
+// by the operator.
+type EtcdMemberStatus struct {
+	// Name is the name of the member, which is the name of its Pod.
+	Name string `json:"name"`
+
+	// Ready indicates whether the member is healthy and ready to serve
+	// requests.
+	Ready bool `json:"ready"`
 }
 
 //+genclient
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeploymentOptions 是一个 shared type，用来表示在一个 Deployment 中可能需要
@@ -26,12 +27,51 @@ type DeploymentOptions struct {
 	//
 	// +optional
 	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// PodTemplateSpec 以 strategic merge patch 的方式合并到 operator 生成的
+	// Pod 模板之上，用来设置资源、调度、安全上下文、sidecar 以及额外的卷等。
+	// 容器按名称合并。
+	//
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodTemplateSpec *corev1.PodTemplateSpec `json:"podTemplateSpec,omitempty"`
+
+	// PodDisruptionBudget 用来为部署的 Pod 创建 PodDisruptionBudget，保护它们
+	// 在节点排空等自愿中断时不被全部驱逐。
+	//
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+}
+
+// PodDisruptionBudget 定义了 PodDisruptionBudget 的参数，MinAvailable 与
+// MaxUnavailable 必须且只能设置其一。
+//
+// +kubebuilder:validation:MinProperties=1
+// +kubebuilder:validation:MaxProperties=1
+type PodDisruptionBudget struct {
+	// MinAvailable 是驱逐之后至少仍然可用的 Pod 数量或者百分比。
+	//
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable 是驱逐之后至多不可用的 Pod 数量或者百分比。
+	//
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 }
 
+// GatewayConfigureTargetKind is the kind of an object configured by an
+// APISIXConfiguration.
 type GatewayConfigureTargetKind string
 
 const (
+	// GatewayConfigureTargetKindGateway is used for Gateways whose
+	// GatewayClass references an APISIXConfiguration.
 	GatewayConfigureTargetKindGateway GatewayConfigureTargetKind = "ApisixGateway"
 
+	// GatewayConfigureTargetKindGatewayClass is used for GatewayClasses
+	// referencing an APISIXConfiguration through their parametersRef.
 	GatewayConfigureTargetKindGatewayClass GatewayConfigureTargetKind = "ApisixGatewayClass"
 )
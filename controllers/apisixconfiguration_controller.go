@@ -19,44 +19,115 @@ package controllers
 import (
 	"context"
 
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 )
 
+// -----------------------------------------------------------------------------
+// APISIXConfigurationReconciler
+// -----------------------------------------------------------------------------
+
 // APISIXConfigurationReconciler reconciles a APISIXConfiguration object
 type APISIXConfigurationReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
-}
-
-//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=apisixconfigurations,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=apisixconfigurations/status,verbs=get;update;patch
-//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=apisixconfigurations/finalizers,verbs=update
-
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the APISIXConfiguration object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.12.2/pkg/reconcile
-func (r *APISIXConfigurationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
 
-	// TODO(user): your logic here
-
-	return ctrl.Result{}, nil
+	// ControllerName is the name this operator uses to claim GatewayClasses
+	// through their .spec.controllerName field. Only GatewayClasses claimed
+	// by this operator, and their Gateways, are consumers of the
+	// APISIXConfigurations.
+	ControllerName string
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *APISIXConfigurationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&apisixoperatorv1alpha1.APISIXConfiguration{}).
+		// watch for changes in GatewayClasses so that consumers starting or
+		// stopping to reference an APISIXConfiguration are reported.
+		Watches(
+			&source.Kind{Type: &gatewayv1alpha2.GatewayClass{}},
+			handler.EnqueueRequestsFromMapFunc(r.listAPISIXConfigurationsForGatewayClass)).
+		// watch for changes in Gateways, their DataPlanes and their ControlPlanes
+		// so that the state of the configuration for every Gateway is reported.
+		Watches(
+			&source.Kind{Type: &gatewayv1alpha2.Gateway{}},
+			handler.EnqueueRequestsFromMapFunc(r.listAPISIXConfigurationsForGateway)).
+		Watches(
+			&source.Kind{Type: &apisixoperatorv1alpha1.DataPlane{}},
+			handler.EnqueueRequestsFromMapFunc(r.listAPISIXConfigurationsForGatewayOwnedObject)).
+		Watches(
+			&source.Kind{Type: &apisixoperatorv1alpha1.ControlPlane{}},
+			handler.EnqueueRequestsFromMapFunc(r.listAPISIXConfigurationsForGatewayOwnedObject)).
 		Complete(r)
 }
+
+// Reconcile validates an APISIXConfiguration and reports the state of the
+// configuration for each of its consumers: the GatewayClasses referencing it
+// through their parametersRef and the Gateways of those GatewayClasses. The
+// options themselves are applied to the DataPlanes and ControlPlanes of the
+// Gateways by the Gateway controller.
+func (r *APISIXConfigurationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithName("APISIXConfiguration")
+
+	debug(log, "reconciling APISIXConfiguration resource", req)
+	apisixConfiguration := new(apisixoperatorv1alpha1.APISIXConfiguration)
+	if err := r.Client.Get(ctx, req.NamespacedName, apisixConfiguration); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	oldAPISIXConfiguration := apisixConfiguration.DeepCopy()
+
+	debug(log, "validating APISIXConfiguration resource", apisixConfiguration)
+	validCondition := metav1.Condition{
+		Type:               string(APISIXConfigurationConditionTypeValid),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(APISIXConfigurationConditionReasonValid),
+		ObservedGeneration: apisixConfiguration.Generation,
+	}
+	validationErr := validateAPISIXConfiguration(apisixConfiguration)
+	if validationErr != nil {
+		info(log, "APISIXConfiguration resource is invalid", apisixConfiguration, "error", validationErr)
+		validCondition.Status = metav1.ConditionFalse
+		validCondition.Reason = string(APISIXConfigurationConditionReasonInvalid)
+		validCondition.Message = validationErr.Error()
+	}
+	meta.SetStatusCondition(&apisixConfiguration.Status.Conditions, validCondition)
+
+	debug(log, "determining the state of the APISIXConfiguration for its consumers", apisixConfiguration)
+	consumers, err := r.generateConsumerStatuses(ctx, apisixConfiguration, validationErr)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	apisixConfiguration.Status.Consumers = consumers
+
+	if equality.Semantic.DeepEqual(oldAPISIXConfiguration.Status, apisixConfiguration.Status) {
+		debug(log, "reconciliation complete for APISIXConfiguration resource, status is up to date", apisixConfiguration)
+		return ctrl.Result{}, nil
+	}
+
+	debug(log, "updating APISIXConfiguration resource status", apisixConfiguration, "consumers", len(consumers))
+	if err := r.Client.Status().Update(ctx, apisixConfiguration); err != nil {
+		if k8serrors.IsConflict(err) {
+			debug(log, "conflict found when updating APISIXConfiguration status, retrying", apisixConfiguration)
+			return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	debug(log, "reconciliation complete for APISIXConfiguration resource", apisixConfiguration)
+	return ctrl.Result{}, nil
+}
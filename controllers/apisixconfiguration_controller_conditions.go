@@ -0,0 +1,45 @@
+package controllers
+
+import k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+
+// -----------------------------------------------------------------------------
+// APISIXConfiguration - Status Condition Types
+// -----------------------------------------------------------------------------
+
+const (
+	// APISIXConfigurationConditionTypeValid is a condition type indicating
+	// whether or not the APISIXConfiguration is valid.
+	APISIXConfigurationConditionTypeValid k8sutils.ConditionType = "Valid"
+
+	// APISIXConfigurationConditionTypeApplied is a condition type of the
+	// consumers of an APISIXConfiguration, indicating whether or not the
+	// configuration has been applied to the consumer.
+	APISIXConfigurationConditionTypeApplied k8sutils.ConditionType = "Applied"
+)
+
+// -----------------------------------------------------------------------------
+// APISIXConfiguration - Status Condition Reasons
+// -----------------------------------------------------------------------------
+
+const (
+	// APISIXConfigurationConditionReasonValid is a reason which indicates that
+	// the APISIXConfiguration passed validation.
+	APISIXConfigurationConditionReasonValid k8sutils.ConditionReason = "Valid"
+
+	// APISIXConfigurationConditionReasonInvalid is a reason which indicates why
+	// an APISIXConfiguration, or its options for a consumer, failed validation.
+	APISIXConfigurationConditionReasonInvalid k8sutils.ConditionReason = "Invalid"
+
+	// APISIXConfigurationConditionReasonApplied is a reason which indicates that
+	// the configuration has been applied to a consumer.
+	APISIXConfigurationConditionReasonApplied k8sutils.ConditionReason = "Applied"
+
+	// APISIXConfigurationConditionReasonPending is a reason which indicates that
+	// the configuration has not been applied to a consumer yet.
+	APISIXConfigurationConditionReasonPending k8sutils.ConditionReason = "Pending"
+
+	// APISIXConfigurationConditionReasonGatewayClassNotAccepted is a reason
+	// which indicates that a GatewayClass referencing the configuration has
+	// not been accepted.
+	APISIXConfigurationConditionReasonGatewayClassNotAccepted k8sutils.ConditionReason = "GatewayClassNotAccepted"
+)
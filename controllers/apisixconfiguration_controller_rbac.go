@@ -0,0 +1,15 @@
+package controllers
+
+// -----------------------------------------------------------------------------
+// APISIXConfigurationReconciler - RBAC
+// -----------------------------------------------------------------------------
+
+//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=apisixconfigurations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=apisixconfigurations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=apisixconfigurations/finalizers,verbs=update
+//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=dataplanes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=controlplanes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+	dataplanevalidation "github.com/chever-john/apisix-operator/internal/validation/dataplane"
+)
+
+// -----------------------------------------------------------------------------
+// APISIXConfigurationReconciler - Status Management
+// -----------------------------------------------------------------------------
+
+// generateConsumerStatuses returns the status of every consumer of the
+// provided APISIXConfiguration, preserving the conditions which did not
+// change. An invalid configuration, as reported by validationErr, is not
+// reported as applied to any of its consumers.
+func (r *APISIXConfigurationReconciler) generateConsumerStatuses(
+	ctx context.Context,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	validationErr error,
+) ([]apisixoperatorv1alpha1.APISIXConfigurationConsumerStatus, error) {
+	gatewayClasses := &gatewayv1alpha2.GatewayClassList{}
+	if err := r.Client.List(ctx, gatewayClasses); err != nil {
+		return nil, err
+	}
+	gateways := &gatewayv1alpha2.GatewayList{}
+	if err := r.Client.List(ctx, gateways); err != nil {
+		return nil, err
+	}
+
+	consumers := make([]apisixoperatorv1alpha1.APISIXConfigurationConsumerStatus, 0)
+	for i := range gatewayClasses.Items {
+		gatewayClass := &gatewayClasses.Items[i]
+		if !gatewayutils.IsGatewayClassControlled(gatewayClass, r.ControllerName) ||
+			!gatewayutils.IsParametersRefForAPISIXConfiguration(gatewayClass.Spec.ParametersRef, apisixConfiguration) {
+			continue
+		}
+
+		consumer := newConsumerStatus(apisixConfiguration, apisixoperatorv1alpha1.GatewayConfigureTargetKindGatewayClass, "", gatewayClass.Name)
+		if validationErr != nil {
+			meta.SetStatusCondition(&consumer.Conditions, invalidAppliedCondition(apisixConfiguration, validationErr))
+		} else {
+			meta.SetStatusCondition(&consumer.Conditions, gatewayClassAppliedCondition(apisixConfiguration, gatewayClass))
+		}
+		consumers = append(consumers, consumer)
+
+		for j := range gateways.Items {
+			gateway := &gateways.Items[j]
+			if string(gateway.Spec.GatewayClassName) != gatewayClass.Name {
+				continue
+			}
+
+			var condition metav1.Condition
+			if validationErr != nil {
+				condition = invalidAppliedCondition(apisixConfiguration, validationErr)
+			} else {
+				var err error
+				condition, err = r.gatewayAppliedCondition(ctx, apisixConfiguration, gateway)
+				if err != nil {
+					return nil, err
+				}
+			}
+			consumer := newConsumerStatus(apisixConfiguration, apisixoperatorv1alpha1.GatewayConfigureTargetKindGateway, gateway.Namespace, gateway.Name)
+			meta.SetStatusCondition(&consumer.Conditions, condition)
+			consumers = append(consumers, consumer)
+		}
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		if consumers[i].Kind != consumers[j].Kind {
+			return consumers[i].Kind < consumers[j].Kind
+		}
+		if consumers[i].Namespace != consumers[j].Namespace {
+			return consumers[i].Namespace < consumers[j].Namespace
+		}
+		return consumers[i].Name < consumers[j].Name
+	})
+
+	return consumers, nil
+}
+
+// gatewayAppliedCondition returns the Applied condition of the provided
+// APISIXConfiguration for the provided Gateway: the configuration is applied
+// once the DataPlane and ControlPlane of the Gateway carry its options.
+func (r *APISIXConfigurationReconciler) gatewayAppliedCondition(
+	ctx context.Context,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	gateway *gatewayv1alpha2.Gateway,
+) (metav1.Condition, error) {
+	condition := metav1.Condition{
+		Type:               string(APISIXConfigurationConditionTypeApplied),
+		Status:             metav1.ConditionFalse,
+		Reason:             string(APISIXConfigurationConditionReasonPending),
+		ObservedGeneration: apisixConfiguration.Generation,
+	}
+
+	// the DataPlane of the Gateway lives in the namespace of the Gateway, which
+	// is where the ConfigMaps and Secrets it references are resolved.
+	if opts := apisixConfiguration.Spec.DataPlaneDeploymentOptions; opts != nil {
+		validator := dataplanevalidation.NewValidator(r.Client)
+		if err := validator.ValidateDeployOptions(gateway.Namespace, &opts.DeploymentOptions); err != nil {
+			condition.Reason = string(APISIXConfigurationConditionReasonInvalid)
+			condition.Message = fmt.Sprintf("invalid dataPlaneDeploymentOptions for the Gateway: %s", err)
+			return condition, nil
+		}
+	}
+
+	dataplanes, err := gatewayutils.ListDataPlanesForGateway(ctx, r.Client, gateway)
+	if err != nil {
+		return condition, err
+	}
+	if len(dataplanes) != 1 {
+		condition.Message = "waiting for the DataPlane of the Gateway to be provisioned"
+		return condition, nil
+	}
+	if !dataplaneHasAPISIXConfigurationOptions(&dataplanes[0], apisixConfiguration) {
+		condition.Message = fmt.Sprintf("waiting for DataPlane %s to be updated", dataplanes[0].Name)
+		return condition, nil
+	}
+
+	controlplanes, err := gatewayutils.ListControlPlanesForGateway(ctx, r.Client, gateway)
+	if err != nil {
+		return condition, err
+	}
+	if len(controlplanes) != 1 {
+		condition.Message = "waiting for the ControlPlane of the Gateway to be provisioned"
+		return condition, nil
+	}
+	if !controlplaneHasAPISIXConfigurationOptions(&controlplanes[0], apisixConfiguration) {
+		condition.Message = fmt.Sprintf("waiting for ControlPlane %s to be updated", controlplanes[0].Name)
+		return condition, nil
+	}
+
+	condition.Status = metav1.ConditionTrue
+	condition.Reason = string(APISIXConfigurationConditionReasonApplied)
+	condition.Message = fmt.Sprintf("configuration applied to DataPlane %s and ControlPlane %s", dataplanes[0].Name, controlplanes[0].Name)
+	return condition, nil
+}
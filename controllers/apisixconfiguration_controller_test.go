@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+)
+
+func TestAPISIXConfigurationReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+	require.NoError(t, gatewayv1alpha2.AddToScheme(scheme))
+
+	namespace := gatewayv1alpha2.Namespace("default")
+	image := "apache/apisix-ingress-controller"
+	dataplaneName := "test-dataplane"
+	configuredEnv := corev1.EnvVar{Name: "CONTROLLER_LOG_LEVEL", Value: "debug"}
+
+	newObjects := func(controlplaneEnv []corev1.EnvVar, dataplaneOverride *string) []client.Object {
+		gatewayOwnerRef := []metav1.OwnerReference{{
+			APIVersion: gatewayv1alpha2.GroupVersion.String(),
+			Kind:       "Gateway",
+			Name:       "test-gateway",
+			UID:        "test-gateway-uid",
+		}}
+		gatewayLabels := map[string]string{consts.GatewayOperatorControlledLabel: consts.GatewayManagedLabelValue}
+
+		return []client.Object{
+			&apisixoperatorv1alpha1.APISIXConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-config", Generation: 1},
+				Spec: apisixoperatorv1alpha1.APISIXConfigurationSpec{
+					ControlPlaneDeploymentOptions: &apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+						DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+							ContainerImage: &image,
+							Env:            []corev1.EnvVar{configuredEnv},
+						},
+						DataPlane: dataplaneOverride,
+					},
+				},
+			},
+			&gatewayv1alpha2.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1alpha2.GatewayClassSpec{
+					ControllerName: consts.DefaultControllerName,
+					ParametersRef: &gatewayv1alpha2.ParametersReference{
+						Group:     gatewayv1alpha2.Group(apisixoperatorv1alpha1.SchemeGroupVersion.Group),
+						Kind:      "APISIXConfiguration",
+						Name:      "test-config",
+						Namespace: &namespace,
+					},
+				},
+				Status: gatewayv1alpha2.GatewayClassStatus{
+					Conditions: []metav1.Condition{{
+						Type:   string(gatewayv1alpha2.GatewayClassConditionStatusAccepted),
+						Status: metav1.ConditionTrue,
+						Reason: string(gatewayv1alpha2.GatewayClassReasonAccepted),
+					}},
+				},
+			},
+			&gatewayv1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-gateway", UID: "test-gateway-uid"},
+				Spec:       gatewayv1alpha2.GatewaySpec{GatewayClassName: "test-gatewayclass"},
+			},
+			&apisixoperatorv1alpha1.DataPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "default",
+					Name:            dataplaneName,
+					Labels:          gatewayLabels,
+					OwnerReferences: gatewayOwnerRef,
+				},
+			},
+			&apisixoperatorv1alpha1.ControlPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "default",
+					Name:            "test-controlplane",
+					Labels:          gatewayLabels,
+					OwnerReferences: gatewayOwnerRef,
+				},
+				Spec: apisixoperatorv1alpha1.ControlPlaneSpec{
+					ControlPlaneDeploymentOptions: apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+						DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+							ContainerImage: &image,
+							Env:            controlplaneEnv,
+						},
+						DataPlane: &dataplaneName,
+					},
+				},
+			},
+		}
+	}
+
+	for _, tt := range []struct {
+		name                       string
+		objects                    []client.Object
+		expectedValid              metav1.ConditionStatus
+		expectedGatewayReason      k8sutils.ConditionReason
+		expectedGatewayClassReason k8sutils.ConditionReason
+	}{
+		{
+			name: "configuration applied to the gateway",
+			objects: newObjects([]corev1.EnvVar{
+				{Name: "POD_NAME", Value: "test"},
+				configuredEnv,
+			}, nil),
+			expectedValid:              metav1.ConditionTrue,
+			expectedGatewayReason:      APISIXConfigurationConditionReasonApplied,
+			expectedGatewayClassReason: APISIXConfigurationConditionReasonApplied,
+		},
+		{
+			name:                       "configuration not applied to the controlplane yet",
+			objects:                    newObjects(nil, nil),
+			expectedValid:              metav1.ConditionTrue,
+			expectedGatewayReason:      APISIXConfigurationConditionReasonPending,
+			expectedGatewayClassReason: APISIXConfigurationConditionReasonApplied,
+		},
+		{
+			// the options are carried by the ControlPlane, but the configuration
+			// must not be reported as applied anyway.
+			name:                       "invalid configuration",
+			objects:                    newObjects([]corev1.EnvVar{configuredEnv}, &dataplaneName),
+			expectedValid:              metav1.ConditionFalse,
+			expectedGatewayReason:      APISIXConfigurationConditionReasonInvalid,
+			expectedGatewayClassReason: APISIXConfigurationConditionReasonInvalid,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objects...).Build()
+			r := &APISIXConfigurationReconciler{
+				Client:         c,
+				Scheme:         scheme,
+				ControllerName: consts.DefaultControllerName,
+			}
+
+			nn := types.NamespacedName{Namespace: "default", Name: "test-config"}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: nn})
+			require.NoError(t, err)
+
+			apisixConfiguration := &apisixoperatorv1alpha1.APISIXConfiguration{}
+			require.NoError(t, c.Get(context.Background(), nn, apisixConfiguration))
+
+			valid := meta.FindStatusCondition(apisixConfiguration.Status.Conditions, string(APISIXConfigurationConditionTypeValid))
+			require.NotNil(t, valid)
+			require.Equal(t, tt.expectedValid, valid.Status)
+
+			require.Len(t, apisixConfiguration.Status.Consumers, 2)
+			gatewayConsumer := apisixConfiguration.Status.Consumers[0]
+			require.Equal(t, apisixoperatorv1alpha1.GatewayConfigureTargetKindGateway, gatewayConsumer.Kind)
+			require.Equal(t, "default", gatewayConsumer.Namespace)
+			require.Equal(t, "test-gateway", gatewayConsumer.Name)
+			applied := meta.FindStatusCondition(gatewayConsumer.Conditions, string(APISIXConfigurationConditionTypeApplied))
+			require.NotNil(t, applied)
+			require.Equal(t, string(tt.expectedGatewayReason), applied.Reason)
+			require.Equal(t, tt.expectedGatewayReason == APISIXConfigurationConditionReasonApplied, applied.Status == metav1.ConditionTrue)
+
+			gatewayClassConsumer := apisixConfiguration.Status.Consumers[1]
+			require.Equal(t, apisixoperatorv1alpha1.GatewayConfigureTargetKindGatewayClass, gatewayClassConsumer.Kind)
+			require.Equal(t, "test-gatewayclass", gatewayClassConsumer.Name)
+			applied = meta.FindStatusCondition(gatewayClassConsumer.Conditions, string(APISIXConfigurationConditionTypeApplied))
+			require.NotNil(t, applied)
+			require.Equal(t, string(tt.expectedGatewayClassReason), applied.Reason)
+			require.Equal(t, tt.expectedGatewayClassReason == APISIXConfigurationConditionReasonApplied, applied.Status == metav1.ConditionTrue)
+		})
+	}
+}
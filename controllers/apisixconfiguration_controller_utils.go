@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	controlplaneutils "github.com/chever-john/apisix-operator/internal/utils/controlplane"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+)
+
+// -----------------------------------------------------------------------------
+// APISIXConfiguration - Private Functions - Validation
+// -----------------------------------------------------------------------------
+
+// validateAPISIXConfiguration validates the options of the provided
+// APISIXConfiguration which do not depend on its consumers.
+func validateAPISIXConfiguration(apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration) error {
+	if opts := apisixConfiguration.Spec.ControlPlaneDeploymentOptions; opts != nil && opts.DataPlane != nil {
+		return fmt.Errorf("controlPlaneDeploymentOptions.dataplane can't be set: " +
+			"the ControlPlane of a Gateway always manages the DataPlane of the Gateway")
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// APISIXConfiguration - Private Functions - Status Management
+// -----------------------------------------------------------------------------
+
+// newConsumerStatus returns the status of a consumer of the provided
+// APISIXConfiguration, initialized with the conditions currently reported
+// for that consumer.
+func newConsumerStatus(
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	kind apisixoperatorv1alpha1.GatewayConfigureTargetKind,
+	namespace, name string,
+) apisixoperatorv1alpha1.APISIXConfigurationConsumerStatus {
+	consumer := apisixoperatorv1alpha1.APISIXConfigurationConsumerStatus{
+		Kind:       kind,
+		Namespace:  namespace,
+		Name:       name,
+		Conditions: []metav1.Condition{},
+	}
+	for _, existing := range apisixConfiguration.Status.Consumers {
+		if existing.Kind == kind && existing.Namespace == namespace && existing.Name == name {
+			consumer.Conditions = append(consumer.Conditions, existing.Conditions...)
+		}
+	}
+	return consumer
+}
+
+// invalidAppliedCondition returns the Applied condition of the provided
+// APISIXConfiguration for its consumers when it failed validation.
+func invalidAppliedCondition(
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	validationErr error,
+) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(APISIXConfigurationConditionTypeApplied),
+		Status:             metav1.ConditionFalse,
+		Reason:             string(APISIXConfigurationConditionReasonInvalid),
+		Message:            fmt.Sprintf("invalid configuration: %s", validationErr),
+		ObservedGeneration: apisixConfiguration.Generation,
+	}
+}
+
+// gatewayClassAppliedCondition returns the Applied condition of the provided
+// APISIXConfiguration for the provided GatewayClass: the configuration is
+// applied once the GatewayClass is accepted.
+func gatewayClassAppliedCondition(
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	gatewayClass *gatewayv1alpha2.GatewayClass,
+) metav1.Condition {
+	if !gatewayutils.IsAccepted(gatewayClass) {
+		return metav1.Condition{
+			Type:               string(APISIXConfigurationConditionTypeApplied),
+			Status:             metav1.ConditionFalse,
+			Reason:             string(APISIXConfigurationConditionReasonGatewayClassNotAccepted),
+			Message:            fmt.Sprintf("GatewayClass %s is not accepted", gatewayClass.Name),
+			ObservedGeneration: apisixConfiguration.Generation,
+		}
+	}
+	return metav1.Condition{
+		Type:               string(APISIXConfigurationConditionTypeApplied),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(APISIXConfigurationConditionReasonApplied),
+		Message:            fmt.Sprintf("GatewayClass %s is accepted", gatewayClass.Name),
+		ObservedGeneration: apisixConfiguration.Generation,
+	}
+}
+
+// -----------------------------------------------------------------------------
+// APISIXConfiguration - Private Functions - Equality Checks
+// -----------------------------------------------------------------------------
+
+// dataplaneHasAPISIXConfigurationOptions returns true if the provided DataPlane
+// is configured with the DataPlane options of the provided APISIXConfiguration.
+// The defaults are applied to both beforehand, as the DataPlane may or may not
+// have been defaulted on admission.
+func dataplaneHasAPISIXConfigurationOptions(
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+) bool {
+	opts := &apisixoperatorv1alpha1.DataPlaneDeploymentOptions{}
+	if apisixConfiguration.Spec.DataPlaneDeploymentOptions != nil {
+		opts = apisixConfiguration.Spec.DataPlaneDeploymentOptions.DeepCopy()
+	}
+	dataplaneutils.SetDataPlaneDefaults(opts)
+
+	existing := dataplane.Spec.DataPlaneDeploymentOptions.DeepCopy()
+	dataplaneutils.SetDataPlaneDefaults(existing)
+
+	return dataplaneSpecDeepEqual(existing, opts)
+}
+
+// controlplaneHasAPISIXConfigurationOptions returns true if the provided
+// ControlPlane is configured with the ControlPlane options of the provided
+// APISIXConfiguration. The defaults are applied to both beforehand, as the
+// ControlPlane may or may not have been defaulted on admission. The
+// ControlPlane environment may also contain the variables pointing to its
+// DataPlane, so the configured environment only needs to be part of it.
+func controlplaneHasAPISIXConfigurationOptions(
+	controlplane *apisixoperatorv1alpha1.ControlPlane,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+) bool {
+	opts := &apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{}
+	if apisixConfiguration.Spec.ControlPlaneDeploymentOptions != nil {
+		opts = apisixConfiguration.Spec.ControlPlaneDeploymentOptions.DeepCopy()
+	}
+	controlplaneutils.SetControlPlaneDefaults(opts)
+
+	spec := controlplane.Spec.ControlPlaneDeploymentOptions.DeepCopy()
+	controlplaneutils.SetControlPlaneDefaults(spec)
+
+	if !reflect.DeepEqual(spec.ContainerImage, opts.ContainerImage) ||
+		!reflect.DeepEqual(spec.Version, opts.Version) ||
+		!reflect.DeepEqual(spec.EnvFrom, opts.EnvFrom) ||
+		!reflect.DeepEqual(spec.PodTemplateSpec, opts.PodTemplateSpec) ||
+		!reflect.DeepEqual(spec.PodDisruptionBudget, opts.PodDisruptionBudget) {
+		return false
+	}
+
+	for _, envVar := range opts.Env {
+		found := false
+		for _, existing := range spec.Env {
+			if reflect.DeepEqual(envVar, existing) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+)
+
+// -----------------------------------------------------------------------------
+// APISIXConfigurationReconciler - Watch Map Funcs
+// -----------------------------------------------------------------------------
+
+func (r *APISIXConfigurationReconciler) listAPISIXConfigurationsForGatewayClass(obj client.Object) (recs []reconcile.Request) {
+	gatewayClass, ok := obj.(*gatewayv1alpha2.GatewayClass)
+	if !ok {
+		log.FromContext(context.Background()).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "GatewayClass", "found", reflect.TypeOf(obj),
+		)
+		return
+	}
+
+	return apisixConfigurationRequestsForGatewayClass(gatewayClass, r.ControllerName)
+}
+
+func (r *APISIXConfigurationReconciler) listAPISIXConfigurationsForGateway(obj client.Object) (recs []reconcile.Request) {
+	ctx := context.Background()
+	gateway, ok := obj.(*gatewayv1alpha2.Gateway)
+	if !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "Gateway", "found", reflect.TypeOf(obj),
+		)
+		return
+	}
+
+	gatewayClass := &gatewayv1alpha2.GatewayClass{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: string(gateway.Spec.GatewayClassName)}, gatewayClass); err != nil {
+		log.FromContext(ctx).Error(err, "could not retrieve gatewayclass in map func")
+		return
+	}
+
+	return apisixConfigurationRequestsForGatewayClass(gatewayClass, r.ControllerName)
+}
+
+// listAPISIXConfigurationsForGatewayOwnedObject maps the DataPlanes and
+// ControlPlanes owned by Gateways to the APISIXConfiguration of the Gateway.
+func (r *APISIXConfigurationReconciler) listAPISIXConfigurationsForGatewayOwnedObject(obj client.Object) (recs []reconcile.Request) {
+	ctx := context.Background()
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.Kind != "Gateway" {
+			continue
+		}
+
+		gateway := &gatewayv1alpha2.Gateway{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: ownerRef.Name}, gateway); err != nil {
+			log.FromContext(ctx).Error(err, "could not retrieve gateway in map func")
+			return
+		}
+		recs = append(recs, r.listAPISIXConfigurationsForGateway(gateway)...)
+	}
+	return
+}
+
+// apisixConfigurationRequestsForGatewayClass returns a request for the
+// APISIXConfiguration referenced by the provided GatewayClass, if any.
+func apisixConfigurationRequestsForGatewayClass(
+	gatewayClass *gatewayv1alpha2.GatewayClass,
+	controllerName string,
+) []reconcile.Request {
+	if !gatewayutils.IsGatewayClassControlled(gatewayClass, controllerName) {
+		return nil
+	}
+
+	ref := gatewayClass.Spec.ParametersRef
+	if ref == nil || ref.Namespace == nil ||
+		string(ref.Group) != apisixoperatorv1alpha1.SchemeGroupVersion.Group ||
+		string(ref.Kind) != gatewayutils.APISIXConfigurationKind {
+		return nil
+	}
+
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{
+			Namespace: string(*ref.Namespace),
+			Name:      ref.Name,
+		},
+	}}
+}
@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	certificatesv1client "k8s.io/client-go/kubernetes/typed/certificates/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/chever-john/apisix-operator/internal/consts"
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+)
+
+// -----------------------------------------------------------------------------
+// CertificateSigningRequestReconciler
+// -----------------------------------------------------------------------------
+
+// CertificateSigningRequestReconciler is the signer of the
+// CertificateSigningRequests submitted for the mTLS certificates of
+// ControlPlanes and DataPlanes: it approves the requests made by the operator
+// and signs them with the cluster CA.
+type CertificateSigningRequestReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// CertificatesClient is used to approve CertificateSigningRequests, which
+	// is only possible through their approval subresource.
+	CertificatesClient       certificatesv1client.CertificateSigningRequestInterface
+	ClusterCASecretName      string
+	ClusterCASecretNamespace string
+	// OperatorUsername is the username the operator authenticates to the API
+	// server as, which the approved CertificateSigningRequests must be made by.
+	OperatorUsername string
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CertificateSigningRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// watch CertificateSigningRequests, filtering out the ones which are
+		// not addressed to the operator signer.
+		For(&certificatesv1.CertificateSigningRequest{},
+			builder.WithPredicates(predicate.NewPredicateFuncs(isOperatorCertificateSigningRequest))).
+		Complete(r)
+}
+
+// Reconcile approves the CertificateSigningRequests addressed to the operator
+// signer which were made by the operator for the owners of the Secrets they
+// reference, denies the other ones, and signs the approved ones with the
+// cluster CA once verified again, as they may have been approved by others.
+func (r *CertificateSigningRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithName("CertificateSigningRequest")
+
+	debug(log, "reconciling CertificateSigningRequest resource", req)
+	csr := new(certificatesv1.CertificateSigningRequest)
+	if err := r.Client.Get(ctx, req.NamespacedName, csr); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !isOperatorCertificateSigningRequest(csr) {
+		return ctrl.Result{}, nil
+	}
+	if len(csr.Status.Certificate) > 0 {
+		debug(log, "CertificateSigningRequest already issued", csr)
+		return ctrl.Result{}, nil
+	}
+	if _, failed := certificateSigningRequestFailure(csr); failed {
+		debug(log, "CertificateSigningRequest denied or failed", csr)
+		return ctrl.Result{}, nil
+	}
+
+	if !certificateSigningRequestIsApproved(csr) {
+		condition := certificatesv1.CertificateSigningRequestCondition{
+			Type:               certificatesv1.CertificateApproved,
+			Status:             corev1.ConditionTrue,
+			Reason:             "AutoApproved",
+			Message:            "request made by the APISIX operator",
+			LastUpdateTime:     metav1.Now(),
+			LastTransitionTime: metav1.Now(),
+		}
+		reason, err := r.verifyCertificateSigningRequest(ctx, csr)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if reason != "" {
+			info(log, "denying CertificateSigningRequest", csr, "reason", reason)
+			condition.Type = certificatesv1.CertificateDenied
+			condition.Reason = "RequestInvalid"
+			condition.Message = reason
+		} else {
+			info(log, "approving CertificateSigningRequest", csr)
+		}
+		csr.Status.Conditions = append(csr.Status.Conditions, condition)
+		// the update of the approval triggers a new reconciliation to sign the request.
+		_, err = r.CertificatesClient.UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+		return r.handleUpdateError(log, csr, err)
+	}
+
+	ca := &corev1.Secret{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: r.ClusterCASecretNamespace, Name: r.ClusterCASecretName}, ca); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// requests can be approved by anyone allowed to, e.g. with kubectl certificate
+	// approve, hence they are verified again before being signed.
+	failureReason := "RequestInvalid"
+	reason, err := r.verifyCertificateSigningRequest(ctx, csr)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	var certificate []byte
+	if reason == "" {
+		info(log, "signing CertificateSigningRequest", csr)
+		certificate, err = signCertificate(*csr, ca)
+		if err != nil {
+			failureReason, reason = "SigningError", err.Error()
+		}
+	}
+	if reason != "" {
+		info(log, "failing CertificateSigningRequest", csr, "reason", reason)
+		csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:               certificatesv1.CertificateFailed,
+			Status:             corev1.ConditionTrue,
+			Reason:             failureReason,
+			Message:            reason,
+			LastUpdateTime:     metav1.Now(),
+			LastTransitionTime: metav1.Now(),
+		})
+	} else {
+		csr.Status.Certificate = certificate
+	}
+	return r.handleUpdateError(log, csr, r.Client.Status().Update(ctx, csr))
+}
+
+func (r *CertificateSigningRequestReconciler) handleUpdateError(
+	log logr.Logger,
+	csr *certificatesv1.CertificateSigningRequest,
+	err error,
+) (ctrl.Result, error) {
+	if k8serrors.IsConflict(err) {
+		// no need to throw an error for 409's, just requeue to get a fresh copy
+		debug(log, "conflict during CertificateSigningRequest reconciliation", csr)
+		return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+// -----------------------------------------------------------------------------
+// CertificateSigningRequestReconciler - Verification
+// -----------------------------------------------------------------------------
+
+// allowedCertificateUsages are the key usages the operator requests for the
+// mTLS certificates of ControlPlanes and DataPlanes.
+var allowedCertificateUsages = map[certificatesv1.KeyUsage]struct{}{
+	certificatesv1.UsageKeyEncipherment:  {},
+	certificatesv1.UsageDigitalSignature: {},
+	certificatesv1.UsageServerAuth:       {},
+	certificatesv1.UsageClientAuth:       {},
+}
+
+// verifyCertificateSigningRequest returns the reason why the provided
+// CertificateSigningRequest has to be denied, if it was not made by the
+// operator or not for the owner of the Secret it references. The operator
+// makes the requests with the private key stored in the mTLS Secret it manages
+// referenced by the request, which can't be done by anyone who can't read it,
+// for the subject expected for the owner of the Secret.
+func (r *CertificateSigningRequestReconciler) verifyCertificateSigningRequest(
+	ctx context.Context,
+	csr *certificatesv1.CertificateSigningRequest,
+) (string, error) {
+	// the username is set by the API server to the user who made the request.
+	if csr.Spec.Username != r.OperatorUsername {
+		return fmt.Sprintf("request was made by %q, not by the operator", csr.Spec.Username), nil
+	}
+
+	for _, usage := range csr.Spec.Usages {
+		if _, ok := allowedCertificateUsages[usage]; !ok {
+			return fmt.Sprintf("key usage %q is not allowed", usage), nil
+		}
+	}
+
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return "request is not a PEM encoded certificate request", nil
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return fmt.Sprintf("invalid certificate request: %v", err), nil
+	}
+	if err := request.CheckSignature(); err != nil {
+		return fmt.Sprintf("invalid certificate request signature: %v", err), nil
+	}
+
+	secretName, ok := parseNamespacedName(csr.Annotations[consts.CertificateSecretAnnotation])
+	if !ok {
+		return fmt.Sprintf("annotation %s must reference the Secret the request was made for", consts.CertificateSecretAnnotation), nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, secretName, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return fmt.Sprintf("Secret %s not found", secretName), nil
+		}
+		return "", err
+	}
+	if _, ok := secret.Labels[consts.GatewayOperatorControlledLabel]; !ok {
+		return fmt.Sprintf("Secret %s is not managed by the operator", secretName), nil
+	}
+	priv, err := parsePrivateKeyPEM(secret.Data["tls.key"])
+	if err != nil {
+		return fmt.Sprintf("invalid private key in Secret %s: %v", secretName, err), nil
+	}
+	if !priv.PublicKey.Equal(request.PublicKey) {
+		return fmt.Sprintf("request was not made with the private key of Secret %s", secretName), nil
+	}
+
+	subject, err := r.expectedCertificateSubject(ctx, secret)
+	if err != nil {
+		return "", err
+	}
+	if subject == "" {
+		return fmt.Sprintf("no certificate subject is expected for the owner of Secret %s", secretName), nil
+	}
+	if request.Subject.CommonName != subject ||
+		len(request.DNSNames) != 1 || request.DNSNames[0] != subject ||
+		len(request.IPAddresses) > 0 || len(request.URIs) > 0 || len(request.EmailAddresses) > 0 {
+		return fmt.Sprintf("request must be made for %s only, the subject expected for the owner of Secret %s", subject, secretName), nil
+	}
+
+	return "", nil
+}
+
+// expectedCertificateSubject returns the subject of the certificate the operator
+// requests for the provided Secret, which depends on its owner: the certificates
+// served by DataPlanes and etcd clusters are issued for the Services exposing
+// them, while the client certificates are issued for the name of their owner.
+// An empty subject is returned if none is expected.
+func (r *CertificateSigningRequestReconciler) expectedCertificateSubject(
+	ctx context.Context,
+	secret *corev1.Secret,
+) (string, error) {
+	owner := metav1.GetControllerOf(secret)
+	if owner == nil {
+		return "", nil
+	}
+
+	var serviceLabelValue string
+	switch secret.Labels[consts.GatewayOperatorControlledLabel] {
+	case consts.ControlPlaneManagedLabelValue, consts.EtcdClientManagedLabelValue:
+		return fmt.Sprintf("%s.%s", owner.Name, secret.Namespace), nil
+	case consts.DataPlaneManagedLabelValue:
+		serviceLabelValue = consts.AdminServiceManagedLabelValue
+	case consts.EtcdManagedLabelValue:
+		serviceLabelValue = consts.EtcdManagedLabelValue
+	default:
+		return "", nil
+	}
+
+	services, err := k8sutils.ListServicesForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		serviceLabelValue,
+		secret.Namespace,
+		owner.UID,
+	)
+	if err != nil || len(services) != 1 {
+		return "", err
+	}
+	if serviceLabelValue == consts.EtcdManagedLabelValue {
+		return etcdCertificateSubject(secret.Namespace, services[0].Name), nil
+	}
+	return dataplaneCertificateSubject(secret.Namespace, services[0].Name), nil
+}
+
+// -----------------------------------------------------------------------------
+// CertificateSigningRequestReconciler - Private Functions
+// -----------------------------------------------------------------------------
+
+func isOperatorCertificateSigningRequest(obj client.Object) bool {
+	csr, ok := obj.(*certificatesv1.CertificateSigningRequest)
+	return ok && csr.Spec.SignerName == consts.MTLSCertificateSignerName
+}
+
+func certificateSigningRequestIsApproved(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1.CertificateApproved && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+package controllers
+
+// -----------------------------------------------------------------------------
+// CertificateSigningRequestReconciler - RBAC
+// -----------------------------------------------------------------------------
+
+//+kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests,verbs=create;get;list;watch
+//+kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests/approval,verbs=update
+//+kubebuilder:rbac:groups=certificates.k8s.io,resources=certificatesigningrequests/status,verbs=update
+//+kubebuilder:rbac:groups=certificates.k8s.io,resources=signers,resourceNames=apisix-operator.apisix.apache.org/mtls,verbs=approve;sign
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
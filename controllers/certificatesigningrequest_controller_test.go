@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+)
+
+func TestCertificateSigningRequestReconciler_Reconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	ca := newTestCASecret(t, "ca")
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+	}
+	usages := []certificatesv1.KeyUsage{certificatesv1.UsageServerAuth}
+	// the certificate of the DataPlane is issued for its Admin API Service.
+	adminService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "test",
+			Labels:          map[string]string{consts.GatewayOperatorControlledLabel: consts.AdminServiceManagedLabelValue},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "DataPlane", Name: "test", UID: "test-uid"}},
+		},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(ca, dataplane, adminService).Build()
+	ctx := context.Background()
+	operatorUsername := "system:serviceaccount:apisix-operator-system:apisix-operator-controller-manager"
+
+	issuer := newCertificateIssuer(nil, true, ca.Name, ca.Namespace, 24*time.Hour, time.Hour)
+	ensureForSubject := func(subject string) (bool, *corev1.Secret, error) {
+		return maybeCreateCertificateSecret(ctx, dataplane, subject, usages, issuer, c)
+	}
+	ensure := func() (bool, *corev1.Secret) {
+		createdOrUpdated, secret, err := ensureForSubject("test.default.svc")
+		require.NoError(t, err)
+		return createdOrUpdated, secret
+	}
+
+	// sign runs the signer against the CertificateSigningRequest submitted for the
+	// DataPlane, and returns it once processed.
+	sign := func() *certificatesv1.CertificateSigningRequest {
+		csrs := &certificatesv1.CertificateSigningRequestList{}
+		require.NoError(t, c.List(ctx, csrs))
+		require.Len(t, csrs.Items, 1)
+		csr := &csrs.Items[0]
+		// the username is set by the API server to the user who made the request.
+		if csr.Spec.Username == "" {
+			csr.Spec.Username = operatorUsername
+			require.NoError(t, c.Update(ctx, csr))
+		}
+
+		clientset := fakeclientset.NewSimpleClientset(csr.DeepCopy())
+		r := &CertificateSigningRequestReconciler{
+			Client:                   c,
+			Scheme:                   scheme,
+			CertificatesClient:       clientset.CertificatesV1().CertificateSigningRequests(),
+			ClusterCASecretName:      ca.Name,
+			ClusterCASecretNamespace: ca.Namespace,
+			OperatorUsername:         operatorUsername,
+		}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: csr.Name}}
+
+		t.Log("approving the CertificateSigningRequest")
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		approved, err := clientset.CertificatesV1().CertificateSigningRequests().Get(ctx, csr.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		// the approval subresource is only served by the clientset.
+		csr.Status.Conditions = approved.Status.Conditions
+		require.NoError(t, c.Status().Update(ctx, csr))
+		if !certificateSigningRequestIsApproved(csr) {
+			return csr
+		}
+
+		t.Log("signing the CertificateSigningRequest")
+		_, err = r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(csr), csr))
+		return csr
+	}
+
+	t.Log("generating the private key of the DataPlane")
+	createdOrUpdated, secret := ensure()
+	require.True(t, createdOrUpdated)
+	require.Nil(t, secret)
+
+	t.Log("submitting a CertificateSigningRequest for the DataPlane")
+	createdOrUpdated, secret = ensure()
+	require.True(t, createdOrUpdated)
+	require.Nil(t, secret)
+
+	csr := sign()
+	require.True(t, certificateSigningRequestIsApproved(csr))
+	require.NotEmpty(t, csr.Status.Certificate)
+	require.Equal(t, "default/test", csr.Annotations[consts.CertificateOwnerAnnotation])
+	require.Equal(t, consts.MTLSCertificateSignerName, csr.Spec.SignerName)
+
+	t.Log("storing the issued certificate in the Secret of the DataPlane")
+	createdOrUpdated, secret = ensure()
+	require.True(t, createdOrUpdated)
+	require.NotNil(t, secret)
+	require.Equal(t, csr.Status.Certificate, secret.Data["tls.crt"])
+	require.Equal(t, ca.Data["tls.crt"], secret.Data["ca.crt"])
+	require.NotContains(t, secret.Annotations, consts.CertificateSigningRequestAnnotation)
+	cert, err := parseCertificateSecret(secret)
+	require.NoError(t, err)
+	priv, err := parsePrivateKeyPEM(secret.Data["tls.key"])
+	require.NoError(t, err)
+	require.True(t, priv.PublicKey.Equal(cert.PublicKey))
+
+	createdOrUpdated, _ = ensure()
+	require.False(t, createdOrUpdated)
+
+	t.Log("denying a CertificateSigningRequest which was not made with the key of the Secret it references")
+	require.NoError(t, c.Delete(ctx, csr))
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	forged, err := generateCertificateSigningRequest(dataplane, "test.default.svc", usages, time.Hour, otherKey)
+	require.NoError(t, err)
+	forged.ObjectMeta = metav1.ObjectMeta{
+		Name: "forged",
+		Annotations: map[string]string{
+			consts.CertificateSecretAnnotation: client.ObjectKeyFromObject(secret).String(),
+		},
+	}
+	require.NoError(t, c.Create(ctx, forged))
+	csr = sign()
+	require.False(t, certificateSigningRequestIsApproved(csr))
+	condition, failed := certificateSigningRequestFailure(csr)
+	require.True(t, failed)
+	require.Equal(t, certificatesv1.CertificateDenied, condition.Type)
+	require.Empty(t, csr.Status.Certificate)
+
+	t.Log("denying a CertificateSigningRequest which was not made by the operator")
+	require.NoError(t, c.Delete(ctx, csr))
+	priv, err = parsePrivateKeyPEM(secret.Data["tls.key"])
+	require.NoError(t, err)
+	forged, err = generateCertificateSigningRequest(dataplane, "test.default.svc", usages, time.Hour, priv)
+	require.NoError(t, err)
+	forged.ObjectMeta = metav1.ObjectMeta{
+		Name: "forged",
+		Annotations: map[string]string{
+			consts.CertificateSecretAnnotation: client.ObjectKeyFromObject(secret).String(),
+		},
+	}
+	forged.Spec.Username = "system:serviceaccount:default:default"
+	require.NoError(t, c.Create(ctx, forged))
+	csr = sign()
+	require.False(t, certificateSigningRequestIsApproved(csr))
+	condition, failed = certificateSigningRequestFailure(csr)
+	require.True(t, failed)
+	require.Contains(t, condition.Message, "not by the operator")
+	require.NoError(t, c.Delete(ctx, csr))
+
+	t.Log("denying a CertificateSigningRequest which was not made for the Service of the DataPlane")
+	createdOrUpdated, _, err = ensureForSubject("other.default.svc")
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	csr = sign()
+	require.False(t, certificateSigningRequestIsApproved(csr))
+	condition, failed = certificateSigningRequestFailure(csr)
+	require.True(t, failed)
+	require.Contains(t, condition.Message, "test.default.svc")
+
+	t.Log("reporting the denied CertificateSigningRequest instead of submitting a new one")
+	for i := 0; i < 2; i++ {
+		_, _, err = ensureForSubject("other.default.svc")
+		require.ErrorIs(t, err, operatorerrors.ErrCertificateNotIssued)
+	}
+	csrs := &certificatesv1.CertificateSigningRequestList{}
+	require.NoError(t, c.List(ctx, csrs))
+	require.Len(t, csrs.Items, 1)
+	require.NoError(t, c.Delete(ctx, &csrs.Items[0]))
+
+	// signApproved runs the signer against a CertificateSigningRequest approved
+	// by someone else than the operator, and returns it once processed.
+	signApproved := func(csr *certificatesv1.CertificateSigningRequest) *certificatesv1.CertificateSigningRequest {
+		csr.Status.Conditions = []certificatesv1.CertificateSigningRequestCondition{{
+			Type:   certificatesv1.CertificateApproved,
+			Status: corev1.ConditionTrue,
+			Reason: "KubectlApprove",
+		}}
+		require.NoError(t, c.Create(ctx, csr))
+		r := &CertificateSigningRequestReconciler{
+			Client:                   c,
+			Scheme:                   scheme,
+			CertificatesClient:       fakeclientset.NewSimpleClientset().CertificatesV1().CertificateSigningRequests(),
+			ClusterCASecretName:      ca.Name,
+			ClusterCASecretNamespace: ca.Namespace,
+			OperatorUsername:         operatorUsername,
+		}
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: csr.Name}})
+		require.NoError(t, err)
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(csr), csr))
+		return csr
+	}
+
+	t.Log("not signing an approved CertificateSigningRequest which was not made by the operator")
+	forged, err = generateCertificateSigningRequest(dataplane, "test.default.svc", usages, time.Hour, priv)
+	require.NoError(t, err)
+	forged.ObjectMeta = metav1.ObjectMeta{
+		Name: "approved-forged",
+		Annotations: map[string]string{
+			consts.CertificateSecretAnnotation: client.ObjectKeyFromObject(secret).String(),
+		},
+	}
+	forged.Spec.Username = "system:serviceaccount:default:default"
+	csr = signApproved(forged)
+	require.Empty(t, csr.Status.Certificate)
+	condition, failed = certificateSigningRequestFailure(csr)
+	require.True(t, failed)
+	require.Equal(t, certificatesv1.CertificateFailed, condition.Type)
+	require.Contains(t, condition.Message, "not by the operator")
+
+	t.Log("signing an approved CertificateSigningRequest without expiration for the default lifetime")
+	approved, err := generateCertificateSigningRequest(dataplane, "test.default.svc", usages, time.Hour, priv)
+	require.NoError(t, err)
+	approved.ObjectMeta = metav1.ObjectMeta{
+		Name: "approved",
+		Annotations: map[string]string{
+			consts.CertificateSecretAnnotation: client.ObjectKeyFromObject(secret).String(),
+		},
+	}
+	approved.Spec.Username = operatorUsername
+	approved.Spec.ExpirationSeconds = nil
+	csr = signApproved(approved)
+	_, failed = certificateSigningRequestFailure(csr)
+	require.False(t, failed)
+	require.NotEmpty(t, csr.Status.Certificate)
+	cert, err = parseCertificateSecret(&corev1.Secret{Data: map[string][]byte{"tls.crt": csr.Status.Certificate}})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(consts.DefaultClusterCertificateLifetime), cert.NotAfter, time.Hour)
+}
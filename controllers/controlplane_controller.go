@@ -6,7 +6,9 @@ import (
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,6 +39,20 @@ type ControlPlaneReconciler struct {
 	Scheme                   *runtime.Scheme
 	ClusterCASecretName      string
 	ClusterCASecretNamespace string
+	// ClusterCertificateLifetime is the validity of the mTLS certificates
+	// issued to ControlPlanes.
+	ClusterCertificateLifetime time.Duration
+	// ClusterCertificateRenewBefore is how long before their expiration the
+	// mTLS certificates issued to ControlPlanes are re-issued.
+	ClusterCertificateRenewBefore time.Duration
+	// CertManagerIssuer references the cert-manager issuer of the mTLS
+	// certificates issued to ControlPlanes. They are signed by the cluster CA
+	// if it's not set.
+	CertManagerIssuer *CertManagerIssuerRef
+	// UseCertificateSigningRequests makes the mTLS certificates issued to
+	// ControlPlanes by the cluster CA requested through the
+	// CertificateSigningRequest API rather than signed in memory.
+	UseCertificateSigningRequests bool
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -54,7 +70,7 @@ func (r *ControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return r.clusterRoleBindingHasControlplaneOwner(e.ObjectOld)
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	controller := ctrl.NewControllerManagedBy(mgr).
 		// watch Controlplane objects
 		For(&apisixoperatorv1alpha1.ControlPlane{}).
 		// watch for changes in Secrets created by the controlplane controller
@@ -63,6 +79,8 @@ func (r *ControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&corev1.ServiceAccount{}).
 		// watch for changes in Deployments created by the controlplane controller
 		Owns(&appsv1.Deployment{}).
+		// watch for changes in PodDisruptionBudgets created by the controlplane controller
+		Owns(&policyv1.PodDisruptionBudget{}).
 		// watch for changes in ClusterRoles created by the controlplane controller.
 		// Since the ClusterRoles are cluster-wide but controlplanes are namespaced,
 		// we need to manually detect the owner by means of the UID
@@ -78,10 +96,43 @@ func (r *ControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&source.Kind{Type: &rbacv1.ClusterRoleBinding{}},
 			handler.EnqueueRequestsFromMapFunc(r.getControlplaneForClusterRoleBinding),
 			builder.WithPredicates(clusterRoleBindingPredicate)).
+		// watch for changes in the cluster CA, which require the certificates
+		// issued to the controlplanes to be re-issued.
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.getControlplanesForClusterCASecret),
+			builder.WithPredicates(predicate.NewPredicateFuncs(r.isClusterCASecret))).
+		// watch for changes in the Admin API keys of the dataplanes, which require
+		// the controlplanes configuring them to be rolled out.
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.getControlplanesForAdminKeySecret),
+			builder.WithPredicates(predicate.NewPredicateFuncs(isAdminKeySecret))).
 		Watches(
 			&source.Kind{Type: &apisixoperatorv1alpha1.DataPlane{}},
-			&handler.EnqueueRequestForOwner{OwnerType: &apisixoperatorv1alpha1.ControlPlane{}, IsController: true}).
-		Complete(r)
+			&handler.EnqueueRequestForOwner{OwnerType: &apisixoperatorv1alpha1.ControlPlane{}, IsController: true})
+
+	if r.CertManagerIssuer != nil {
+		// watch for changes in cert-manager Certificates created by the controlplane controller
+		controller = controller.Owns(newCertManagerCertificate()).
+			// watch for the issuance and the renewal of the certificates, which
+			// cert-manager stores in Secrets that aren't owned by the controlplanes.
+			Watches(
+				&source.Kind{Type: &corev1.Secret{}},
+				handler.EnqueueRequestsFromMapFunc(r.getControlplaneForCertManagerSecret),
+				builder.WithPredicates(predicate.NewPredicateFuncs(isCertManagerSecret)))
+	}
+
+	if r.UseCertificateSigningRequests {
+		// watch for the issuance of the certificates requested by the controlplane controller.
+		// CertificateSigningRequests are cluster-wide, hence they are mapped to their
+		// owners through annotations (Owns cannot be used in this case)
+		controller = controller.Watches(
+			&source.Kind{Type: &certificatesv1.CertificateSigningRequest{}},
+			handler.EnqueueRequestsFromMapFunc(r.getControlplaneForCertificateSigningRequest))
+	}
+
+	return controller.Complete(r)
 }
 
 // Reconcile moves the current state of an object to the intended state.
@@ -164,6 +215,7 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	debug(log, "retrieving connected dataplane", controlplane)
 	dataplane, err := gatewayutils.GetDataPlaneForControlPlane(ctx, r.Client, controlplane)
 	var dataplaneServiceName string
+	var adminKeySecret *corev1.Secret
 	if err != nil {
 		if !errors.Is(err, operatorerrors.ErrDataPlaneNotSet) {
 			return ctrl.Result{}, err
@@ -178,36 +230,20 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			debug(log, "no existing dataplane service for controlplane", controlplane, "error", err)
 			return ctrl.Result{}, err
 		}
+		adminKeySecret, err = r.getAdminKeySecretForDataPlane(ctx, dataplane)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
 	debug(log, "validating ControlPlane configuration", controlplane)
 
 	debug(log, "configuring ControlPlane resource", controlplane)
-	changed := setControlPlaneDefaults(&controlplane.Spec.ControlPlaneDeploymentOptions, controlplane.Namespace, dataplaneServiceName, nil)
-	if changed {
-		debug(log, "updating ControlPlane resource after defaults are set since resource has changed", controlplane)
-		err := r.Client.Update(ctx, controlplane)
-		if err != nil {
-			if k8serrors.IsConflict(err) {
-				debug(log, "conflict found when updating ControlPlane resource, retrying", controlplane)
-				return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
-			}
-		}
-		return ctrl.Result{}, err // no need to requeue, the update will trigger.
-	}
-
-	debug(log, "validating that the ControlPlane's DataPlane configuration is up to date", controlplane)
-	if err = r.ensureDataPlaneConfiguration(ctx, controlplane, dataplaneServiceName); err != nil {
-		if k8serrors.IsConflict(err) {
-			debug(
-				log,
-				"conflict found when trying to ensure ControlPlane's DataPlane configuration was up to date, retrying",
-				controlplane,
-			)
-			return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
-		}
-		return ctrl.Result{}, err
-	}
+	// the defaults are stored in the spec by the defaulting webhook, they are
+	// only applied to a copy here so that the spec is never updated by the
+	// operator, and so are the environment variables pointing to the DataPlane.
+	configuredControlPlane := controlplane.DeepCopy()
+	setControlPlaneDefaults(&configuredControlPlane.Spec.ControlPlaneDeploymentOptions, controlplane.Namespace, dataplaneServiceName)
 
 	debug(log, "validating ControlPlane's DataPlane status", controlplane)
 	dataplaneIsSet := r.ensureDataPlaneStatus(controlplane)
@@ -247,6 +283,13 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	debug(log, "creating mTLS certificate", controlplane)
 	created, certSecret, err := r.ensureCertificate(ctx, controlplane)
 	if err != nil {
+		if errors.Is(err, operatorerrors.ErrCertificateNotIssued) {
+			// requesting the certificate again wouldn't help, the failure is reported
+			// instead until the failed request is deleted.
+			info(log, "mTLS certificate for ControlPlane not issued", controlplane, "reason", err.Error())
+			r.ensureIsMarkedCertificateNotIssued(controlplane, err)
+			return ctrl.Result{}, r.updateStatus(ctx, controlplane)
+		}
 		return ctrl.Result{}, err
 	}
 	if created {
@@ -254,7 +297,7 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	debug(log, "looking for existing Deployments for ControlPlane resource", controlplane)
-	createdOrUpdated, controlplaneDeployment, err := r.ensureDeploymentForControlPlane(ctx, controlplane, controlplaneServiceAccount.Name, certSecret.Name)
+	createdOrUpdated, controlplaneDeployment, err := r.ensureDeploymentForControlPlane(ctx, configuredControlPlane, controlplaneServiceAccount.Name, certSecret, adminKeySecret)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -270,12 +313,29 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
 	}
 
+	debug(log, "ensuring PodDisruptionBudget for ControlPlane", controlplane)
+	changed, err := ensurePodDisruptionBudgetForOwner(ctx, r.Client, controlplane, controlplane.Spec.PodDisruptionBudget)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if changed {
+		debug(log, "PodDisruptionBudget for ControlPlane created/updated/deleted", controlplane)
+		return ctrl.Result{}, nil // requeue will be triggered by the change of the owned object
+	}
+
+	// the certificate has to be re-issued before it expires, even if nothing else
+	// triggers a reconciliation of the ControlPlane in the meantime, unless it's
+	// renewed by cert-manager.
+	certificateRenewal := ctrl.Result{}
+	if r.CertManagerIssuer == nil {
+		certificateRenewal.RequeueAfter = certificateRenewalRequeueAfter(certSecret, r.ClusterCertificateRenewBefore)
+	}
 
 	debug(log, "checking readiness of ControlPlane deployments", controlplane)
 
 	if controlplaneDeployment.Status.Replicas == 0 || controlplaneDeployment.Status.AvailableReplicas < controlplaneDeployment.Status.Replicas {
 		debug(log, "deployment for ControlPlane not yet ready, waiting", controlplane)
-		return ctrl.Result{}, nil // requeue will be triggered by the status update
+		return certificateRenewal, nil // requeue will be triggered by the status update
 	}
 
 	r.ensureIsMarkedProvisioned(controlplane)
@@ -288,10 +348,11 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
 		}
 		debug(log, "unable to reconcile the ControlPlane resource", controlplane)
-	} else {
-		debug(log, "reconciliation complete for ControlPlane resource", controlplane)
+		return ctrl.Result{}, err
 	}
-	return ctrl.Result{}, err
+
+	debug(log, "reconciliation complete for ControlPlane resource", controlplane)
+	return certificateRenewal, nil
 }
 
 // updateStatus Updates the resource status only when there are changes in the Conditions
@@ -32,4 +32,8 @@ const (
 	// ControlPlaneConditionsReasonNoDataplane is a reason which indicates that no DataPlane
 	// has been provisioned.
 	ControlPlaneConditionReasonNoDataplane k8sutils.ConditionReason = "NoDataplane"
+
+	// ControlPlaneConditionReasonCertificateNotIssued is a reason which indicates that
+	// the request for the certificate of a ControlPlane was denied or failed.
+	ControlPlaneConditionReasonCertificateNotIssued k8sutils.ConditionReason = "CertificateNotIssued"
 )
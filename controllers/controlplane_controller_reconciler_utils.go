@@ -3,7 +3,6 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"reflect"
 
 	"github.com/hashicorp/go-multierror"
 	appsv1 "k8s.io/api/apps/v1"
@@ -13,9 +12,12 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 	"github.com/chever-john/apisix-operator/internal/consts"
+	controlplaneutils "github.com/chever-john/apisix-operator/internal/utils/controlplane"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
 	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
 	k8sresources "github.com/chever-john/apisix-operator/internal/utils/kubernetes/resources"
 )
@@ -60,6 +62,21 @@ func (r *ControlPlaneReconciler) ensureIsMarkedProvisioned(
 	k8sutils.SetReady(controlplane)
 }
 
+// ensureIsMarkedCertificateNotIssued reports on the status of the provided
+// ControlPlane why its certificate was not issued.
+func (r *ControlPlaneReconciler) ensureIsMarkedCertificateNotIssued(
+	controlplane *apisixoperatorv1alpha1.ControlPlane,
+	err error,
+) {
+	condition := k8sutils.NewCondition(
+		ControlPlaneConditionTypeProvisioned,
+		metav1.ConditionFalse,
+		ControlPlaneConditionReasonCertificateNotIssued,
+		err.Error(),
+	)
+	k8sutils.SetCondition(condition, controlplane)
+}
+
 // ensureDataPlaneStatus ensures that the dataplane is in the correct state
 // to carry on with the controlplane deployments reconciliation.
 // Information about the missing dataplane is stored in the controlplane status.
@@ -89,26 +106,6 @@ func (r *ControlPlaneReconciler) ensureDataPlaneStatus(
 	return dataplaneIsSet
 }
 
-// -----------------------------------------------------------------------------
-// ControlPlaneReconciler - Spec Management
-// -----------------------------------------------------------------------------
-
-func (r *ControlPlaneReconciler) ensureDataPlaneConfiguration(
-	ctx context.Context,
-	controlplane *apisixoperatorv1alpha1.ControlPlane,
-	dataplaneServiceName string,
-) error {
-	changed := setControlPlaneEnvOnDataPlaneChange(
-		&controlplane.Spec.ControlPlaneDeploymentOptions,
-		controlplane.Namespace,
-		dataplaneServiceName,
-	)
-	if changed {
-		return r.Client.Update(ctx, controlplane)
-	}
-	return nil
-}
-
 // -----------------------------------------------------------------------------
 // ControlPlaneReconciler - Owned Resource Management
 // -----------------------------------------------------------------------------
@@ -119,7 +116,9 @@ func (r *ControlPlaneReconciler) ensureDataPlaneConfiguration(
 func (r *ControlPlaneReconciler) ensureDeploymentForControlPlane(
 	ctx context.Context,
 	controlplane *apisixoperatorv1alpha1.ControlPlane,
-	serviceAccountName, certSecretName string,
+	serviceAccountName string,
+	certSecret *corev1.Secret,
+	adminKeySecret *corev1.Secret,
 ) (bool, *appsv1.Deployment, error) {
 	dataplaneIsSet := controlplane.Spec.DataPlane != nil && *controlplane.Spec.DataPlane != ""
 
@@ -139,7 +138,14 @@ func (r *ControlPlaneReconciler) ensureDeploymentForControlPlane(
 		return false, nil, fmt.Errorf("found %d deployments for ControlPlane currently unsupported: expected 1 or less", count)
 	}
 
-	generatedDeployment := generateNewDeploymentForControlPlane(controlplane, serviceAccountName, certSecretName)
+	generatedDeployment, err := generateNewDeploymentForControlPlane(controlplane, serviceAccountName, certSecret.Name)
+	if err != nil {
+		return false, nil, err
+	}
+	setCertificateHashAnnotation(&generatedDeployment.Spec.Template, consts.ClusterCertificateHashAnnotation, certSecret)
+	if adminKeySecret != nil {
+		setAdminKeyHashAnnotation(&generatedDeployment.Spec.Template, adminKeySecret)
+	}
 	k8sutils.SetOwnerForObject(generatedDeployment, controlplane)
 	addLabelForControlPlane(generatedDeployment)
 
@@ -147,15 +153,6 @@ func (r *ControlPlaneReconciler) ensureDeploymentForControlPlane(
 		var updated bool
 		existingDeployment := &deployments[0]
 		updated, existingDeployment.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingDeployment.ObjectMeta, generatedDeployment.ObjectMeta)
-		container := k8sresources.GetPodContainerByName(&existingDeployment.Spec.Template.Spec, consts.ControlPlaneControllerContainerName)
-		if container == nil {
-			// someone has deleted the main container from the Deployment for ??? reasons. we can't fathom why they
-			// would do this, but don't allow it and replace the container set entirely
-			existingDeployment.Spec.Template.Spec.Containers = generatedDeployment.Spec.Template.Spec.Containers
-			updated = true
-			container = k8sresources.GetPodContainerByName(&existingDeployment.Spec.Template.Spec, consts.ControlPlaneControllerContainerName)
-		}
-
 		replicas := existingDeployment.Spec.Replicas
 		switch {
 
@@ -164,28 +161,23 @@ func (r *ControlPlaneReconciler) ensureDeploymentForControlPlane(
 			existingDeployment.Spec.Replicas = pointer.Int32(numReplicasWhenNoDataplane)
 			updated = true
 
-		// Dataplane was just set, so we need to scale up the Deployment
-		// and ensure the env variables that might have been changed in
-		// deployment are updated.
+		// Dataplane was just set, so we need to scale up the Deployment.
 		case dataplaneIsSet && (replicas != nil && *replicas == numReplicasWhenNoDataplane):
 			existingDeployment.Spec.Replicas = nil
-			if len(container.Env) > 0 {
-				container.Env = controlplane.Spec.Env
-			}
 			updated = true
 		}
 
-		// We do not want to permit direct edits of the Deployment environment. Any user-supplied values should be set
-		// in the ControlPlane. If the actual Deployment environment does not match the generated environment, either
-		// something requires an update (e.g. the associated DataPlane Service changed and value generation changed the
-		// publish service configuration) or there was a manual edit we want to purge.
-		if !reflect.DeepEqual(container.Env, controlplane.Spec.Env) {
-			container.Env = controlplane.Spec.Env
-			updated = true
+		// We do not want to permit direct edits of the Deployment pods. Any user-supplied values should be set
+		// in the ControlPlane. If the actual pod template does not match the generated one, either something
+		// requires an update (e.g. the associated DataPlane Service changed and value generation changed the
+		// publish service configuration) or there was a manual edit we want to purge. A re-issued certificate
+		// or a rotated admin key is only picked up by the controller on restart, which the hash annotations
+		// trigger.
+		templateUpdated, err := ensurePodTemplateIsUpdated(ctx, r.Client, existingDeployment, &generatedDeployment.Spec.Template)
+		if err != nil {
+			return false, nil, err
 		}
-
-		if !reflect.DeepEqual(container.EnvFrom, controlplane.Spec.EnvFrom) {
-			container.EnvFrom = controlplane.Spec.EnvFrom
+		if templateUpdated {
 			updated = true
 		}
 
@@ -202,6 +194,26 @@ func (r *ControlPlaneReconciler) ensureDeploymentForControlPlane(
 	return true, generatedDeployment, r.Client.Create(ctx, generatedDeployment)
 }
 
+// getAdminKeySecretForDataPlane returns the Secret holding the Admin API key of
+// the provided DataPlane, or nil if it hasn't been created yet.
+func (r *ControlPlaneReconciler) getAdminKeySecretForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, client.ObjectKey{
+		Namespace: dataplane.Namespace,
+		Name:      dataplaneutils.AdminKeySecretName(dataplane.Name),
+	}, secret)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return secret, nil
+}
+
 func (r *ControlPlaneReconciler) ensureServiceAccountForControlPlane(
 	ctx context.Context,
 	controlplane *apisixoperatorv1alpha1.ControlPlane,
@@ -247,7 +259,7 @@ func (r *ControlPlaneReconciler) ensureClusterRoleForControlPlane(
 		return false, nil, fmt.Errorf("found %d deployments for ControlPlane currently unsupported: expected 1 or less", count)
 	}
 
-	generatedClusterRole, err := k8sresources.GenerateNewClusterRoleForControlPlane(controlplane.Name, controlplane.Spec.ContainerImage)
+	generatedClusterRole, err := k8sresources.GenerateNewClusterRoleForControlPlane(controlplane.Name, controlplaneutils.Image(controlplane.Spec.DeploymentOptions))
 	if err != nil {
 		return false, nil, err
 	}
@@ -313,9 +325,15 @@ func (r *ControlPlaneReconciler) ensureCertificate(
 	return maybeCreateCertificateSecret(ctx,
 		controlplane,
 		fmt.Sprintf("%s.%s", controlplane.Name, controlplane.Namespace),
-		r.ClusterCASecretName,
-		r.ClusterCASecretNamespace,
 		usages,
+		newCertificateIssuer(
+			r.CertManagerIssuer,
+			r.UseCertificateSigningRequests,
+			r.ClusterCASecretName,
+			r.ClusterCASecretNamespace,
+			r.ClusterCertificateLifetime,
+			r.ClusterCertificateRenewBefore,
+		),
 		r.Client)
 }
 
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+)
+
+func TestControlPlaneReconciler_ensureDeploymentForControlPlaneDrift(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	controlplane := &apisixoperatorv1alpha1.ControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+		Spec: apisixoperatorv1alpha1.ControlPlaneSpec{
+			ControlPlaneDeploymentOptions: apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+				DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+					Env: []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "info"}},
+				},
+			},
+		},
+	}
+	certSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cert"}}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(controlplane).Build()
+	r := &ControlPlaneReconciler{Client: defaultingClient{c}, Scheme: scheme}
+	ctx := context.Background()
+
+	createdOrUpdated, deployment, err := r.ensureDeploymentForControlPlane(ctx, controlplane, "controller", certSecret, nil)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+
+	createdOrUpdated, _, err = r.ensureDeploymentForControlPlane(ctx, controlplane, "controller", certSecret, nil)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("reverting manual edits of the environment and of the probes")
+	existing := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(deployment), existing))
+	existing.Spec.Template.Spec.Containers[0].Env = nil
+	existing.Spec.Template.Spec.Containers[0].LivenessProbe.PeriodSeconds = 60
+	require.NoError(t, c.Update(ctx, existing))
+	createdOrUpdated, deployment, err = r.ensureDeploymentForControlPlane(ctx, controlplane, "controller", certSecret, nil)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	require.Equal(t, controlplane.Spec.Env, container.Env)
+	require.Equal(t, int32(10), container.LivenessProbe.PeriodSeconds)
+
+	t.Log("following a change of the image of the ControlPlane")
+	controlplane.Spec.ContainerImage = pointer.String("apache/apisix-ingress-controller")
+	controlplane.Spec.Version = pointer.String("1.5.0")
+	createdOrUpdated, deployment, err = r.ensureDeploymentForControlPlane(ctx, controlplane, "controller", certSecret, nil)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, "apache/apisix-ingress-controller:1.5.0", deployment.Spec.Template.Spec.Containers[0].Image)
+}
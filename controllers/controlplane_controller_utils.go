@@ -12,7 +12,7 @@ import (
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 	"github.com/chever-john/apisix-operator/internal/consts"
-	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
+	controlplaneutils "github.com/chever-john/apisix-operator/internal/utils/controlplane"
 	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
 )
 
@@ -20,67 +20,16 @@ import (
 // ControlPlane - Private Functions
 // -----------------------------------------------------------------------------
 
-// setControlPlaneDefaults updates the environment variables of control plane
-// and returns true if env field is changed.
+// setControlPlaneDefaults sets the defaults of the ControlPlane deployment
+// options, along with the environment variables pointing to the Service and to
+// the Admin API key of its DataPlane, which are removed if no DataPlane is set.
 func setControlPlaneDefaults(
 	spec *apisixoperatorv1alpha1.ControlPlaneDeploymentOptions,
-	namespace string, dataplaneServiceName string,
-	dontOverride map[string]struct{},
-) bool {
-	changed := false
-
-	// set env POD_NAMESPACE. should be always from `metadata.namespace` of pod.
-	envSourceMetadataNamespace := &corev1.EnvVarSource{
-		FieldRef: &corev1.ObjectFieldSelector{
-			APIVersion: "v1",
-			FieldPath:  "metadata.namespace",
-		},
-	}
-	if !reflect.DeepEqual(envSourceMetadataNamespace, envVarSourceByName(spec.Env, "POD_NAMESPACE")) {
-		spec.Env = updateEnvSource(spec.Env, "POD_NAMESPACE", envSourceMetadataNamespace)
-		changed = true
-	}
-
-	// set env POD_NAME. should be always from `metadata.name` of pod.
-	envSourceMetadataName := &corev1.EnvVarSource{
-		FieldRef: &corev1.ObjectFieldSelector{
-			APIVersion: "v1",
-			FieldPath:  "metadata.name",
-		},
-	}
-	if !reflect.DeepEqual(envSourceMetadataName, envVarSourceByName(spec.Env, "POD_NAME")) {
-		spec.Env = updateEnvSource(spec.Env, "POD_NAME", envSourceMetadataName)
-		changed = true
-	}
-
-	if namespace != "" && dataplaneServiceName != "" {
-		if _, isOverrideDisabled := dontOverride["CONTROLLER_PUBLISH_SERVICE"]; !isOverrideDisabled {
-			publishService := controllerPublishService(dataplaneServiceName, namespace)
-			if envValueByName(spec.Env, "CONTROLLER_PUBLISH_SERVICE") != publishService {
-				spec.Env = updateEnv(spec.Env, "CONTROLLER_PUBLISH_SERVICE", controllerPublishService(dataplaneServiceName, namespace))
-				changed = true
-			}
-		}
-		if _, isOverrideDisabled := dontOverride["CONTROLLER_APISIX_ADMIN_URL"]; !isOverrideDisabled {
-			apisixAdminURL := controllerApisixAdminURL(dataplaneServiceName, namespace)
-			if envValueByName(spec.Env, "CONTROLLER_APISIX_ADMIN_URL") != apisixAdminURL {
-				spec.Env = updateEnv(spec.Env, "CONTROLLER_APISIX_ADMIN_URL", apisixAdminURL)
-				changed = true
-			}
-		}
-	}
-
-	if _, isOverrideDisabled := dontOverride["CONTROLLER_APISIX_ADMIN_TLS_CLIENT_CERT_FILE"]; !isOverrideDisabled {
-		spec.Env = updateEnv(spec.Env, "CONTROLLER_APISIX_ADMIN_TLS_CLIENT_CERT_FILE", "/var/cluster-certificate/tls.crt")
-	}
-	if _, isOverrideDisabled := dontOverride["CONTROLLER_APISIX_ADMIN_TLS_CLIENT_KEY_FILE"]; !isOverrideDisabled {
-		spec.Env = updateEnv(spec.Env, "CONTROLLER_APISIX_ADMIN_TLS_CLIENT_KEY_FILE", "/var/cluster-certificate/tls.key")
-	}
-	if _, isOverrideDisabled := dontOverride["CONTROLLER_APISIX_ADMIN_CA_CERT_FILE"]; !isOverrideDisabled {
-		spec.Env = updateEnv(spec.Env, "CONTROLLER_APISIX_ADMIN_CA_CERT_FILE", "/var/cluster-certificate/ca.crt")
-	}
-
-	return changed
+	namespace string,
+	dataplaneServiceName string,
+) {
+	controlplaneutils.SetControlPlaneDefaults(spec)
+	setControlPlaneEnvOnDataPlaneChange(spec, namespace, dataplaneServiceName)
 }
 
 func setControlPlaneEnvOnDataPlaneChange(
@@ -92,23 +41,32 @@ func setControlPlaneEnvOnDataPlaneChange(
 
 	dataplaneIsSet := spec.DataPlane != nil && *spec.DataPlane != ""
 	if dataplaneIsSet {
-		newPublishServiceValue := controllerPublishService(dataplaneServiceName, namespace)
-		if envValueByName(spec.Env, "CONTROLLER_PUBLISH_SERVICE") != newPublishServiceValue {
-			spec.Env = updateEnv(spec.Env, "CONTROLLER_PUBLISH_SERVICE", newPublishServiceValue)
+		newPublishServiceValue := controlplaneutils.PublishService(dataplaneServiceName, namespace)
+		if envValueByName(spec.Env, controlplaneutils.EnvVarPublishService) != newPublishServiceValue {
+			spec.Env = updateEnv(spec.Env, controlplaneutils.EnvVarPublishService, newPublishServiceValue)
 			changed = true
 		}
-		newApisixAdminURL := controllerApisixAdminURL(dataplaneServiceName, namespace)
-		if envValueByName(spec.Env, "CONTROLLER_APISIX_ADMIN_URL") != newApisixAdminURL {
-			spec.Env = updateEnv(spec.Env, "CONTROLLER_APISIX_ADMIN_URL", newApisixAdminURL)
+		newApisixAdminURL := controlplaneutils.ApisixAdminURL(*spec.DataPlane, namespace)
+		if envValueByName(spec.Env, controlplaneutils.EnvVarApisixAdminURL) != newApisixAdminURL {
+			spec.Env = updateEnv(spec.Env, controlplaneutils.EnvVarApisixAdminURL, newApisixAdminURL)
+			changed = true
+		}
+		newApisixAdminKey := controlplaneutils.ApisixAdminKeyEnvVar(*spec.DataPlane)
+		if !reflect.DeepEqual(envVarByName(spec.Env, controlplaneutils.EnvVarApisixAdminKey), &newApisixAdminKey) {
+			spec.Env = append(rejectEnvByName(spec.Env, controlplaneutils.EnvVarApisixAdminKey), newApisixAdminKey)
 			changed = true
 		}
 	} else {
-		if envValueByName(spec.Env, "CONTROLLER_PUBLISH_SERVICE") != "" {
-			spec.Env = rejectEnvByName(spec.Env, "CONTROLLER_PUBLISH_SERVICE")
+		if envValueByName(spec.Env, controlplaneutils.EnvVarPublishService) != "" {
+			spec.Env = rejectEnvByName(spec.Env, controlplaneutils.EnvVarPublishService)
+			changed = true
+		}
+		if envValueByName(spec.Env, controlplaneutils.EnvVarApisixAdminURL) != "" {
+			spec.Env = rejectEnvByName(spec.Env, controlplaneutils.EnvVarApisixAdminURL)
 			changed = true
 		}
-		if envValueByName(spec.Env, "CONTROLLER_APISIX_ADMIN_URL") != "" {
-			spec.Env = rejectEnvByName(spec.Env, "CONTROLLER_APISIX_ADMIN_URL")
+		if envVarByName(spec.Env, controlplaneutils.EnvVarApisixAdminKey) != nil {
+			spec.Env = rejectEnvByName(spec.Env, controlplaneutils.EnvVarApisixAdminKey)
 			changed = true
 		}
 	}
@@ -116,15 +74,6 @@ func setControlPlaneEnvOnDataPlaneChange(
 	return changed
 }
 
-func controllerApisixAdminURL(dataplaneName, dataplaneNamespace string) string {
-	return fmt.Sprintf("https://%s.%s.svc:%d",
-		dataplaneName, dataplaneNamespace, dataplaneutils.DefaultAPISIXAdminPort)
-}
-
-func controllerPublishService(dataplaneName, dataplaneNamespace string) string {
-	return fmt.Sprintf("%s/%s", dataplaneNamespace, dataplaneName)
-}
-
 // envValueByName returns the value of the first env var with the given name.
 // If no env var with the given name is found, an empty string is returned.
 func envValueByName(env []corev1.EnvVar, name string) string {
@@ -136,12 +85,12 @@ func envValueByName(env []corev1.EnvVar, name string) string {
 	return ""
 }
 
-// envVarSourceByName returns the ValueFrom of the first env var with the given name.
-// returns nil if env var is not found, or does not have a ValueFrom field.
-func envVarSourceByName(env []corev1.EnvVar, name string) *corev1.EnvVarSource {
-	for _, envVar := range env {
-		if envVar.Name == name {
-			return envVar.ValueFrom
+// envVarByName returns the first env var with the given name, or nil if no env
+// var with the given name is found.
+func envVarByName(env []corev1.EnvVar, name string) *corev1.EnvVar {
+	for i := range env {
+		if env[i].Name == name {
+			return &env[i]
 		}
 	}
 	return nil
@@ -163,23 +112,6 @@ func updateEnv(envVars []corev1.EnvVar, name, val string) []corev1.EnvVar {
 	return newEnvVars
 }
 
-// updateEnvSource updates env var with `name` to come from `envSource`.
-func updateEnvSource(envVars []corev1.EnvVar, name string, envSource *corev1.EnvVarSource) []corev1.EnvVar {
-	newEnvVars := make([]corev1.EnvVar, 0, len(envVars))
-	for _, envVar := range envVars {
-		if envVar.Name != name {
-			newEnvVars = append(newEnvVars, envVar)
-		}
-	}
-
-	newEnvVars = append(newEnvVars, corev1.EnvVar{
-		Name:      name,
-		ValueFrom: envSource,
-	})
-
-	return newEnvVars
-}
-
 // rejectEnvByName returns a copy of the given env vars,
 // but with the env vars with the given name removed.
 func rejectEnvByName(envVars []corev1.EnvVar, name string) []corev1.EnvVar {
@@ -193,13 +125,10 @@ func rejectEnvByName(envVars []corev1.EnvVar, name string) []corev1.EnvVar {
 }
 
 func generateNewDeploymentForControlPlane(controlplane *apisixoperatorv1alpha1.ControlPlane, serviceAccountName,
-	certSecretName string) *appsv1.Deployment {
+	certSecretName string) (*appsv1.Deployment, error) {
 	var controlplaneImage string
-	if controlplane.Spec.ContainerImage != nil {
-		controlplaneImage = *controlplane.Spec.ContainerImage
-		if controlplane.Spec.Version != nil {
-			controlplaneImage = fmt.Sprintf("%s:%s", controlplaneImage, *controlplane.Spec.Version)
-		}
+	if image := controlplaneutils.Image(controlplane.Spec.DeploymentOptions); image != nil {
+		controlplaneImage = *image
 	} else {
 		controlplaneImage = consts.DefaultControlPlaneImage // TODO: https://github.com/Kong/gateway-operator/issues/20
 	}
@@ -261,7 +190,7 @@ func generateNewDeploymentForControlPlane(controlplane *apisixoperatorv1alpha1.C
 							{
 								Name:      "cluster-certificate",
 								ReadOnly:  true,
-								MountPath: "/var/cluster-certificate",
+								MountPath: controlplaneutils.ClusterCertificateMountPath,
 							},
 						},
 						Lifecycle: &corev1.Lifecycle{
@@ -315,7 +244,16 @@ func generateNewDeploymentForControlPlane(controlplane *apisixoperatorv1alpha1.C
 			},
 		},
 	}
-	return deployment
+
+	// the pod template overrides of the ControlPlane are merged last.
+	template, err := k8sutils.StrategicMergePatchPodTemplateSpec(&deployment.Spec.Template, controlplane.Spec.PodTemplateSpec)
+	if err != nil {
+		return nil, err
+	}
+	setLastAppliedAnnotations(template, controlplane.Spec.PodTemplateSpec)
+	deployment.Spec.Template = *template
+
+	return deployment, nil
 }
 
 // -----------------------------------------------------------------------------
@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -11,7 +12,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
 	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
 	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
 )
 
@@ -70,10 +73,86 @@ func (r *ControlPlaneReconciler) objHasControlplaneOwner(ctx context.Context, ob
 	return false
 }
 
+func (r *ControlPlaneReconciler) isClusterCASecret(obj client.Object) bool {
+	return obj.GetNamespace() == r.ClusterCASecretNamespace && obj.GetName() == r.ClusterCASecretName
+}
+
+func isAdminKeySecret(obj client.Object) bool {
+	return obj.GetLabels()[consts.GatewayOperatorControlledLabel] == consts.AdminKeyManagedLabelValue
+}
+
 // -----------------------------------------------------------------------------
 // ControlplaneReconciler - Watch Map Funcs
 // -----------------------------------------------------------------------------
 
+// getControlplanesForClusterCASecret enqueues all the ControlPlanes when the
+// cluster CA changes, so that their certificates get re-issued.
+func (r *ControlPlaneReconciler) getControlplanesForClusterCASecret(obj client.Object) (recs []reconcile.Request) {
+	ctx := context.Background()
+
+	if _, ok := obj.(*corev1.Secret); !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "Secret", "found", reflect.TypeOf(obj),
+		)
+		return
+	}
+
+	controlplanes := &apisixoperatorv1alpha1.ControlPlaneList{}
+	if err := r.Client.List(ctx, controlplanes); err != nil {
+		log.FromContext(ctx).Error(err, "could not list controlplanes in map func")
+		return
+	}
+
+	for _, controlplane := range controlplanes.Items {
+		recs = append(recs, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: controlplane.Namespace,
+				Name:      controlplane.Name,
+			},
+		})
+	}
+
+	return
+}
+
+// getControlplanesForAdminKeySecret enqueues the ControlPlanes configuring the
+// DataPlane the Admin API key belongs to, so that they pick up a rotated key.
+func (r *ControlPlaneReconciler) getControlplanesForAdminKeySecret(obj client.Object) (recs []reconcile.Request) {
+	ctx := context.Background()
+
+	if _, ok := obj.(*corev1.Secret); !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "Secret", "found", reflect.TypeOf(obj),
+		)
+		return
+	}
+
+	controlplanes := &apisixoperatorv1alpha1.ControlPlaneList{}
+	if err := r.Client.List(ctx, controlplanes, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "could not list controlplanes in map func")
+		return
+	}
+
+	for _, controlplane := range controlplanes.Items {
+		if controlplane.Spec.DataPlane == nil ||
+			dataplaneutils.AdminKeySecretName(*controlplane.Spec.DataPlane) != obj.GetName() {
+			continue
+		}
+		recs = append(recs, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: controlplane.Namespace,
+				Name:      controlplane.Name,
+			},
+		})
+	}
+
+	return
+}
+
 func (r *ControlPlaneReconciler) getControlplaneForClusterRole(obj client.Object) (recs []reconcile.Request) {
 	ctx := context.Background()
 
@@ -128,3 +207,17 @@ func (r *ControlPlaneReconciler) getControlplaneRequestFromRefUID(ctx context.Co
 
 	return
 }
+
+// getControlplaneForCertificateSigningRequest enqueues the ControlPlane a
+// CertificateSigningRequest was submitted for, so that the certificate is
+// stored once issued.
+func (r *ControlPlaneReconciler) getControlplaneForCertificateSigningRequest(obj client.Object) []reconcile.Request {
+	return certificateSigningRequestOwnerRequests(context.Background(), obj, consts.ControlPlaneManagedLabelValue)
+}
+
+// getControlplaneForCertManagerSecret enqueues the ControlPlane owning the
+// cert-manager Certificate stored in the provided Secret, so that the certificate
+// is rolled out once issued or renewed.
+func (r *ControlPlaneReconciler) getControlplaneForCertManagerSecret(obj client.Object) []reconcile.Request {
+	return certManagerSecretOwnerRequests(context.Background(), r.Client, obj, "ControlPlane")
+}
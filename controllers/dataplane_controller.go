@@ -18,15 +18,29 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
 	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
 )
 
@@ -37,6 +51,20 @@ type DataPlaneReconciler struct {
 	eventRecorder            record.EventRecorder
 	ClusterCASecretName      string
 	ClusterCASecretNamespace string
+	// ClusterCertificateLifetime is the validity of the mTLS certificates
+	// issued to DataPlanes.
+	ClusterCertificateLifetime time.Duration
+	// ClusterCertificateRenewBefore is how long before their expiration the
+	// mTLS certificates issued to DataPlanes are re-issued.
+	ClusterCertificateRenewBefore time.Duration
+	// CertManagerIssuer references the cert-manager issuer of the mTLS
+	// certificates issued to DataPlanes. They are signed by the cluster CA
+	// if it's not set.
+	CertManagerIssuer *CertManagerIssuerRef
+	// UseCertificateSigningRequests makes the mTLS certificates issued to
+	// DataPlanes by the cluster CA requested through the
+	// CertificateSigningRequest API rather than signed in memory.
+	UseCertificateSigningRequests bool
 }
 
 //+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=dataplanes,verbs=get;list;watch;create;update;patch;delete
@@ -45,10 +73,15 @@ type DataPlaneReconciler struct {
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the DataPlane object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// It provisions the Service, the Admin API Service, the mTLS certificate
+// Secret, the Admin API key Secret, the managed etcd cluster if any, the
+// ConfigMap holding the APISIX configuration, the Deployment or the DaemonSet
+// depending on its deployment mode, the HorizontalPodAutoscaler and the
+// PodDisruptionBudget for the DataPlane, in that order. The workloads of the
+// previous deployment mode are only deleted once the current ones are ready.
+// It then reports the addresses, the replicas and the image of the DataPlane
+// in its status, and marks it as Provisioned once all the pods of its
+// Deployment are available, or all the pods of its DaemonSet are ready.
 //
 // Reconcile 是 k8s 调和循环的一部分，其目的是使集群的当前状态更加接近于理想状态。
 //
@@ -68,7 +101,7 @@ func (r *DataPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	k8sutils.InitReady(dataplane)
-	// TODO(user): your logic here
+
 	debug(log, "validating DataPlane resource condition", dataplane)
 
 	if r.ensureIsMarkedScheduled(dataplane) {
@@ -82,18 +115,237 @@ func (r *DataPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	debug(log, "exposing DataPlane deployment via service", dataplane)
 	createdOrUpdated, dataplaneService, err := r.ensureServiceForDataPlane(ctx, dataplane)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if createdOrUpdated || dataplane.Status.Service != dataplaneService.Name {
+		return ctrl.Result{}, r.ensureDataPlaneServiceStatus(ctx, dataplane, dataplaneService.Name)
+	}
 
+	debug(log, "exposing DataPlane Admin API via service", dataplane)
+	createdOrUpdated, adminService, err := r.ensureAdminServiceForDataPlane(ctx, dataplane)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	if createdOrUpdated {
+		debug(log, "Admin API service for DataPlane created/updated", dataplane)
+		return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
+	}
 
+	// the mTLS certificate is served by the Admin API, it's issued for the
+	// Service the ControlPlane reaches it through.
+	debug(log, "ensuring mTLS certificate", dataplane)
+	createdOrUpdated, certSecret, err := r.ensureCertificate(ctx, dataplane, adminService.Name)
+	if err != nil {
+		if errors.Is(err, operatorerrors.ErrCertificateNotIssued) {
+			return r.reportCertificateNotIssued(ctx, log, dataplane, err)
+		}
+		return ctrl.Result{}, err
+	}
 	if createdOrUpdated {
-		return ctrl.Result{}, r.ensureDataPlaneServiceStatus(ctx, dataplane, dataplaneService.Name)
+		debug(log, "mTLS certificate created/updated", dataplane)
+		return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
+	}
+
+	debug(log, "ensuring Admin API key", dataplane)
+	createdOrUpdated, adminKeySecret, err := r.ensureAdminKeySecretForDataPlane(ctx, dataplane)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if createdOrUpdated {
+		debug(log, "Admin API key created/updated", dataplane)
+		return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
 	}
 
-	return ctrl.Result{}, err
+	// the defaults are stored in the spec by the defaulting webhook, they are
+	// only applied to a copy here so that the spec is never updated by the
+	// operator.
+	configuredDataPlane := dataplane.DeepCopy()
+	dataplaneutils.SetDataPlaneDefaults(&configuredDataPlane.Spec.DataPlaneDeploymentOptions)
+
+	// the certificates have to be re-issued before they expire, even if nothing else
+	// triggers a reconciliation of the DataPlane in the meantime, unless they're
+	// renewed by cert-manager.
+	certificateRenewal := ctrl.Result{}
+	if r.CertManagerIssuer == nil {
+		certificateRenewal.RequeueAfter = certificateRenewalRequeueAfter(certSecret, r.ClusterCertificateRenewBefore)
+	}
 
+	var etcdClientCertSecret *corev1.Secret
+	if isManagedEtcdEnabled(dataplane) {
+		debug(log, "ensuring managed etcd cluster", dataplane)
+		var (
+			etcd           *apisixoperatorv1alpha1.EtcdConfigCenter
+			etcdCertSecret *corev1.Secret
+		)
+		createdOrUpdated, etcd, etcdCertSecret, etcdClientCertSecret, err = r.ensureManagedEtcdForDataPlane(ctx, dataplane)
+		if err != nil {
+			if k8serrors.IsConflict(err) {
+				debug(log, "conflict during managed etcd reconciliation", dataplane)
+				return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
+			}
+			if errors.Is(err, operatorerrors.ErrCertificateNotIssued) {
+				return r.reportCertificateNotIssued(ctx, log, dataplane, err)
+			}
+			return ctrl.Result{}, err
+		}
+		if createdOrUpdated {
+			debug(log, "managed etcd cluster for DataPlane created/updated", dataplane)
+			return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
+		}
+		configuredDataPlane.Spec.ConfigCenter.Etcd = etcd
+
+		if r.CertManagerIssuer == nil {
+			for _, secret := range []*corev1.Secret{etcdCertSecret, etcdClientCertSecret} {
+				if requeueAfter := certificateRenewalRequeueAfter(secret, r.ClusterCertificateRenewBefore); requeueAfter < certificateRenewal.RequeueAfter {
+					certificateRenewal.RequeueAfter = requeueAfter
+				}
+			}
+		}
+	} else if dataplane.Status.ConfigCenter != nil {
+		// the status reports the cluster once provisioned, until it's deleted.
+		deleted, err := r.ensureManagedEtcdIsDeleted(ctx, dataplane)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if deleted {
+			debug(log, "managed etcd cluster for DataPlane deleted", dataplane)
+			return ctrl.Result{}, nil // requeue will be triggered by the deletion of the owned objects
+		}
+	}
+
+	debug(log, "ensuring APISIX configuration", dataplane)
+	createdOrUpdated, configMap, err := r.ensureConfigMapForDataPlane(ctx, configuredDataPlane)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if createdOrUpdated {
+		debug(log, "APISIX configuration for DataPlane created/updated", dataplane)
+		return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
+	}
+
+	var (
+		dataplaneReady          bool
+		dataplaneDeploymentName string
+		dataplanePodTemplate    *corev1.PodTemplateSpec
+		replicas, readyReplicas int32
+	)
+	if dataplaneutils.GetDeploymentMode(dataplane) == apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet {
+		debug(log, "looking for existing DaemonSets for DataPlane resource", dataplane)
+		createdOrUpdated, dataplaneDaemonSet, err := r.ensureDaemonSetForDataPlane(ctx, configuredDataPlane, certSecret, etcdClientCertSecret, adminKeySecret, configMap)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if createdOrUpdated {
+			debug(log, "daemonset for DataPlane created/updated", dataplane)
+			return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
+		}
+		dataplanePodTemplate = &dataplaneDaemonSet.Spec.Template
+		replicas = dataplaneDaemonSet.Status.DesiredNumberScheduled
+		readyReplicas = dataplaneDaemonSet.Status.NumberReady
+		dataplaneReady = replicas > 0 && readyReplicas >= replicas
+	} else {
+		debug(log, "looking for existing Deployments for DataPlane resource", dataplane)
+		createdOrUpdated, dataplaneDeployment, err := r.ensureDeploymentForDataPlane(ctx, configuredDataPlane, certSecret, etcdClientCertSecret, adminKeySecret, configMap)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if createdOrUpdated {
+			debug(log, "deployment for DataPlane created/updated", dataplane)
+			return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
+		}
+		dataplaneDeploymentName = dataplaneDeployment.Name
+		dataplanePodTemplate = &dataplaneDeployment.Spec.Template
+		replicas = dataplaneDeployment.Status.Replicas
+		readyReplicas = dataplaneDeployment.Status.ReadyReplicas
+		dataplaneReady = replicas > 0 && dataplaneDeployment.Status.AvailableReplicas >= replicas
+	}
+
+	// the workloads of the previous deployment mode keep serving the traffic
+	// until the ones of the current deployment mode are ready.
+	if dataplaneReady {
+		debug(log, "ensuring the workloads of the previous deployment mode of DataPlane are deleted", dataplane)
+		deleted, err := r.ensureUnusedWorkloadsAreDeletedForDataPlane(ctx, dataplane)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if deleted {
+			debug(log, "workloads of the previous deployment mode of DataPlane deleted", dataplane)
+			return ctrl.Result{}, nil // requeue will be triggered by the deletion of the owned objects
+		}
+	}
+
+	debug(log, "ensuring HorizontalPodAutoscaler for DataPlane", dataplane)
+	changed, err := r.ensureHorizontalPodAutoscalerForDataPlane(ctx, dataplane, dataplaneDeploymentName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if changed {
+		debug(log, "HorizontalPodAutoscaler for DataPlane created/updated/deleted", dataplane)
+		return ctrl.Result{}, nil // requeue will be triggered by the change of the owned object
+	}
+
+	debug(log, "ensuring PodDisruptionBudget for DataPlane", dataplane)
+	changed, err = ensurePodDisruptionBudgetForOwner(ctx, r.Client, dataplane, dataplane.Spec.PodDisruptionBudget)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if changed {
+		debug(log, "PodDisruptionBudget for DataPlane created/updated/deleted", dataplane)
+		return ctrl.Result{}, nil // requeue will be triggered by the change of the owned object
+	}
+
+	debug(log, "ensuring DataPlane status reports its addresses and replicas", dataplane)
+	updated, err := r.ensureDataPlaneDeploymentStatus(ctx, dataplane, dataplaneService, dataplanePodTemplate, replicas, readyReplicas)
+	if err != nil {
+		if k8serrors.IsConflict(err) {
+			debug(log, "conflict during DataPlane status update", dataplane)
+			return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if updated {
+		debug(log, "DataPlane status updated", dataplane)
+		return ctrl.Result{}, nil // requeue will be triggered by the update of the DataPlane status
+	}
+
+	debug(log, "checking readiness of DataPlane deployments", dataplane)
+	if !dataplaneReady {
+		debug(log, "deployment for DataPlane not yet ready, waiting", dataplane)
+		return certificateRenewal, nil // requeue will be triggered by the status update of the Deployment or DaemonSet
+	}
+
+	r.ensureIsMarkedProvisioned(dataplane)
+	err = r.updateStatus(ctx, dataplane)
+	if err != nil {
+		if k8serrors.IsConflict(err) {
+			// no need to throw an error for 409's, just requeue to get a fresh copy
+			debug(log, "conflict during DataPlane reconciliation", dataplane)
+			return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
+		}
+		debug(log, "unable to reconcile the DataPlane resource", dataplane)
+		return ctrl.Result{}, err
+	}
+
+	debug(log, "reconciliation complete for DataPlane resource", dataplane)
+	return certificateRenewal, nil
 }
+
+// reportCertificateNotIssued reports on the status of the provided DataPlane
+// that the request for one of its certificates was denied or failed. Requesting
+// the certificate again wouldn't help, the failure is reported instead until
+// the failed request is deleted.
+func (r *DataPlaneReconciler) reportCertificateNotIssued(
+	ctx context.Context,
+	log logr.Logger,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	err error,
+) (ctrl.Result, error) {
+	info(log, "certificate for DataPlane not issued", dataplane, "reason", err.Error())
+	r.ensureIsMarkedCertificateNotIssued(dataplane, err)
+	return ctrl.Result{}, r.updateStatus(ctx, dataplane)
+}
+
 func (r *DataPlaneReconciler) updateStatus(ctx context.Context, updated *apisixoperatorv1alpha1.DataPlane) error {
 	current := &apisixoperatorv1alpha1.DataPlane{}
 
@@ -111,7 +363,52 @@ func (r *DataPlaneReconciler) updateStatus(ctx context.Context, updated *apisixo
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *DataPlaneReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	controller := ctrl.NewControllerManagedBy(mgr).
+		// watch DataPlane objects
 		For(&apisixoperatorv1alpha1.DataPlane{}).
-		Complete(r)
+		// watch for changes in Secrets created by the dataplane controller
+		Owns(&corev1.Secret{}).
+		// watch for changes in Services created by the dataplane controller
+		Owns(&corev1.Service{}).
+		// watch for changes in ConfigMaps created by the dataplane controller
+		Owns(&corev1.ConfigMap{}).
+		// watch for changes in Deployments created by the dataplane controller
+		Owns(&appsv1.Deployment{}).
+		// watch for changes in DaemonSets created by the dataplane controller
+		Owns(&appsv1.DaemonSet{}).
+		// watch for changes in HorizontalPodAutoscalers created by the dataplane controller
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		// watch for changes in PodDisruptionBudgets created by the dataplane controller
+		Owns(&policyv1.PodDisruptionBudget{}).
+		// watch for changes in the StatefulSets of the etcd clusters provisioned by
+		// the dataplane controller
+		Owns(&appsv1.StatefulSet{}).
+		// watch for changes in the cluster CA, which require the certificates
+		// issued to the dataplanes to be re-issued.
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.getDataPlanesForClusterCASecret),
+			builder.WithPredicates(predicate.NewPredicateFuncs(r.isClusterCASecret)))
+
+	if r.CertManagerIssuer != nil {
+		// watch for changes in cert-manager Certificates created by the dataplane controller
+		controller = controller.Owns(newCertManagerCertificate()).
+			// watch for the issuance and the renewal of the certificates, which
+			// cert-manager stores in Secrets that aren't owned by the dataplanes.
+			Watches(
+				&source.Kind{Type: &corev1.Secret{}},
+				handler.EnqueueRequestsFromMapFunc(r.getDataPlaneForCertManagerSecret),
+				builder.WithPredicates(predicate.NewPredicateFuncs(isCertManagerSecret)))
+	}
+
+	if r.UseCertificateSigningRequests {
+		// watch for the issuance of the certificates requested by the dataplane controller.
+		// CertificateSigningRequests are cluster-wide, hence they are mapped to their
+		// owners through annotations (Owns cannot be used in this case)
+		controller = controller.Watches(
+			&source.Kind{Type: &certificatesv1.CertificateSigningRequest{}},
+			handler.EnqueueRequestsFromMapFunc(r.getDataPlaneForCertificateSigningRequest))
+	}
+
+	return controller.Complete(r)
 }
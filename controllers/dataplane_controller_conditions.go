@@ -35,4 +35,8 @@ const (
 	// DataPlaneConditionValidationFailed is a reason which indicates validation of
 	// a dataplane is failed.
 	DataPlaneConditionValidationFailed k8sutils.ConditionReason = "ValidationFailed"
+
+	// DataPlaneConditionReasonCertificateNotIssued is a reason which indicates that
+	// the request for one of the certificates of a DataPlane was denied or failed.
+	DataPlaneConditionReasonCertificateNotIssued k8sutils.ConditionReason = "CertificateNotIssued"
 )
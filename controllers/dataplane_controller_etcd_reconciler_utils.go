@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlaneReconciler - Managed Etcd
+// -----------------------------------------------------------------------------
+
+// ensureManagedEtcdForDataPlane ensures the etcd cluster of the provided
+// DataPlane is provisioned: its headless Service, its certificate, the client
+// certificate of the proxy and its StatefulSet, in that order, and reports its
+// health in the status of the DataPlane. It returns a boolean indicating if it
+// created or updated any object, in which case the reconciliation is triggered
+// again by the update, and once provisioned, the etcd options the proxy is
+// configured with along with the Secrets holding the certificate of the
+// cluster and the client certificate of the proxy.
+func (r *DataPlaneReconciler) ensureManagedEtcdForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (bool, *apisixoperatorv1alpha1.EtcdConfigCenter, *corev1.Secret, *corev1.Secret, error) {
+	createdOrUpdated, service, err := r.ensureEtcdServiceForDataPlane(ctx, dataplane)
+	if err != nil || createdOrUpdated {
+		return createdOrUpdated, nil, nil, nil, err
+	}
+
+	createdOrUpdated, certSecret, err := r.ensureEtcdCertificate(ctx, dataplane, service.Name)
+	if err != nil || createdOrUpdated {
+		return createdOrUpdated, nil, nil, nil, err
+	}
+
+	createdOrUpdated, clientCertSecret, err := r.ensureEtcdClientCertificate(ctx, dataplane)
+	if err != nil || createdOrUpdated {
+		return createdOrUpdated, nil, nil, nil, err
+	}
+
+	createdOrUpdated, statefulSet, err := r.ensureEtcdStatefulSetForDataPlane(ctx, dataplane, service.Name, certSecret)
+	if err != nil || createdOrUpdated {
+		return createdOrUpdated, nil, nil, nil, err
+	}
+
+	endpoints := generateEtcdClientEndpoints(statefulSet)
+	updated, err := r.ensureEtcdStatusForDataPlane(ctx, dataplane, statefulSet, endpoints)
+	if err != nil || updated {
+		return updated, nil, nil, nil, err
+	}
+
+	return false, generateManagedEtcdConfigCenter(endpoints, clientCertSecret), certSecret, clientCertSecret, nil
+}
+
+func (r *DataPlaneReconciler) ensureEtcdServiceForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (createdOrUpdated bool, svc *corev1.Service, err error) {
+	services, err := k8sutils.ListServicesForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.EtcdManagedLabelValue,
+		dataplane.Namespace,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(services)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d etcd services for DataPlane currently unsupported: expected 1 or less", count)
+	}
+
+	generatedService := generateEtcdServiceForDataPlane(dataplane)
+	k8sutils.SetOwnerForObject(generatedService, dataplane)
+	addLabelForEtcd(generatedService)
+
+	if count == 1 {
+		var updated bool
+		existingService := &services[0]
+		updated, existingService.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingService.ObjectMeta, generatedService.ObjectMeta)
+
+		if !reflect.DeepEqual(existingService.Spec.Selector, generatedService.Spec.Selector) ||
+			!servicePortsEqual(existingService.Spec.Ports, generatedService.Spec.Ports) ||
+			existingService.Spec.PublishNotReadyAddresses != generatedService.Spec.PublishNotReadyAddresses {
+			existingService.Spec.Selector = generatedService.Spec.Selector
+			existingService.Spec.Ports = generatedService.Spec.Ports
+			existingService.Spec.PublishNotReadyAddresses = generatedService.Spec.PublishNotReadyAddresses
+			updated = true
+		}
+
+		if updated {
+			return true, existingService, r.Client.Update(ctx, existingService)
+		}
+		return false, existingService, nil
+	}
+
+	return true, generatedService, r.Client.Create(ctx, generatedService)
+}
+
+func (r *DataPlaneReconciler) ensureEtcdCertificate(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	serviceName string,
+) (bool, *corev1.Secret, error) {
+	return maybeCreateCertificateSecret(ctx,
+		&etcdCertificateOwner{DataPlane: dataplane},
+		etcdCertificateSubject(dataplane.Namespace, serviceName),
+		etcdCertificateUsages,
+		newCertificateIssuer(
+			r.CertManagerIssuer,
+			r.UseCertificateSigningRequests,
+			r.ClusterCASecretName,
+			r.ClusterCASecretNamespace,
+			r.ClusterCertificateLifetime,
+			r.ClusterCertificateRenewBefore,
+		),
+		r.Client)
+}
+
+// ensureEtcdClientCertificate ensures the client certificate the proxy of the
+// provided DataPlane authenticates to its etcd cluster with is issued. It's
+// kept apart from the certificate of the cluster, whose key must not leave
+// the members.
+func (r *DataPlaneReconciler) ensureEtcdClientCertificate(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (bool, *corev1.Secret, error) {
+	return maybeCreateCertificateSecret(ctx,
+		&etcdClientCertificateOwner{DataPlane: dataplane},
+		etcdClientCertificateSubject(dataplane),
+		etcdClientCertificateUsages,
+		newCertificateIssuer(
+			r.CertManagerIssuer,
+			r.UseCertificateSigningRequests,
+			r.ClusterCASecretName,
+			r.ClusterCASecretNamespace,
+			r.ClusterCertificateLifetime,
+			r.ClusterCertificateRenewBefore,
+		),
+		r.Client)
+}
+
+func (r *DataPlaneReconciler) ensureEtcdStatefulSetForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	serviceName string,
+	certSecret *corev1.Secret,
+) (createdOrUpdated bool, statefulSet *appsv1.StatefulSet, err error) {
+	statefulSets, err := k8sutils.ListStatefulSetsForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.EtcdManagedLabelValue,
+		dataplane.Namespace,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(statefulSets)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d etcd statefulsets for DataPlane currently unsupported: expected 1 or less", count)
+	}
+
+	// the members of the cluster are bootstrapped statically, the number of
+	// members of an existing cluster is kept. Changing it is rejected by the
+	// validating webhook.
+	replicas := etcdReplicasForDataPlane(dataplane)
+	if count == 1 && statefulSets[0].Spec.Replicas != nil {
+		replicas = *statefulSets[0].Spec.Replicas
+	}
+
+	generatedStatefulSet := generateEtcdStatefulSetForDataPlane(dataplane, serviceName, replicas, certSecret)
+	setCertificateHashAnnotation(&generatedStatefulSet.Spec.Template, consts.EtcdCertificateHashAnnotation, certSecret)
+	k8sutils.SetOwnerForObject(generatedStatefulSet, dataplane)
+	addLabelForEtcd(generatedStatefulSet)
+
+	if count == 1 {
+		var updated bool
+		existingStatefulSet := &statefulSets[0]
+		updated, existingStatefulSet.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingStatefulSet.ObjectMeta, generatedStatefulSet.ObjectMeta)
+
+		// any drift of the members from the DataPlane is reverted, and a re-issued
+		// certificate is only picked up by the members on restart, which the hash
+		// annotation triggers.
+		templateUpdated, err := ensurePodTemplateIsUpdated(ctx, r.Client, existingStatefulSet, &generatedStatefulSet.Spec.Template)
+		if err != nil {
+			return false, nil, err
+		}
+		if templateUpdated {
+			updated = true
+		}
+
+		if updated {
+			return true, existingStatefulSet, r.Client.Update(ctx, existingStatefulSet)
+		}
+		return false, existingStatefulSet, nil
+	}
+
+	return true, generatedStatefulSet, r.Client.Create(ctx, generatedStatefulSet)
+}
+
+// ensureEtcdStatusForDataPlane reports the endpoints and the health of the
+// members of the provided etcd StatefulSet in the status of the DataPlane. It
+// returns true if the status was updated.
+func (r *DataPlaneReconciler) ensureEtcdStatusForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	statefulSet *appsv1.StatefulSet,
+	endpoints []string,
+) (bool, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods,
+		client.InNamespace(statefulSet.Namespace),
+		client.MatchingLabels(statefulSet.Spec.Selector.MatchLabels),
+	); err != nil {
+		return false, err
+	}
+
+	members, readyMembers := generateEtcdMemberStatuses(pods.Items)
+	status := &apisixoperatorv1alpha1.DataPlaneConfigCenterStatus{
+		Endpoints:    endpoints,
+		ReadyMembers: readyMembers,
+		Members:      members,
+	}
+	if reflect.DeepEqual(dataplane.Status.ConfigCenter, status) {
+		return false, nil
+	}
+
+	dataplane.Status.ConfigCenter = status
+	return true, r.Status().Update(ctx, dataplane)
+}
+
+// ensureManagedEtcdIsDeleted deletes the etcd cluster provisioned for the
+// provided DataPlane once it no longer asks for it. It's only called while the
+// status of the DataPlane reports the cluster, which is cleared last. It
+// returns true if any object was deleted or if the status of the DataPlane was
+// updated.
+func (r *DataPlaneReconciler) ensureManagedEtcdIsDeleted(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (bool, error) {
+	var deleted bool
+
+	statefulSets, err := k8sutils.ListStatefulSetsForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.EtcdManagedLabelValue,
+		dataplane.Namespace,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, err
+	}
+	for i := range statefulSets {
+		if err := r.Client.Delete(ctx, &statefulSets[i]); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		deleted = true
+	}
+
+	services, err := k8sutils.ListServicesForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.EtcdManagedLabelValue,
+		dataplane.Namespace,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, err
+	}
+	for i := range services {
+		if err := r.Client.Delete(ctx, &services[i]); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		deleted = true
+	}
+
+	for _, owner := range []client.Object{
+		&etcdCertificateOwner{DataPlane: dataplane},
+		&etcdClientCertificateOwner{DataPlane: dataplane},
+	} {
+		if r.CertManagerIssuer != nil {
+			certificate := newCertManagerCertificate()
+			certificate.SetNamespace(dataplane.Namespace)
+			certificate.SetName(certManagerCertificateName(owner))
+			// the Secret cert-manager stores the certificate in isn't labelled,
+			// and only garbage collected with the Certificate if cert-manager
+			// is configured so.
+			secret := &corev1.Secret{}
+			secret.SetNamespace(dataplane.Namespace)
+			secret.SetName(certManagerCertificateName(owner))
+			for _, obj := range []client.Object{certificate, secret} {
+				err := r.Client.Delete(ctx, obj)
+				if err == nil {
+					deleted = true
+				} else if !k8serrors.IsNotFound(err) {
+					return false, err
+				}
+			}
+		}
+
+		selectorKey, selectorValue := getManagedLabelForOwner(owner)
+		secrets, err := k8sutils.ListSecretsForOwner(ctx, r.Client, selectorKey, selectorValue, dataplane.UID)
+		if err != nil {
+			return false, err
+		}
+		for i := range secrets {
+			if err := r.Client.Delete(ctx, &secrets[i]); client.IgnoreNotFound(err) != nil {
+				return false, err
+			}
+			deleted = true
+		}
+	}
+
+	if dataplane.Status.ConfigCenter != nil {
+		dataplane.Status.ConfigCenter = nil
+		return true, r.Status().Update(ctx, dataplane)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+)
+
+func TestDataPlaneReconciler_ManagedEtcd(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	ca := newTestCASecret(t, "ca")
+	replicas := int32(3)
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+		Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+			ConfigCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+				Mode:    apisixoperatorv1alpha1.ConfigCenterModeEtcd,
+				Managed: &apisixoperatorv1alpha1.ManagedEtcdConfigCenter{Replicas: &replicas},
+			},
+		},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(ca, dataplane).Build()
+	r := &DataPlaneReconciler{
+		Client:                   c,
+		Scheme:                   scheme,
+		ClusterCASecretName:      ca.Name,
+		ClusterCASecretNamespace: ca.Namespace,
+	}
+	ctx := context.Background()
+
+	getDataPlane := func() *apisixoperatorv1alpha1.DataPlane {
+		current := &apisixoperatorv1alpha1.DataPlane{}
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(dataplane), current))
+		return current
+	}
+	ensure := func() (*apisixoperatorv1alpha1.EtcdConfigCenter, *corev1.Secret, *corev1.Secret) {
+		for i := 0; i < 10; i++ {
+			createdOrUpdated, etcd, certSecret, clientCertSecret, err := r.ensureManagedEtcdForDataPlane(ctx, getDataPlane())
+			require.NoError(t, err)
+			if !createdOrUpdated {
+				return etcd, certSecret, clientCertSecret
+			}
+		}
+		require.Fail(t, "managed etcd cluster was not provisioned")
+		return nil, nil, nil
+	}
+
+	t.Log("provisioning the etcd cluster")
+	etcd, certSecret, clientCertSecret := ensure()
+	require.Equal(t, []string{
+		"https://etcd-test-0.etcd-test.default.svc:2379",
+		"https://etcd-test-1.etcd-test.default.svc:2379",
+		"https://etcd-test-2.etcd-test.default.svc:2379",
+	}, etcd.Endpoints)
+	require.Equal(t, consts.EtcdManagedLabelValue, certSecret.Labels[consts.GatewayOperatorControlledLabel])
+	cert, err := parseCertificateSecret(certSecret)
+	require.NoError(t, err)
+	require.Equal(t, []string{"*.etcd-test.default.svc"}, cert.DNSNames)
+
+	t.Log("issuing a separate client certificate for the proxy")
+	require.NotEqual(t, certSecret.Name, clientCertSecret.Name)
+	require.Equal(t, clientCertSecret.Name, etcd.TLS.ClientCertificateSecretRef.Name)
+	require.Equal(t, clientCertSecret.Name, etcd.TLS.CASecretRef.Name)
+	require.Equal(t, consts.EtcdClientManagedLabelValue, clientCertSecret.Labels[consts.GatewayOperatorControlledLabel])
+	clientCert, err := parseCertificateSecret(clientCertSecret)
+	require.NoError(t, err)
+	require.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, clientCert.ExtKeyUsage)
+
+	service := &corev1.Service{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "etcd-test"}, service))
+	require.Equal(t, corev1.ClusterIPNone, service.Spec.ClusterIP)
+
+	statefulSet := &appsv1.StatefulSet{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "etcd-test"}, statefulSet))
+	require.Equal(t, replicas, *statefulSet.Spec.Replicas)
+	require.Equal(t, "etcd-test", statefulSet.Spec.ServiceName)
+	require.Contains(t, statefulSet.Spec.Template.Spec.Containers[0].Args,
+		"--initial-cluster=etcd-test-0=https://etcd-test-0.etcd-test.default.svc:2380,"+
+			"etcd-test-1=https://etcd-test-1.etcd-test.default.svc:2380,"+
+			"etcd-test-2=https://etcd-test-2.etcd-test.default.svc:2380")
+	require.Equal(t, certificateHash(certSecret), statefulSet.Spec.Template.Annotations[consts.EtcdCertificateHashAnnotation])
+	require.Len(t, statefulSet.Spec.VolumeClaimTemplates, 1)
+	require.Equal(t, defaultEtcdStorageSize, statefulSet.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage])
+
+	status := getDataPlane().Status.ConfigCenter
+	require.NotNil(t, status)
+	require.Equal(t, etcd.Endpoints, status.Endpoints)
+	require.Zero(t, status.ReadyMembers)
+
+	t.Log("keeping the number of members of the existing cluster")
+	updated := getDataPlane()
+	replicas = 5
+	updated.Spec.ConfigCenter.Managed.Replicas = &replicas
+	require.NoError(t, c.Update(ctx, updated))
+	etcd, _, _ = ensure()
+	require.Len(t, etcd.Endpoints, 3)
+
+	t.Log("reverting manual edits of the pods of the members")
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(statefulSet), statefulSet))
+	generatedTemplate := statefulSet.Spec.Template.DeepCopy()
+	statefulSet.Spec.Template.Spec.Containers[0].Image = "example/etcd:edited"
+	statefulSet.Spec.Template.Spec.NodeSelector = map[string]string{"example.com/node": "edited"}
+	require.NoError(t, c.Update(ctx, statefulSet))
+	ensure()
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(statefulSet), statefulSet))
+	require.Equal(t, *generatedTemplate, statefulSet.Spec.Template)
+
+	t.Log("reporting the health of the members")
+	for i, ready := range []corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionFalse} {
+		require.NoError(t, c.Create(ctx, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      etcdMemberNames("etcd-test", 3)[i],
+				Labels:    map[string]string{"app": "etcd-test"},
+			},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}))
+	}
+	ensure()
+	status = getDataPlane().Status.ConfigCenter
+	require.Equal(t, int32(1), status.ReadyMembers)
+	require.Equal(t, []apisixoperatorv1alpha1.EtcdMemberStatus{
+		{Name: "etcd-test-0", Ready: true},
+		{Name: "etcd-test-1", Ready: false},
+	}, status.Members)
+
+	t.Log("deleting the etcd cluster once it's no longer managed")
+	updated = getDataPlane()
+	updated.Spec.ConfigCenter = nil
+	require.NoError(t, c.Update(ctx, updated))
+	for deleted := true; deleted; {
+		deleted, err = r.ensureManagedEtcdIsDeleted(ctx, getDataPlane())
+		require.NoError(t, err)
+	}
+	require.Nil(t, getDataPlane().Status.ConfigCenter)
+	statefulSets := &appsv1.StatefulSetList{}
+	require.NoError(t, c.List(ctx, statefulSets, client.InNamespace("default")))
+	require.Empty(t, statefulSets.Items)
+	services := &corev1.ServiceList{}
+	require.NoError(t, c.List(ctx, services, client.InNamespace("default")))
+	require.Empty(t, services.Items)
+	secrets := &corev1.SecretList{}
+	require.NoError(t, c.List(ctx, secrets, client.InNamespace("default")))
+	require.Empty(t, secrets.Items)
+}
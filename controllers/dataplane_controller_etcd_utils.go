@@ -0,0 +1,421 @@
+package controllers
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlane Managed Etcd - Private Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	etcdCertificateVolumeName = "etcd-certificate"
+	etcdCertificateMountPath  = "/etc/etcd/tls"
+	etcdDataVolumeName        = "data"
+	etcdDataMountPath         = "/var/run/etcd"
+)
+
+// defaultEtcdStorageSize is the size of the volume of each member of the etcd
+// clusters provisioned for DataPlanes when their storage is not set.
+var defaultEtcdStorageSize = resource.MustParse("1Gi")
+
+// etcdCertificateUsages are the key usages of the certificate of the etcd
+// clusters provisioned for DataPlanes: it's used by the members to serve the
+// clients and to authenticate to each other.
+var etcdCertificateUsages = []certificatesv1.KeyUsage{
+	certificatesv1.UsageKeyEncipherment,
+	certificatesv1.UsageDigitalSignature,
+	certificatesv1.UsageServerAuth,
+	certificatesv1.UsageClientAuth,
+}
+
+// etcdClientCertificateUsages are the key usages of the certificate the
+// proxies of DataPlanes authenticate to their etcd cluster with.
+var etcdClientCertificateUsages = []certificatesv1.KeyUsage{
+	certificatesv1.UsageKeyEncipherment,
+	certificatesv1.UsageDigitalSignature,
+	certificatesv1.UsageClientAuth,
+}
+
+// etcdCertificateOwner is the owner of the certificate of the etcd cluster
+// provisioned for a DataPlane. It's the DataPlane itself, the certificate
+// being told apart from the mTLS certificate of the DataPlane by its
+// managed label and name prefix.
+type etcdCertificateOwner struct {
+	*apisixoperatorv1alpha1.DataPlane
+}
+
+// etcdClientCertificateOwner is the owner of the client certificate the proxy
+// of a DataPlane authenticates to its etcd cluster with. Like the certificate
+// of the cluster, it's told apart by its managed label and name prefix.
+type etcdClientCertificateOwner struct {
+	*apisixoperatorv1alpha1.DataPlane
+}
+
+// -----------------------------------------------------------------------------
+// DataPlane Managed Etcd - Private Functions - Generators
+// -----------------------------------------------------------------------------
+
+// isManagedEtcdEnabled returns true if the operator has to provision an etcd
+// cluster for the provided DataPlane.
+func isManagedEtcdEnabled(dataplane *apisixoperatorv1alpha1.DataPlane) bool {
+	configCenter := dataplane.Spec.ConfigCenter
+	return configCenter != nil &&
+		configCenter.Mode == apisixoperatorv1alpha1.ConfigCenterModeEtcd &&
+		configCenter.Managed != nil
+}
+
+// etcdNameForDataPlane returns the name of the StatefulSet and of the headless
+// Service of the etcd cluster provisioned for the provided DataPlane. Unlike
+// the other owned objects, it's not generated, as the members have to know
+// the names of each other when bootstrapping the cluster.
+func etcdNameForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) string {
+	return fmt.Sprintf("%s-%s", consts.EtcdPrefix, dataplane.Name)
+}
+
+// etcdCertificateSubject returns the subject of the certificate of the etcd
+// cluster exposed by the provided headless Service, which covers all its members.
+func etcdCertificateSubject(namespace, serviceName string) string {
+	return fmt.Sprintf("*.%s.%s.svc", serviceName, namespace)
+}
+
+// etcdClientCertificateSubject returns the subject of the client certificate
+// of the proxy of the provided DataPlane. It's arbitrary, the members only
+// check that the client certificates are signed by the trusted CA.
+func etcdClientCertificateSubject(dataplane *apisixoperatorv1alpha1.DataPlane) string {
+	return fmt.Sprintf("%s.%s", dataplane.Name, dataplane.Namespace)
+}
+
+// etcdMemberNames returns the names of the members of the provided etcd StatefulSet.
+func etcdMemberNames(statefulSetName string, replicas int32) []string {
+	names := make([]string, 0, replicas)
+	for i := int32(0); i < replicas; i++ {
+		names = append(names, fmt.Sprintf("%s-%d", statefulSetName, i))
+	}
+	return names
+}
+
+// etcdMemberHost returns the host name of an etcd member exposed by the
+// provided headless Service.
+func etcdMemberHost(member, namespace, serviceName string) string {
+	return fmt.Sprintf("%s.%s.%s.svc", member, serviceName, namespace)
+}
+
+// etcdReplicasForDataPlane returns the number of members of the etcd cluster
+// provisioned for the provided DataPlane.
+func etcdReplicasForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) int32 {
+	if replicas := dataplane.Spec.ConfigCenter.Managed.Replicas; replicas != nil {
+		return *replicas
+	}
+	return consts.DefaultEtcdReplicas
+}
+
+// generateEtcdClientEndpoints returns the client URLs of the members of the
+// provided etcd StatefulSet.
+func generateEtcdClientEndpoints(statefulSet *appsv1.StatefulSet) []string {
+	var replicas int32
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	members := etcdMemberNames(statefulSet.Name, replicas)
+	endpoints := make([]string, 0, len(members))
+	for _, member := range members {
+		endpoints = append(endpoints, fmt.Sprintf("https://%s:%d",
+			etcdMemberHost(member, statefulSet.Namespace, statefulSet.Spec.ServiceName), consts.EtcdClientPort))
+	}
+	return endpoints
+}
+
+// generateManagedEtcdConfigCenter returns the etcd options the proxy of a
+// DataPlane is configured with to load its configuration from the etcd
+// cluster provisioned for it. The proxy authenticates with its own client
+// certificate, whose Secret also holds the CA the certificate of the cluster
+// is signed by.
+func generateManagedEtcdConfigCenter(endpoints []string, clientCertSecret *corev1.Secret) *apisixoperatorv1alpha1.EtcdConfigCenter {
+	return &apisixoperatorv1alpha1.EtcdConfigCenter{
+		Endpoints: endpoints,
+		TLS: &apisixoperatorv1alpha1.EtcdTLSConfig{
+			ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: clientCertSecret.Name},
+			CASecretRef:                &corev1.LocalObjectReference{Name: clientCertSecret.Name},
+		},
+	}
+}
+
+func generateEtcdServiceForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) *corev1.Service {
+	name := etcdNameForDataPlane(dataplane)
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: dataplane.Namespace,
+			Name:      name,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  map[string]string{"app": name},
+			// the members have to resolve each other before being ready to bootstrap the cluster.
+			PublishNotReadyAddresses: true,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "client",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       consts.EtcdClientPort,
+					TargetPort: intstr.FromInt(consts.EtcdClientPort),
+				},
+				{
+					Name:       "peer",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       consts.EtcdPeerPort,
+					TargetPort: intstr.FromInt(consts.EtcdPeerPort),
+				},
+			},
+		},
+	}
+}
+
+// generateEtcdStatefulSetForDataPlane returns the StatefulSet of the etcd
+// cluster with the provided number of members, exposed by the provided
+// headless Service, provisioned for the provided DataPlane. The data of the
+// members is always held in persistent volumes: a member is started with the
+// initial cluster state new, which etcd only honours on an empty data
+// directory, so a recreated member has to find its data back to rejoin the
+// cluster.
+func generateEtcdStatefulSetForDataPlane(
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	serviceName string,
+	replicas int32,
+	certSecret *corev1.Secret,
+) *appsv1.StatefulSet {
+	name := etcdNameForDataPlane(dataplane)
+	managed := dataplane.Spec.ConfigCenter.Managed
+
+	image := consts.DefaultEtcdBaseImage
+	if managed.ContainerImage != nil && *managed.ContainerImage != "" {
+		image = *managed.ContainerImage
+	}
+	if managed.Version != nil && *managed.Version != "" {
+		image = fmt.Sprintf("%s:%s", image, *managed.Version)
+	} else if managed.ContainerImage == nil || *managed.ContainerImage == "" {
+		image = fmt.Sprintf("%s:%s", image, consts.DefaultEtcdTag)
+	}
+
+	initialCluster := make([]string, 0, replicas)
+	for _, member := range etcdMemberNames(name, replicas) {
+		initialCluster = append(initialCluster, fmt.Sprintf("%s=https://%s:%d",
+			member, etcdMemberHost(member, dataplane.Namespace, serviceName), consts.EtcdPeerPort))
+	}
+	memberHost := etcdMemberHost("$(POD_NAME)", dataplane.Namespace, serviceName)
+	certFile := path.Join(etcdCertificateMountPath, corev1.TLSCertKey)
+	keyFile := path.Join(etcdCertificateMountPath, corev1.TLSPrivateKeyKey)
+	caFile := path.Join(etcdCertificateMountPath, "ca.crt")
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: dataplane.Namespace,
+			Name:      name,
+			Labels: map[string]string{
+				"app": name,
+			},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: serviceName,
+			// the members are started together, as none of them is ready before
+			// the cluster has a quorum.
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": name,
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": name,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{
+							Name: etcdCertificateVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: certSecret.Name,
+									Items: []corev1.KeyToPath{
+										{Key: "tls.crt", Path: "tls.crt"},
+										{Key: "tls.key", Path: "tls.key"},
+										{Key: "ca.crt", Path: "ca.crt"},
+									},
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{{
+						Name:            consts.EtcdContainerName,
+						Image:           image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"etcd"},
+						Args: []string{
+							"--name=$(POD_NAME)",
+							"--data-dir=" + path.Join(etcdDataMountPath, "data"),
+							fmt.Sprintf("--listen-client-urls=https://0.0.0.0:%d", consts.EtcdClientPort),
+							fmt.Sprintf("--advertise-client-urls=https://%s:%d", memberHost, consts.EtcdClientPort),
+							fmt.Sprintf("--listen-peer-urls=https://0.0.0.0:%d", consts.EtcdPeerPort),
+							fmt.Sprintf("--initial-advertise-peer-urls=https://%s:%d", memberHost, consts.EtcdPeerPort),
+							fmt.Sprintf("--listen-metrics-urls=http://0.0.0.0:%d", consts.EtcdMetricsPort),
+							"--initial-cluster=" + strings.Join(initialCluster, ","),
+							"--initial-cluster-state=new",
+							"--initial-cluster-token=" + name,
+							"--client-cert-auth",
+							"--trusted-ca-file=" + caFile,
+							"--cert-file=" + certFile,
+							"--key-file=" + keyFile,
+							"--peer-client-cert-auth",
+							"--peer-trusted-ca-file=" + caFile,
+							"--peer-cert-file=" + certFile,
+							"--peer-key-file=" + keyFile,
+						},
+						Env: []corev1.EnvVar{
+							{
+								Name: "POD_NAME",
+								ValueFrom: &corev1.EnvVarSource{
+									FieldRef: &corev1.ObjectFieldSelector{
+										APIVersion: "v1",
+										FieldPath:  "metadata.name",
+									},
+								},
+							},
+						},
+						Ports: []corev1.ContainerPort{
+							{
+								Name:          "client",
+								ContainerPort: consts.EtcdClientPort,
+								Protocol:      corev1.ProtocolTCP,
+							},
+							{
+								Name:          "peer",
+								ContainerPort: consts.EtcdPeerPort,
+								Protocol:      corev1.ProtocolTCP,
+							},
+							{
+								Name:          "metrics",
+								ContainerPort: consts.EtcdMetricsPort,
+								Protocol:      corev1.ProtocolTCP,
+							},
+						},
+						VolumeMounts: []corev1.VolumeMount{
+							{
+								Name:      etcdCertificateVolumeName,
+								ReadOnly:  true,
+								MountPath: etcdCertificateMountPath,
+							},
+							{
+								Name:      etcdDataVolumeName,
+								MountPath: etcdDataMountPath,
+							},
+						},
+						// a member is only healthy while the cluster has a quorum.
+						ReadinessProbe: &corev1.Probe{
+							FailureThreshold:    3,
+							InitialDelaySeconds: 5,
+							PeriodSeconds:       10,
+							SuccessThreshold:    1,
+							TimeoutSeconds:      5,
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path:   "/health",
+									Port:   intstr.FromInt(consts.EtcdMetricsPort),
+									Scheme: corev1.URISchemeHTTP,
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	size := defaultEtcdStorageSize
+	var storageClassName *string
+	if storage := managed.Storage; storage != nil {
+		size = storage.Size
+		storageClassName = storage.StorageClassName
+	}
+	statefulSet.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: etcdDataVolumeName,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}}
+
+	return statefulSet
+}
+
+// -----------------------------------------------------------------------------
+// DataPlane Managed Etcd - Private Functions - Status
+// -----------------------------------------------------------------------------
+
+// generateEtcdMemberStatuses returns the health of the members of an etcd
+// cluster from their pods, sorted by name.
+func generateEtcdMemberStatuses(pods []corev1.Pod) ([]apisixoperatorv1alpha1.EtcdMemberStatus, int32) {
+	var (
+		members []apisixoperatorv1alpha1.EtcdMemberStatus
+		ready   int32
+	)
+	for _, pod := range pods {
+		member := apisixoperatorv1alpha1.EtcdMemberStatus{Name: pod.Name}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				member.Ready = pod.DeletionTimestamp == nil
+			}
+		}
+		if member.Ready {
+			ready++
+		}
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return members, ready
+}
+
+// -----------------------------------------------------------------------------
+// DataPlane Managed Etcd - Private Functions - Kubernetes Object Labels
+// -----------------------------------------------------------------------------
+
+func addLabelForEtcd(obj client.Object) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[consts.GatewayOperatorControlledLabel] = consts.EtcdManagedLabelValue
+	obj.SetLabels(labels)
+}
+
+func addLabelForEtcdClient(obj client.Object) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[consts.GatewayOperatorControlledLabel] = consts.EtcdClientManagedLabelValue
+	obj.SetLabels(labels)
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 
 	appsv1 "k8s.io/api/apps/v1"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 	"github.com/chever-john/apisix-operator/internal/consts"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
 	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
-	k8sresources "github.com/chever-john/apisix-operator/internal/utils/kubernetes/resources"
 )
 
 // -----------------------------------------------------------------------------
@@ -51,6 +55,21 @@ func (r *DataPlaneReconciler) ensureIsMarkedProvisioned(
 	k8sutils.SetReady(dataplane)
 }
 
+// ensureIsMarkedCertificateNotIssued reports on the status of the provided
+// DataPlane why one of its certificates was not issued.
+func (r *DataPlaneReconciler) ensureIsMarkedCertificateNotIssued(
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	err error,
+) {
+	condition := k8sutils.NewCondition(
+		DataPlaneConditionTypeProvisioned,
+		metav1.ConditionFalse,
+		DataPlaneConditionReasonCertificateNotIssued,
+		err.Error(),
+	)
+	k8sutils.SetCondition(condition, dataplane)
+}
+
 func (r *DataPlaneReconciler) ensureDataPlaneServiceStatus(
 	ctx context.Context,
 	dataplane *apisixoperatorv1alpha1.DataPlane,
@@ -60,6 +79,77 @@ func (r *DataPlaneReconciler) ensureDataPlaneServiceStatus(
 	return r.Status().Update(ctx, dataplane)
 }
 
+// ensureDataPlaneDeploymentStatus reports the addresses the provided Service
+// of the DataPlane is reachable at, the replicas of its workload and the image
+// of its proxy in the status of the DataPlane, along with the generation they
+// were reported for. It returns true if the status was updated.
+func (r *DataPlaneReconciler) ensureDataPlaneDeploymentStatus(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	service *corev1.Service,
+	template *corev1.PodTemplateSpec,
+	replicas, readyReplicas int32,
+) (bool, error) {
+	var nodes []corev1.Node
+	if service.Spec.Type == corev1.ServiceTypeNodePort {
+		var err error
+		nodes, err = r.getNodesForDataPlaneService(ctx, service)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	status := dataplane.Status.DeepCopy()
+	status.Addresses = generateAddressesForDataPlane(service, nodes)
+	status.Replicas = replicas
+	status.ReadyReplicas = readyReplicas
+	status.Image = proxyImageForDataPlane(template)
+	status.ObservedGeneration = dataplane.Generation
+	if equality.Semantic.DeepEqual(&dataplane.Status, status) {
+		return false, nil
+	}
+
+	dataplane.Status = *status
+	return true, r.Status().Update(ctx, dataplane)
+}
+
+// getNodesForDataPlaneService returns the nodes running the pods selected by
+// the provided Service of a DataPlane, sorted by name. Only these nodes are
+// guaranteed to serve its node ports, whatever its external traffic policy.
+func (r *DataPlaneReconciler) getNodesForDataPlaneService(
+	ctx context.Context,
+	service *corev1.Service,
+) ([]corev1.Node, error) {
+	pods := &corev1.PodList{}
+	if err := r.Client.List(ctx, pods,
+		client.InNamespace(service.Namespace),
+		client.MatchingLabels(service.Spec.Selector),
+	); err != nil {
+		return nil, err
+	}
+
+	nodeNames := make(map[string]struct{})
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" && pod.DeletionTimestamp == nil {
+			nodeNames[pod.Spec.NodeName] = struct{}{}
+		}
+	}
+
+	nodes := make([]corev1.Node, 0, len(nodeNames))
+	for nodeName := range nodeNames {
+		node := corev1.Node{}
+		if err := r.Client.Get(ctx, client.ObjectKey{Name: nodeName}, &node); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
 // isSameDataPlaneCondition returns true if two `metav1.Condition`s
 // indicates the same condition of a `DataPlane` resource.
 func isSameDataPlaneCondition(condition1, condition2 metav1.Condition) bool {
@@ -124,17 +214,26 @@ func (r *DataPlaneReconciler) ensureCertificate(
 	}
 	return maybeCreateCertificateSecret(ctx,
 		dataplane,
-		fmt.Sprintf("%s.%s.svc", serviceName, dataplane.Namespace),
-		r.ClusterCASecretName,
-		r.ClusterCASecretNamespace,
+		dataplaneCertificateSubject(dataplane.Namespace, serviceName),
 		usages,
+		newCertificateIssuer(
+			r.CertManagerIssuer,
+			r.UseCertificateSigningRequests,
+			r.ClusterCASecretName,
+			r.ClusterCASecretNamespace,
+			r.ClusterCertificateLifetime,
+			r.ClusterCertificateRenewBefore,
+		),
 		r.Client)
 }
 
 func (r *DataPlaneReconciler) ensureDeploymentForDataPlane(
 	ctx context.Context,
 	dataplane *apisixoperatorv1alpha1.DataPlane,
-	certSecretName string,
+	certSecret *corev1.Secret,
+	etcdCertSecret *corev1.Secret,
+	adminKeySecret *corev1.Secret,
+	configMap *corev1.ConfigMap,
 ) (createdOrUpdate bool, deploy *appsv1.Deployment, err error) {
 	deployments, err := k8sutils.ListDeploymentsForOwner(
 		ctx,
@@ -153,7 +252,11 @@ func (r *DataPlaneReconciler) ensureDeploymentForDataPlane(
 		return false, nil, fmt.Errorf("found %d deployments for DataPlane currently unsupported: expected 1 or less", count)
 	}
 
-	generatedDeployment := generateNewDeploymentForDataPlane(dataplane, certSecretName)
+	generatedDeployment, err := generateNewDeploymentForDataPlane(dataplane, certSecret.Name, configMap.Name)
+	if err != nil {
+		return false, nil, err
+	}
+	setPodTemplateHashAnnotationsForDataPlane(&generatedDeployment.Spec.Template, certSecret, etcdCertSecret, adminKeySecret, configMap)
 	k8sutils.SetOwnerForObject(generatedDeployment, dataplane)
 	addLabelForDataplane(generatedDeployment)
 
@@ -162,24 +265,21 @@ func (r *DataPlaneReconciler) ensureDeploymentForDataPlane(
 		existingDeployment := &deployments[0]
 		updated, existingDeployment.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingDeployment.ObjectMeta, generatedDeployment.ObjectMeta)
 
-		// We do not want to permit direct edits of the Deployment environment. Any user-supplied values should be set
-		// in the DataPlane. If the actual Deployment environment does not match the generated environment, either
-		// something requires an update or there was a manual edit we want to purge.
-		container := k8sresources.GetPodContainerByName(&existingDeployment.Spec.Template.Spec, consts.DataPlaneProxyContainerName)
-		if container == nil {
-			// someone has deleted the main container from the Deployment for ??? reasons. we can't fathom why they
-			// would do this, but don't allow it and replace the container set entirely
-			existingDeployment.Spec.Template.Spec.Containers = generatedDeployment.Spec.Template.Spec.Containers
-			updated = true
-			container = k8sresources.GetPodContainerByName(&existingDeployment.Spec.Template.Spec, consts.DataPlaneProxyContainerName)
+		// any drift of the pods from the DataPlane, including the overrides of their template, is
+		// reverted, and a re-issued certificate, a new configuration or a rotated admin key is only
+		// picked up by the proxy on restart, which the hash annotations trigger.
+		templateUpdated, err := ensurePodTemplateIsUpdated(ctx, r.Client, existingDeployment, &generatedDeployment.Spec.Template)
+		if err != nil {
+			return false, nil, err
 		}
-		if !reflect.DeepEqual(container.Env, dataplane.Spec.Env) {
-			container.Env = dataplane.Spec.Env
+		if templateUpdated {
 			updated = true
 		}
 
-		if !reflect.DeepEqual(container.EnvFrom, dataplane.Spec.EnvFrom) {
-			container.EnvFrom = dataplane.Spec.EnvFrom
+		// the replicas of a DataPlane scaled horizontally are left to its HorizontalPodAutoscaler.
+		if !dataplaneIsScaledHorizontally(dataplane) &&
+			!reflect.DeepEqual(existingDeployment.Spec.Replicas, generatedDeployment.Spec.Replicas) {
+			existingDeployment.Spec.Replicas = generatedDeployment.Spec.Replicas
 			updated = true
 		}
 
@@ -192,6 +292,298 @@ func (r *DataPlaneReconciler) ensureDeploymentForDataPlane(
 	return true, generatedDeployment, r.Client.Create(ctx, generatedDeployment)
 }
 
+func (r *DataPlaneReconciler) ensureDaemonSetForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	certSecret *corev1.Secret,
+	etcdCertSecret *corev1.Secret,
+	adminKeySecret *corev1.Secret,
+	configMap *corev1.ConfigMap,
+) (createdOrUpdate bool, daemonSet *appsv1.DaemonSet, err error) {
+	daemonSets, err := k8sutils.ListDaemonSetsForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.DataPlaneManagedLabelValue,
+		dataplane.Namespace,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(daemonSets)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d daemonsets for DataPlane currently unsupported: expected 1 or less", count)
+	}
+
+	generatedDaemonSet, err := generateNewDaemonSetForDataPlane(dataplane, certSecret.Name, configMap.Name)
+	if err != nil {
+		return false, nil, err
+	}
+	setPodTemplateHashAnnotationsForDataPlane(&generatedDaemonSet.Spec.Template, certSecret, etcdCertSecret, adminKeySecret, configMap)
+	k8sutils.SetOwnerForObject(generatedDaemonSet, dataplane)
+	addLabelForDataplane(generatedDaemonSet)
+
+	if count == 1 {
+		var updated bool
+		existingDaemonSet := &daemonSets[0]
+		updated, existingDaemonSet.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingDaemonSet.ObjectMeta, generatedDaemonSet.ObjectMeta)
+
+		// any drift of the pods from the DataPlane, including the overrides of their template, is
+		// reverted, and a re-issued certificate, a new configuration or a rotated admin key is only
+		// picked up by the proxy on restart, which the hash annotations trigger.
+		templateUpdated, err := ensurePodTemplateIsUpdated(ctx, r.Client, existingDaemonSet, &generatedDaemonSet.Spec.Template)
+		if err != nil {
+			return false, nil, err
+		}
+		if templateUpdated {
+			updated = true
+		}
+
+		if updated {
+			return true, existingDaemonSet, r.Client.Update(ctx, existingDaemonSet)
+		}
+		return false, existingDaemonSet, nil
+	}
+
+	return true, generatedDaemonSet, r.Client.Create(ctx, generatedDaemonSet)
+}
+
+// ensureUnusedWorkloadsAreDeletedForDataPlane deletes the workloads of the
+// provided DataPlane which don't match its deployment mode, e.g. its
+// Deployment once it's deployed by a DaemonSet. It returns true if any
+// workload was deleted.
+func (r *DataPlaneReconciler) ensureUnusedWorkloadsAreDeletedForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (bool, error) {
+	var unused []client.Object
+	if dataplaneutils.GetDeploymentMode(dataplane) == apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet {
+		deployments, err := k8sutils.ListDeploymentsForOwner(
+			ctx,
+			r.Client,
+			consts.GatewayOperatorControlledLabel,
+			consts.DataPlaneManagedLabelValue,
+			dataplane.Namespace,
+			dataplane.UID,
+		)
+		if err != nil {
+			return false, err
+		}
+		for i := range deployments {
+			unused = append(unused, &deployments[i])
+		}
+	} else {
+		daemonSets, err := k8sutils.ListDaemonSetsForOwner(
+			ctx,
+			r.Client,
+			consts.GatewayOperatorControlledLabel,
+			consts.DataPlaneManagedLabelValue,
+			dataplane.Namespace,
+			dataplane.UID,
+		)
+		if err != nil {
+			return false, err
+		}
+		for i := range daemonSets {
+			unused = append(unused, &daemonSets[i])
+		}
+	}
+
+	for _, obj := range unused {
+		if err := r.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+	}
+	return len(unused) > 0, nil
+}
+
+// setPodTemplateHashAnnotationsForDataPlane sets the annotations rolling the
+// pods of a DataPlane on the provided template: the hashes of its cluster and
+// etcd certificates, of its configuration and of its Admin API key.
+func setPodTemplateHashAnnotationsForDataPlane(
+	template *corev1.PodTemplateSpec,
+	certSecret *corev1.Secret,
+	etcdCertSecret *corev1.Secret,
+	adminKeySecret *corev1.Secret,
+	configMap *corev1.ConfigMap,
+) {
+	setCertificateHashAnnotation(template, consts.ClusterCertificateHashAnnotation, certSecret)
+	if etcdCertSecret != nil {
+		setCertificateHashAnnotation(template, consts.EtcdCertificateHashAnnotation, etcdCertSecret)
+	}
+	setConfigHashAnnotation(template, configMap)
+	setAdminKeyHashAnnotation(template, adminKeySecret)
+}
+
+// ensureAdminKeySecretForDataPlane ensures the Secret holding the Admin API
+// key of the provided DataPlane exists. The key is generated once, and
+// re-generated whenever the value of the rotation annotation of the DataPlane
+// differs from the one recorded on the Secret.
+func (r *DataPlaneReconciler) ensureAdminKeySecretForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (createdOrUpdated bool, secret *corev1.Secret, err error) {
+	secrets, err := k8sutils.ListSecretsForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.AdminKeyManagedLabelValue,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(secrets)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d admin key secrets for DataPlane currently unsupported: expected 1 or less", count)
+	}
+
+	generatedSecret, err := generateNewAdminKeySecretForDataPlane(dataplane)
+	if err != nil {
+		return false, nil, err
+	}
+	k8sutils.SetOwnerForObject(generatedSecret, dataplane)
+	addLabelForAdminKey(generatedSecret)
+
+	if count == 1 {
+		var updated bool
+		existingSecret := &secrets[0]
+		updated, existingSecret.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingSecret.ObjectMeta, generatedSecret.ObjectMeta)
+
+		if len(existingSecret.Data[dataplaneutils.AdminKeySecretKey]) == 0 ||
+			existingSecret.Annotations[consts.RotateAdminKeyAnnotation] != generatedSecret.Annotations[consts.RotateAdminKeyAnnotation] {
+			existingSecret.Data = generatedSecret.Data
+			if existingSecret.Annotations == nil {
+				existingSecret.Annotations = make(map[string]string)
+			}
+			existingSecret.Annotations[consts.RotateAdminKeyAnnotation] = generatedSecret.Annotations[consts.RotateAdminKeyAnnotation]
+			updated = true
+		}
+
+		if updated {
+			return true, existingSecret, r.Client.Update(ctx, existingSecret)
+		}
+		return false, existingSecret, nil
+	}
+
+	return true, generatedSecret, r.Client.Create(ctx, generatedSecret)
+}
+
+// ensureHorizontalPodAutoscalerForDataPlane ensures the provided Deployment of
+// the DataPlane is scaled by a HorizontalPodAutoscaler if the DataPlane is
+// scaled horizontally, and deletes it otherwise. It returns true if any
+// HorizontalPodAutoscaler was created, updated or deleted.
+func (r *DataPlaneReconciler) ensureHorizontalPodAutoscalerForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	deploymentName string,
+) (bool, error) {
+	hpas, err := k8sutils.ListHorizontalPodAutoscalersForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.DataPlaneManagedLabelValue,
+		dataplane.Namespace,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if !dataplaneIsScaledHorizontally(dataplane) {
+		var deleted bool
+		for i := range hpas {
+			if err := r.Client.Delete(ctx, &hpas[i]); client.IgnoreNotFound(err) != nil {
+				return false, err
+			}
+			deleted = true
+		}
+		return deleted, nil
+	}
+
+	count := len(hpas)
+	if count > 1 {
+		return false, fmt.Errorf("found %d horizontalpodautoscalers for DataPlane currently unsupported: expected 1 or less", count)
+	}
+
+	generatedHPA := generateNewHorizontalPodAutoscalerForDataPlane(dataplane, deploymentName)
+	k8sutils.SetOwnerForObject(generatedHPA, dataplane)
+	addLabelForDataplane(generatedHPA)
+
+	if count == 1 {
+		var updated bool
+		existingHPA := &hpas[0]
+		updated, existingHPA.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingHPA.ObjectMeta, generatedHPA.ObjectMeta)
+
+		// the behavior of the HorizontalPodAutoscaler is defaulted by the API server.
+		if !reflect.DeepEqual(existingHPA.Spec.ScaleTargetRef, generatedHPA.Spec.ScaleTargetRef) ||
+			!reflect.DeepEqual(existingHPA.Spec.MinReplicas, generatedHPA.Spec.MinReplicas) ||
+			existingHPA.Spec.MaxReplicas != generatedHPA.Spec.MaxReplicas ||
+			!equality.Semantic.DeepEqual(existingHPA.Spec.Metrics, generatedHPA.Spec.Metrics) {
+			existingHPA.Spec.ScaleTargetRef = generatedHPA.Spec.ScaleTargetRef
+			existingHPA.Spec.MinReplicas = generatedHPA.Spec.MinReplicas
+			existingHPA.Spec.MaxReplicas = generatedHPA.Spec.MaxReplicas
+			existingHPA.Spec.Metrics = generatedHPA.Spec.Metrics
+			updated = true
+		}
+
+		if updated {
+			return true, r.Client.Update(ctx, existingHPA)
+		}
+		return false, nil
+	}
+
+	return true, r.Client.Create(ctx, generatedHPA)
+}
+
+func (r *DataPlaneReconciler) ensureConfigMapForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (createdOrUpdated bool, configMap *corev1.ConfigMap, err error) {
+	configMaps, err := k8sutils.ListConfigMapsForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.DataPlaneManagedLabelValue,
+		dataplane.Namespace,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(configMaps)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d configmaps for DataPlane currently unsupported: expected 1 or less", count)
+	}
+
+	generatedConfigMap, err := generateNewConfigMapForDataPlane(dataplane)
+	if err != nil {
+		return false, nil, err
+	}
+	k8sutils.SetOwnerForObject(generatedConfigMap, dataplane)
+	addLabelForDataplane(generatedConfigMap)
+
+	if count == 1 {
+		var updated bool
+		existingConfigMap := &configMaps[0]
+		updated, existingConfigMap.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingConfigMap.ObjectMeta, generatedConfigMap.ObjectMeta)
+		if !reflect.DeepEqual(existingConfigMap.Data, generatedConfigMap.Data) {
+			existingConfigMap.Data = generatedConfigMap.Data
+			updated = true
+		}
+		if updated {
+			return true, existingConfigMap, r.Client.Update(ctx, existingConfigMap)
+		}
+		return false, existingConfigMap, nil
+	}
+
+	return true, generatedConfigMap, r.Client.Create(ctx, generatedConfigMap)
+}
+
 func (r *DataPlaneReconciler) ensureServiceForDataPlane(
 	ctx context.Context,
 	dataplane *apisixoperatorv1alpha1.DataPlane,
@@ -221,6 +613,87 @@ func (r *DataPlaneReconciler) ensureServiceForDataPlane(
 		var updated bool
 		existingService := &services[0]
 		updated, existingService.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingService.ObjectMeta, generatedService.ObjectMeta)
+
+		if ensureServiceAnnotations(existingService, generatedService) {
+			updated = true
+		}
+
+		typeChanged := existingService.Spec.Type != generatedService.Spec.Type
+		if typeChanged ||
+			existingService.Spec.ExternalTrafficPolicy != generatedService.Spec.ExternalTrafficPolicy ||
+			!reflect.DeepEqual(existingService.Spec.LoadBalancerSourceRanges, generatedService.Spec.LoadBalancerSourceRanges) {
+			existingService.Spec.Type = generatedService.Spec.Type
+			existingService.Spec.ExternalTrafficPolicy = generatedService.Spec.ExternalTrafficPolicy
+			existingService.Spec.LoadBalancerSourceRanges = generatedService.Spec.LoadBalancerSourceRanges
+			updated = true
+		}
+
+		if typeChanged || !servicePortsEqual(existingService.Spec.Ports, generatedService.Spec.Ports) {
+			// keep the node ports already allocated to the ports which are still exposed,
+			// unless they're set explicitly or the Service is no longer exposed on the nodes.
+			if generatedService.Spec.Type != corev1.ServiceTypeClusterIP {
+				nodePorts := make(map[string]int32, len(existingService.Spec.Ports))
+				for _, port := range existingService.Spec.Ports {
+					nodePorts[port.Name] = port.NodePort
+				}
+				for i := range generatedService.Spec.Ports {
+					if generatedService.Spec.Ports[i].NodePort == 0 {
+						generatedService.Spec.Ports[i].NodePort = nodePorts[generatedService.Spec.Ports[i].Name]
+					}
+				}
+			}
+			existingService.Spec.Ports = generatedService.Spec.Ports
+			updated = true
+		}
+
+		if updated {
+			return true, existingService, r.Client.Update(ctx, existingService)
+		}
+		return false, existingService, nil
+	}
+
+	return true, generatedService, r.Client.Create(ctx, generatedService)
+}
+
+// ensureAdminServiceForDataPlane ensures the ClusterIP Service exposing the
+// Admin API of the provided DataPlane to its ControlPlane exists.
+func (r *DataPlaneReconciler) ensureAdminServiceForDataPlane(
+	ctx context.Context,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (createdOrUpdated bool, svc *corev1.Service, err error) {
+	services, err := k8sutils.ListServicesForOwner(
+		ctx,
+		r.Client,
+		consts.GatewayOperatorControlledLabel,
+		consts.AdminServiceManagedLabelValue,
+		dataplane.Namespace,
+		dataplane.UID,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(services)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d admin services for DataPlane currently unsupported: expected 1 or less", count)
+	}
+
+	generatedService := generateNewAdminServiceForDataPlane(dataplane)
+	addLabelForAdminService(generatedService)
+	k8sutils.SetOwnerForObject(generatedService, dataplane)
+
+	if count == 1 {
+		var updated bool
+		existingService := &services[0]
+		updated, existingService.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingService.ObjectMeta, generatedService.ObjectMeta)
+
+		if !reflect.DeepEqual(existingService.Spec.Selector, generatedService.Spec.Selector) ||
+			!servicePortsEqual(existingService.Spec.Ports, generatedService.Spec.Ports) {
+			existingService.Spec.Selector = generatedService.Spec.Selector
+			existingService.Spec.Ports = generatedService.Spec.Ports
+			updated = true
+		}
+
 		if updated {
 			return true, existingService, r.Client.Update(ctx, existingService)
 		}
@@ -0,0 +1,584 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
+)
+
+func TestDataPlaneReconciler_ensureConfigMapForDataPlane(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	r := &DataPlaneReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+
+	createdOrUpdated, configMap, err := r.ensureConfigMapForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, consts.DataPlaneManagedLabelValue, configMap.Labels[consts.GatewayOperatorControlledLabel])
+	require.Contains(t, configMap.Data[dataplaneutils.ConfigFileName], "- port: 9080")
+
+	createdOrUpdated, _, err = r.ensureConfigMapForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("following the ports of the DataPlane")
+	dataplane.Spec.Network.Services = &apisixoperatorv1alpha1.DataPlaneServices{
+		Ingress: &apisixoperatorv1alpha1.DataPlaneServiceOptions{
+			Ports: []apisixoperatorv1alpha1.DataPlaneServicePort{
+				{Port: 8080, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+			},
+		},
+	}
+	createdOrUpdated, updatedConfigMap, err := r.ensureConfigMapForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, configMap.Name, updatedConfigMap.Name)
+	require.Contains(t, updatedConfigMap.Data[dataplaneutils.ConfigFileName], "- port: 8080")
+
+	t.Log("rolling the proxies on configuration changes")
+	template := &corev1.PodTemplateSpec{}
+	setConfigHashAnnotation(template, configMap)
+	updatedTemplate := &corev1.PodTemplateSpec{}
+	setConfigHashAnnotation(updatedTemplate, updatedConfigMap)
+	require.True(t, ensurePodTemplateHashAnnotations(template, updatedTemplate))
+	require.Equal(t, configHash(updatedConfigMap), template.Annotations[consts.APISIXConfigHashAnnotation])
+}
+
+func TestDataPlaneReconciler_ensureAdminKeySecretForDataPlane(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	r := &DataPlaneReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+
+	createdOrUpdated, secret, err := r.ensureAdminKeySecretForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, dataplaneutils.AdminKeySecretName(dataplane.Name), secret.Name)
+	require.Equal(t, consts.AdminKeyManagedLabelValue, secret.Labels[consts.GatewayOperatorControlledLabel])
+	key := secret.Data[dataplaneutils.AdminKeySecretKey]
+	require.Len(t, key, 32)
+
+	t.Log("keeping the key across reconciliations")
+	createdOrUpdated, secret, err = r.ensureAdminKeySecretForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+	require.Equal(t, key, secret.Data[dataplaneutils.AdminKeySecretKey])
+
+	t.Log("rotating the key through the annotation of the DataPlane")
+	dataplane.Annotations = map[string]string{consts.RotateAdminKeyAnnotation: "2022-10-01"}
+	createdOrUpdated, rotatedSecret, err := r.ensureAdminKeySecretForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.NotEqual(t, key, rotatedSecret.Data[dataplaneutils.AdminKeySecretKey])
+	require.Equal(t, "2022-10-01", rotatedSecret.Annotations[consts.RotateAdminKeyAnnotation])
+
+	createdOrUpdated, _, err = r.ensureAdminKeySecretForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("rolling the proxies on rotation")
+	template := &corev1.PodTemplateSpec{}
+	setAdminKeyHashAnnotation(template, secret)
+	rotatedTemplate := &corev1.PodTemplateSpec{}
+	setAdminKeyHashAnnotation(rotatedTemplate, rotatedSecret)
+	require.True(t, ensurePodTemplateHashAnnotations(template, rotatedTemplate))
+	require.Equal(t, rotatedTemplate.Annotations[consts.AdminKeyHashAnnotation], template.Annotations[consts.AdminKeyHashAnnotation])
+}
+
+func TestDataPlaneReconciler_ensureDeploymentForDataPlanePodTemplateOverrides(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+		Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+			DataPlaneDeploymentOptions: apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+				DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+					PodTemplateSpec: &corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							NodeSelector: map[string]string{"zone": "a"},
+							Containers: []corev1.Container{{
+								Name: consts.DataPlaneProxyContainerName,
+								Resources: corev1.ResourceRequirements{
+									Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	certSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cert"}}
+	adminKeySecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "admin-key"}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"}}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	r := &DataPlaneReconciler{Client: defaultingClient{c}, Scheme: scheme}
+	ctx := context.Background()
+
+	createdOrUpdated, deployment, err := r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, map[string]string{"zone": "a"}, deployment.Spec.Template.Spec.NodeSelector)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	require.Equal(t, consts.DataPlaneProxyContainerName, container.Name)
+	require.NotEmpty(t, container.Image)
+	require.Equal(t, "1", container.Resources.Limits.Cpu().String())
+
+	t.Log("ignoring the fields defaulted by the API server")
+	existing := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(deployment), existing))
+	existing.Spec.Template.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	existing.Spec.Template.Spec.SchedulerName = corev1.DefaultSchedulerName
+	require.NoError(t, c.Update(ctx, existing))
+	createdOrUpdated, _, err = r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("restoring the overrides after manual edits")
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(deployment), existing))
+	existing.Spec.Template.Spec.NodeSelector = nil
+	existing.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{}
+	require.NoError(t, c.Update(ctx, existing))
+	createdOrUpdated, deployment, err = r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, map[string]string{"zone": "a"}, deployment.Spec.Template.Spec.NodeSelector)
+	require.Equal(t, "1", deployment.Spec.Template.Spec.Containers[0].Resources.Limits.Cpu().String())
+
+	t.Log("removing the annotations once unset from the overrides")
+	dataplane.Spec.PodTemplateSpec.Annotations = map[string]string{"example.com/sidecar": "true"}
+	createdOrUpdated, deployment, err = r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, "true", deployment.Spec.Template.Annotations["example.com/sidecar"])
+	dataplane.Spec.PodTemplateSpec.Annotations = nil
+	createdOrUpdated, deployment, err = r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.NotContains(t, deployment.Spec.Template.Annotations, "example.com/sidecar")
+	require.NotContains(t, deployment.Spec.Template.Annotations, consts.LastAppliedAnnotationsAnnotation)
+}
+
+func TestDataPlaneReconciler_ensureHorizontalPodAutoscalerForDataPlane(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	minReplicas := int32(2)
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+		Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+			DataPlaneDeploymentOptions: apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+				Scaling: &apisixoperatorv1alpha1.DataPlaneScaling{
+					Horizontal: &apisixoperatorv1alpha1.HorizontalScaling{
+						MinReplicas: &minReplicas,
+						MaxReplicas: 5,
+						CustomMetrics: []apisixoperatorv1alpha1.CustomMetricTarget{
+							{Name: "apisix_http_requests", AverageValue: resource.MustParse("100")},
+						},
+					},
+				},
+			},
+		},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	r := &DataPlaneReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+
+	listHPAs := func() []autoscalingv2.HorizontalPodAutoscaler {
+		hpas := &autoscalingv2.HorizontalPodAutoscalerList{}
+		require.NoError(t, c.List(ctx, hpas, client.InNamespace("default")))
+		return hpas.Items
+	}
+
+	changed, err := r.ensureHorizontalPodAutoscalerForDataPlane(ctx, dataplane, "dataplane-test-abcde")
+	require.NoError(t, err)
+	require.True(t, changed)
+	hpas := listHPAs()
+	require.Len(t, hpas, 1)
+	require.Equal(t, "dataplane-test-abcde", hpas[0].Spec.ScaleTargetRef.Name)
+	require.Equal(t, minReplicas, *hpas[0].Spec.MinReplicas)
+	require.Equal(t, int32(5), hpas[0].Spec.MaxReplicas)
+	require.Len(t, hpas[0].Spec.Metrics, 1)
+	require.Equal(t, "apisix_http_requests", hpas[0].Spec.Metrics[0].Pods.Metric.Name)
+
+	t.Log("ignoring the behavior defaulted by the API server")
+	hpas[0].Spec.Behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{}
+	require.NoError(t, c.Update(ctx, &hpas[0]))
+	changed, err = r.ensureHorizontalPodAutoscalerForDataPlane(ctx, dataplane, "dataplane-test-abcde")
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	t.Log("following the scaling options of the DataPlane")
+	dataplane.Spec.Scaling.Horizontal.MaxReplicas = 10
+	dataplane.Spec.Scaling.Horizontal.CustomMetrics = nil
+	changed, err = r.ensureHorizontalPodAutoscalerForDataPlane(ctx, dataplane, "dataplane-test-abcde")
+	require.NoError(t, err)
+	require.True(t, changed)
+	hpas = listHPAs()
+	require.Equal(t, int32(10), hpas[0].Spec.MaxReplicas)
+	require.Len(t, hpas[0].Spec.Metrics, 1)
+	require.Equal(t, corev1.ResourceCPU, hpas[0].Spec.Metrics[0].Resource.Name)
+	require.Equal(t, int32(defaultHorizontalScalingCPUUtilization), *hpas[0].Spec.Metrics[0].Resource.Target.AverageUtilization)
+
+	t.Log("deleting the HorizontalPodAutoscaler once the DataPlane is no longer scaled horizontally")
+	dataplane.Spec.Scaling = nil
+	changed, err = r.ensureHorizontalPodAutoscalerForDataPlane(ctx, dataplane, "dataplane-test-abcde")
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Empty(t, listHPAs())
+}
+
+func TestDataPlaneReconciler_ensureDaemonSetForDataPlane(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+		Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+			DataPlaneDeploymentOptions: apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+				DeploymentMode: apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet,
+			},
+		},
+	}
+	certSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cert"}}
+	adminKeySecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "admin-key"}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"}}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	r := &DataPlaneReconciler{Client: defaultingClient{c}, Scheme: scheme}
+	ctx := context.Background()
+
+	createdOrUpdated, daemonSet, err := r.ensureDaemonSetForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	container := daemonSet.Spec.Template.Spec.Containers[0]
+	require.Equal(t, consts.DataPlaneProxyContainerName, container.Name)
+	for _, port := range container.Ports {
+		if port.Name == "metrics" || port.Name == "admin-ssl" {
+			require.Zero(t, port.HostPort, "port %s should not be bound on the nodes", port.Name)
+		} else {
+			require.Equal(t, port.ContainerPort, port.HostPort, "port %s should be bound on the nodes", port.Name)
+		}
+	}
+
+	createdOrUpdated, _, err = r.ensureDaemonSetForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("running the pods in the network namespace of the nodes")
+	dataplane.Spec.PodTemplateSpec = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			HostNetwork: true,
+			DNSPolicy:   corev1.DNSClusterFirstWithHostNet,
+		},
+	}
+	createdOrUpdated, daemonSet, err = r.ensureDaemonSetForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.True(t, daemonSet.Spec.Template.Spec.HostNetwork)
+	for _, port := range daemonSet.Spec.Template.Spec.Containers[0].Ports {
+		require.Equal(t, port.ContainerPort, port.HostPort, "port %s should be bound on the nodes", port.Name)
+	}
+	createdOrUpdated, _, err = r.ensureDaemonSetForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("purging manual edits of the environment")
+	existing := &appsv1.DaemonSet{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(daemonSet), existing))
+	existing.Spec.Template.Spec.Containers[0].Env = nil
+	require.NoError(t, c.Update(ctx, existing))
+	createdOrUpdated, daemonSet, err = r.ensureDaemonSetForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, generateEnvForDataPlane(dataplane), daemonSet.Spec.Template.Spec.Containers[0].Env)
+
+	t.Log("deleting the workloads of the previous deployment mode")
+	deploymentModeDataPlane := dataplane.DeepCopy()
+	deploymentModeDataPlane.Spec.DeploymentMode = apisixoperatorv1alpha1.DataPlaneDeploymentModeDeployment
+	_, _, err = r.ensureDeploymentForDataPlane(ctx, deploymentModeDataPlane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	deleted, err := r.ensureUnusedWorkloadsAreDeletedForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deployments := &appsv1.DeploymentList{}
+	require.NoError(t, c.List(ctx, deployments, client.InNamespace("default")))
+	require.Empty(t, deployments.Items)
+	deleted, err = r.ensureUnusedWorkloadsAreDeletedForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	deleted, err = r.ensureUnusedWorkloadsAreDeletedForDataPlane(ctx, deploymentModeDataPlane)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	daemonSets := &appsv1.DaemonSetList{}
+	require.NoError(t, c.List(ctx, daemonSets, client.InNamespace("default")))
+	require.Empty(t, daemonSets.Items)
+}
+
+func TestDataPlaneReconciler_ensureServiceForDataPlane(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	nodePort := int32(30080)
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+		Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+			Network: apisixoperatorv1alpha1.DataPlaneNetworkOptions{
+				Services: &apisixoperatorv1alpha1.DataPlaneServices{
+					Ingress: &apisixoperatorv1alpha1.DataPlaneServiceOptions{
+						Type:                  corev1.ServiceTypeNodePort,
+						Annotations:           map[string]string{"lb.example.com/internal": "true"},
+						ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+						Ports: []apisixoperatorv1alpha1.DataPlaneServicePort{
+							{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP, NodePort: &nodePort},
+						},
+					},
+				},
+			},
+		},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	r := &DataPlaneReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+
+	createdOrUpdated, service, err := r.ensureServiceForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, corev1.ServiceTypeNodePort, service.Spec.Type)
+	require.Equal(t, corev1.ServiceExternalTrafficPolicyTypeLocal, service.Spec.ExternalTrafficPolicy)
+	require.Equal(t, "true", service.Annotations["lb.example.com/internal"])
+	require.Len(t, service.Spec.Ports, 1, "the Admin API should not be exposed by the ingress Service")
+	require.Equal(t, nodePort, service.Spec.Ports[0].NodePort)
+
+	createdOrUpdated, _, err = r.ensureServiceForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("keeping the annotations set by other controllers")
+	existing := &corev1.Service{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(service), existing))
+	existing.Annotations["lb.example.com/status"] = "provisioned"
+	require.NoError(t, c.Update(ctx, existing))
+	createdOrUpdated, _, err = r.ensureServiceForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("following the ingress Service options of the DataPlane")
+	ingress := dataplane.Spec.Network.Services.Ingress
+	ingress.Type = corev1.ServiceTypeLoadBalancer
+	ingress.Annotations = nil
+	ingress.ExternalTrafficPolicy = ""
+	ingress.LoadBalancerSourceRanges = []string{"10.0.0.0/8"}
+	ingress.Ports[0].NodePort = nil
+	createdOrUpdated, service, err = r.ensureServiceForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, corev1.ServiceTypeLoadBalancer, service.Spec.Type)
+	require.Equal(t, corev1.ServiceExternalTrafficPolicyTypeCluster, service.Spec.ExternalTrafficPolicy)
+	require.Equal(t, []string{"10.0.0.0/8"}, service.Spec.LoadBalancerSourceRanges)
+	require.Equal(t, map[string]string{"lb.example.com/status": "provisioned"}, service.Annotations)
+	require.Equal(t, nodePort, service.Spec.Ports[0].NodePort, "the node port already allocated should be kept")
+
+	t.Log("no longer exposing the proxy on the nodes")
+	ingress.Type = corev1.ServiceTypeClusterIP
+	ingress.LoadBalancerSourceRanges = nil
+	createdOrUpdated, service, err = r.ensureServiceForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, corev1.ServiceTypeClusterIP, service.Spec.Type)
+	require.Empty(t, service.Spec.ExternalTrafficPolicy)
+	require.Empty(t, service.Spec.LoadBalancerSourceRanges)
+	require.Zero(t, service.Spec.Ports[0].NodePort)
+}
+
+func TestDataPlaneReconciler_ensureAdminServiceForDataPlane(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	adminPort := int32(9180)
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	r := &DataPlaneReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+
+	createdOrUpdated, service, err := r.ensureAdminServiceForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, dataplaneutils.AdminServiceName("test"), service.Name)
+	require.Equal(t, corev1.ServiceTypeClusterIP, service.Spec.Type)
+	require.Len(t, service.Spec.Ports, 1)
+	require.Equal(t, int32(dataplaneutils.DefaultAPISIXAdminPort), service.Spec.Ports[0].Port)
+
+	createdOrUpdated, _, err = r.ensureAdminServiceForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+
+	t.Log("following the port of the Admin API")
+	dataplane.Spec.Config = &apisixoperatorv1alpha1.DataPlaneConfig{
+		Admin: &apisixoperatorv1alpha1.DataPlaneAdminAPIOptions{Port: &adminPort},
+	}
+	createdOrUpdated, service, err = r.ensureAdminServiceForDataPlane(ctx, dataplane)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, int(adminPort), service.Spec.Ports[0].TargetPort.IntValue())
+}
+
+func TestDataPlaneReconciler_ensureDataPlaneDeploymentStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid", Generation: 3},
+	}
+	selector := map[string]string{"app": "test"}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dataplane-test"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort, ClusterIP: "10.0.0.1", Selector: selector},
+	}
+	template := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: consts.DataPlaneProxyContainerName, Image: "apache/apisix:3.0.0-debian"}},
+		},
+	}
+	pod := func(name, nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: selector},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+	node := func(name string, addresses ...corev1.NodeAddress) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Addresses: addresses},
+		}
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(
+		dataplane,
+		pod("test-a", "node-b"),
+		pod("test-b", "node-a"),
+		pod("test-c", "node-b"),
+		pod("test-pending", ""),
+		node("node-a", corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "192.168.0.1"}),
+		node("node-b",
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "192.168.0.2"},
+			corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+		),
+		node("node-unused", corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.2.3.5"}),
+	).Build()
+	r := &DataPlaneReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+
+	updated, err := r.ensureDataPlaneDeploymentStatus(ctx, dataplane, service, template, 3, 2)
+	require.NoError(t, err)
+	require.True(t, updated)
+	require.Equal(t, []apisixoperatorv1alpha1.DataPlaneAddress{
+		{Type: apisixoperatorv1alpha1.DataPlaneAddressTypeIPAddress, Value: "192.168.0.1"},
+		{Type: apisixoperatorv1alpha1.DataPlaneAddressTypeIPAddress, Value: "1.2.3.4"},
+	}, dataplane.Status.Addresses)
+	require.Equal(t, int32(3), dataplane.Status.Replicas)
+	require.Equal(t, int32(2), dataplane.Status.ReadyReplicas)
+	require.Equal(t, "apache/apisix:3.0.0-debian", dataplane.Status.Image)
+	require.Equal(t, int64(3), dataplane.Status.ObservedGeneration)
+
+	updated, err = r.ensureDataPlaneDeploymentStatus(ctx, dataplane, service, template, 3, 2)
+	require.NoError(t, err)
+	require.False(t, updated)
+
+	t.Log("reporting the cluster IP once the Service isn't exposed on node ports anymore")
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	updated, err = r.ensureDataPlaneDeploymentStatus(ctx, dataplane, service, template, 3, 3)
+	require.NoError(t, err)
+	require.True(t, updated)
+	require.Equal(t, []apisixoperatorv1alpha1.DataPlaneAddress{
+		{Type: apisixoperatorv1alpha1.DataPlaneAddressTypeIPAddress, Value: "10.0.0.1"},
+	}, dataplane.Status.Addresses)
+	require.Equal(t, int32(3), dataplane.Status.ReadyReplicas)
+}
+
+func TestDataPlaneReconciler_ensureDeploymentForDataPlaneDrift(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+	}
+	certSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cert"}}
+	adminKeySecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "admin-key"}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"}}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	r := &DataPlaneReconciler{Client: defaultingClient{c}, Scheme: scheme}
+	ctx := context.Background()
+
+	createdOrUpdated, deployment, err := r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+
+	t.Log("following a change of the image of the DataPlane")
+	dataplane.Spec.ContainerImage = pointer.String("apache/apisix")
+	dataplane.Spec.Version = pointer.String("3.1.0-debian")
+	createdOrUpdated, deployment, err = r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.Equal(t, "apache/apisix:3.1.0-debian", deployment.Spec.Template.Spec.Containers[0].Image)
+
+	t.Log("reverting manual edits of the probes and of the volumes")
+	existing := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(deployment), existing))
+	existing.Spec.Template.Spec.Containers[0].ReadinessProbe = nil
+	existing.Spec.Template.Spec.Volumes = append(existing.Spec.Template.Spec.Volumes, corev1.Volume{Name: "tampered"})
+	require.NoError(t, c.Update(ctx, existing))
+	createdOrUpdated, deployment, err = r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.True(t, createdOrUpdated)
+	require.NotNil(t, deployment.Spec.Template.Spec.Containers[0].ReadinessProbe)
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		require.NotEqual(t, "tampered", volume.Name)
+	}
+
+	t.Log("preserving the annotations set by others")
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(deployment), existing))
+	existing.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = "2022-10-01T00:00:00Z"
+	require.NoError(t, c.Update(ctx, existing))
+	createdOrUpdated, deployment, err = r.ensureDeploymentForDataPlane(ctx, dataplane, certSecret, nil, adminKeySecret, configMap)
+	require.NoError(t, err)
+	require.False(t, createdOrUpdated)
+	require.Equal(t, "2022-10-01T00:00:00Z", deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"])
+}
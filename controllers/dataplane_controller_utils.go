@@ -1,35 +1,43 @@
 package controllers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 	"github.com/chever-john/apisix-operator/internal/consts"
 	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+	k8sresources "github.com/chever-john/apisix-operator/internal/utils/kubernetes/resources"
 )
 
 // -----------------------------------------------------------------------------
 // DataPlane - Private Functions - Generators
 // -----------------------------------------------------------------------------
 
-func generateNewDeploymentForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane, certSecretName string) *appsv1.Deployment {
-	var dataplaneImage string
-	if dataplane.Spec.ContainerImage != nil {
-		dataplaneImage = *dataplane.Spec.ContainerImage
-		if dataplane.Spec.Version != nil {
-			dataplaneImage = fmt.Sprintf("%s:%s", dataplaneImage, *dataplane.Spec.Version)
-		}
-	} else {
-		dataplaneImage = consts.DefaultDataPlaneImage
+func generateNewDeploymentForDataPlane(
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	certSecretName string,
+	configMapName string,
+) (*appsv1.Deployment, error) {
+	template, err := generatePodTemplateSpecForDataPlane(dataplane, certSecretName, configMapName)
+	if err != nil {
+		return nil, err
 	}
 
-	deployment := &appsv1.Deployment{
+	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    dataplane.Namespace,
 			GenerateName: fmt.Sprintf("%s-%s-", consts.DataPlanePrefix, dataplane.Name),
@@ -38,146 +46,558 @@ func generateNewDeploymentForDataPlane(dataplane *apisixoperatorv1alpha1.DataPla
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
+			Replicas: generateReplicasForDataPlane(dataplane),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"app": dataplane.Name,
 				},
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app": dataplane.Name,
-					},
+			Template: *template,
+		},
+	}, nil
+}
+
+// generateNewDaemonSetForDataPlane returns the DaemonSet running a pod of the
+// provided DataPlane on every node, from the same pod template as its
+// Deployment in Deployment mode.
+func generateNewDaemonSetForDataPlane(
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	certSecretName string,
+	configMapName string,
+) (*appsv1.DaemonSet, error) {
+	template, err := generatePodTemplateSpecForDataPlane(dataplane, certSecretName, configMapName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    dataplane.Namespace,
+			GenerateName: fmt.Sprintf("%s-%s-", consts.DataPlanePrefix, dataplane.Name),
+			Labels: map[string]string{
+				"app": dataplane.Name,
+			},
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": dataplane.Name,
 				},
-				Spec: corev1.PodSpec{
-					Volumes: []corev1.Volume{
-						{
-							Name: "cluster-certificate",
-							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName: certSecretName,
-									Items: []corev1.KeyToPath{
-										{
-											Key:  "tls.crt",
-											Path: "tls.crt",
-										},
-										{
-											Key:  "tls.key",
-											Path: "tls.key",
-										},
-										{
-											Key:  "ca.crt",
-											Path: "ca.crt",
-										},
-									},
+			},
+			Template: *template,
+		},
+	}, nil
+}
+
+// generatePodTemplateSpecForDataPlane returns the template of the pods of the
+// provided DataPlane, with its pod template overrides merged in.
+func generatePodTemplateSpecForDataPlane(
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	certSecretName string,
+	configMapName string,
+) (*corev1.PodTemplateSpec, error) {
+	var dataplaneImage string
+	if dataplane.Spec.ContainerImage != nil {
+		dataplaneImage = *dataplane.Spec.ContainerImage
+		if dataplane.Spec.Version != nil {
+			dataplaneImage = fmt.Sprintf("%s:%s", dataplaneImage, *dataplane.Spec.Version)
+		}
+	} else {
+		dataplaneImage = consts.DefaultDataPlaneImage
+	}
+
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"app": dataplane.Name,
+			},
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{
+					Name: "cluster-certificate",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: certSecretName,
+							Items: []corev1.KeyToPath{
+								{
+									Key:  "tls.crt",
+									Path: "tls.crt",
+								},
+								{
+									Key:  "tls.key",
+									Path: "tls.key",
+								},
+								{
+									Key:  "ca.crt",
+									Path: "ca.crt",
 								},
 							},
 						},
 					},
-					Containers: []corev1.Container{{
-						Name: consts.DataPlaneProxyContainerName,
-						VolumeMounts: []corev1.VolumeMount{
-							{
-								Name:      "cluster-certificate",
-								ReadOnly:  true,
-								MountPath: "/var/cluster-certificate",
-							},
-						},
-						Env:             dataplane.Spec.Env,
-						EnvFrom:         dataplane.Spec.EnvFrom,
-						Image:           dataplaneImage,
-						ImagePullPolicy: corev1.PullIfNotPresent,
-						Lifecycle: &corev1.Lifecycle{
-							PreStop: &corev1.LifecycleHandler{
-								Exec: &corev1.ExecAction{
-									Command: []string{
-										"/bin/sh",
-										"-c",
-										" quit",
-									},
-								},
+				},
+				{
+					Name: "apisix-config",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: configMapName,
 							},
 						},
-						Ports: []corev1.ContainerPort{
-							{
-								Name:          "proxy",
-								ContainerPort: consts.DataPlaneProxyPort,
-								Protocol:      corev1.ProtocolTCP,
-							},
-							{
-								Name:          "proxy-ssl",
-								ContainerPort: consts.DataPlaneProxySSLPort,
-								Protocol:      corev1.ProtocolTCP,
-							},
-							{
-								Name:          "metrics",
-								ContainerPort: consts.DataPlaneMetricsPort,
-								Protocol:      corev1.ProtocolTCP,
-							},
-							{
-								Name:          "admin-ssl",
-								ContainerPort: consts.DataPlaneAdminAPIPort,
-								Protocol:      corev1.ProtocolTCP,
+					},
+				},
+			},
+			Containers: []corev1.Container{{
+				Name: consts.DataPlaneProxyContainerName,
+				VolumeMounts: []corev1.VolumeMount{
+					{
+						Name:      "cluster-certificate",
+						ReadOnly:  true,
+						MountPath: dataplaneutils.ClusterCertificateMountPath,
+					},
+					{
+						Name:      "apisix-config",
+						ReadOnly:  true,
+						MountPath: dataplaneutils.ConfigMountPath,
+						SubPath:   dataplaneutils.ConfigFileName,
+					},
+				},
+				Env:             generateEnvForDataPlane(dataplane),
+				EnvFrom:         dataplane.Spec.EnvFrom,
+				Image:           dataplaneImage,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Lifecycle: &corev1.Lifecycle{
+					PreStop: &corev1.LifecycleHandler{
+						Exec: &corev1.ExecAction{
+							Command: []string{
+								"/bin/sh",
+								"-c",
+								" quit",
 							},
 						},
-						ReadinessProbe: &corev1.Probe{
-							FailureThreshold:    3,
-							InitialDelaySeconds: 5,
-							PeriodSeconds:       10,
-							SuccessThreshold:    1,
-							TimeoutSeconds:      1,
-							ProbeHandler: corev1.ProbeHandler{
-								HTTPGet: &corev1.HTTPGetAction{
-									Path:   "/status",
-									Port:   intstr.FromInt(8100),
-									Scheme: corev1.URISchemeHTTP,
-								},
-							},
+					},
+				},
+				Ports: generateContainerPortsForDataPlane(dataplane),
+				ReadinessProbe: &corev1.Probe{
+					FailureThreshold:    3,
+					InitialDelaySeconds: 5,
+					PeriodSeconds:       10,
+					SuccessThreshold:    1,
+					TimeoutSeconds:      1,
+					ProbeHandler: corev1.ProbeHandler{
+						HTTPGet: &corev1.HTTPGetAction{
+							Path:   dataplaneutils.StatusPath,
+							Port:   intstr.FromInt(dataplaneutils.StatusPort),
+							Scheme: corev1.URISchemeHTTP,
 						},
-					}},
+					},
+				},
+			}},
+		},
+	}
+
+	// the certificates of the config center are mounted next to the cluster certificate.
+	volumes, volumeMounts := dataplaneutils.GenerateConfigCenterVolumes(dataplane)
+	podSpec := &template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, volumes...)
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, volumeMounts...)
+
+	// the pod template overrides of the DataPlane are merged last.
+	template, err := k8sutils.StrategicMergePatchPodTemplateSpec(template, dataplane.Spec.PodTemplateSpec)
+	if err != nil {
+		return nil, err
+	}
+	setLastAppliedAnnotations(template, dataplane.Spec.PodTemplateSpec)
+
+	// the pods running in the network namespace of the nodes would otherwise
+	// resolve names with the DNS of the nodes, which doesn't know the Services
+	// of the cluster, e.g. the ones of the etcd cluster.
+	if template.Spec.HostNetwork && template.Spec.DNSPolicy == "" {
+		template.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+
+	return template, nil
+}
+
+// generateReplicasForDataPlane returns the number of pods of the provided
+// DataPlane, defaulting to 1 as the API server would.
+func generateReplicasForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) *int32 {
+	if dataplane.Spec.Replicas != nil {
+		return pointer.Int32(*dataplane.Spec.Replicas)
+	}
+	return pointer.Int32(1)
+}
+
+// dataplaneIsScaledHorizontally returns whether the pods of the provided
+// DataPlane are scaled by a HorizontalPodAutoscaler, which only applies to
+// the DataPlanes deployed by a Deployment.
+func dataplaneIsScaledHorizontally(dataplane *apisixoperatorv1alpha1.DataPlane) bool {
+	return dataplaneutils.GetDeploymentMode(dataplane) == apisixoperatorv1alpha1.DataPlaneDeploymentModeDeployment &&
+		dataplane.Spec.Scaling != nil && dataplane.Spec.Scaling.Horizontal != nil
+}
+
+// defaultHorizontalScalingCPUUtilization is the target average CPU utilization
+// of the DataPlanes scaled horizontally without any metric target, set
+// explicitly since the API server defaults to it.
+const defaultHorizontalScalingCPUUtilization = 80
+
+// generateNewHorizontalPodAutoscalerForDataPlane returns the
+// HorizontalPodAutoscaler scaling the provided Deployment of the DataPlane
+// scaled horizontally.
+func generateNewHorizontalPodAutoscalerForDataPlane(
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	deploymentName string,
+) *autoscalingv2.HorizontalPodAutoscaler {
+	horizontal := dataplane.Spec.Scaling.Horizontal
+
+	minReplicas := pointer.Int32(1)
+	if horizontal.MinReplicas != nil {
+		minReplicas = pointer.Int32(*horizontal.MinReplicas)
+	}
+
+	var metrics []autoscalingv2.MetricSpec
+	resourceMetric := func(name corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: name,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: pointer.Int32(utilization),
+				},
+			},
+		}
+	}
+	if horizontal.CPUUtilization != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, *horizontal.CPUUtilization))
+	}
+	if horizontal.MemoryUtilization != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceMemory, *horizontal.MemoryUtilization))
+	}
+	for _, customMetric := range horizontal.CustomMetrics {
+		averageValue := customMetric.AverageValue.DeepCopy()
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{Name: customMetric.Name},
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: &averageValue,
 				},
 			},
+		})
+	}
+	if len(metrics) == 0 {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, defaultHorizontalScalingCPUUtilization))
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    dataplane.Namespace,
+			GenerateName: fmt.Sprintf("%s-%s-", consts.DataPlanePrefix, dataplane.Name),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+				Name:       deploymentName,
+			},
+			MinReplicas: minReplicas,
+			MaxReplicas: horizontal.MaxReplicas,
+			Metrics:     metrics,
 		},
 	}
-	return deployment
 }
 
+// generateNewConfigMapForDataPlane returns the ConfigMap holding the
+// configuration file of the APISIX proxy of the provided DataPlane.
+func generateNewConfigMapForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) (*corev1.ConfigMap, error) {
+	config, err := dataplaneutils.GenerateAPISIXConfig(dataplane).Render()
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    dataplane.Namespace,
+			GenerateName: fmt.Sprintf("%s-%s-", consts.DataPlanePrefix, dataplane.Name),
+		},
+		Data: map[string]string{
+			dataplaneutils.ConfigFileName: config,
+		},
+	}, nil
+}
+
+// generateNewAdminKeySecretForDataPlane returns a Secret holding a new random
+// key for the Admin API of the provided DataPlane, recording the rotation
+// requested through its annotation.
+func generateNewAdminKeySecretForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) (*corev1.Secret, error) {
+	key, err := dataplaneutils.GenerateAdminKey()
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: dataplane.Namespace,
+			Name:      dataplaneutils.AdminKeySecretName(dataplane.Name),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			dataplaneutils.AdminKeySecretKey: []byte(key),
+		},
+	}
+	if rotation, ok := dataplane.Annotations[consts.RotateAdminKeyAnnotation]; ok {
+		secret.Annotations = map[string]string{consts.RotateAdminKeyAnnotation: rotation}
+	}
+	return secret, nil
+}
+
+// generateNewServiceForDataplane returns the Service exposing the proxy of the
+// provided DataPlane to ingress traffic, as configured by its ingress Service
+// options. Its Admin API is exposed by a separate Service, see
+// generateNewAdminServiceForDataPlane.
 func generateNewServiceForDataplane(dataplane *apisixoperatorv1alpha1.DataPlane) *corev1.Service {
-	return &corev1.Service{
+	opts := dataplaneutils.GetIngressServiceOptions(dataplane)
+	serviceType := dataplaneutils.GetIngressServiceType(dataplane)
+
+	servicePorts := dataplaneutils.GetServicePorts(dataplane)
+	ports := make([]corev1.ServicePort, 0, len(servicePorts))
+	for _, port := range servicePorts {
+		servicePort := corev1.ServicePort{
+			Name:       dataplaneutils.ServicePortName(port),
+			Protocol:   dataplaneutils.TransportProtocolFor(port.Protocol),
+			Port:       port.Port,
+			TargetPort: intstr.FromInt(int(dataplaneutils.ProxyPortFor(port))),
+		}
+		if port.NodePort != nil && serviceType != corev1.ServiceTypeClusterIP {
+			servicePort.NodePort = *port.NodePort
+		}
+		ports = append(ports, servicePort)
+	}
+
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    dataplane.Namespace,
 			GenerateName: fmt.Sprintf("%s-%s-", consts.DataPlanePrefix, dataplane.Name),
 		},
 		Spec: corev1.ServiceSpec{
-			Type:     corev1.ServiceTypeLoadBalancer,
+			Type:     serviceType,
 			Selector: map[string]string{"app": dataplane.Name},
-			Ports: []corev1.ServicePort{
-				{
-					Name:       "http",
-					Protocol:   corev1.ProtocolTCP,
-					Port:       dataplaneutils.DefaultHTTPPort,
-					TargetPort: intstr.FromInt(dataplaneutils.DefaultAPISIXHTTPPort),
-				},
-				{
-					Name:       "https",
-					Protocol:   corev1.ProtocolTCP,
-					Port:       dataplaneutils.DefaultHTTPSPort,
-					TargetPort: intstr.FromInt(dataplaneutils.DefaultAPISIXHTTPSPort),
-				},
-				{
-					Name:     "admin",
-					Protocol: corev1.ProtocolTCP,
-					Port:     dataplaneutils.DefaultAPISIXAdminPort,
-				},
-			},
+			Ports:    ports,
 		},
 	}
+
+	if len(opts.Annotations) > 0 {
+		keys := make([]string, 0, len(opts.Annotations))
+		service.Annotations = make(map[string]string, len(opts.Annotations)+1)
+		for key, value := range opts.Annotations {
+			service.Annotations[key] = value
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		service.Annotations[consts.LastAppliedAnnotationsAnnotation] = strings.Join(keys, ",")
+	}
+
+	// the external traffic policy of the Services exposed outside of the cluster
+	// is defaulted by the API server, it's set explicitly so that it can be reverted.
+	if serviceType != corev1.ServiceTypeClusterIP {
+		service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+		if opts.ExternalTrafficPolicy != "" {
+			service.Spec.ExternalTrafficPolicy = opts.ExternalTrafficPolicy
+		}
+	}
+	if serviceType == corev1.ServiceTypeLoadBalancer {
+		service.Spec.LoadBalancerSourceRanges = opts.LoadBalancerSourceRanges
+	}
+
+	return service
+}
+
+// generateNewAdminServiceForDataPlane returns the ClusterIP Service exposing
+// the Admin API of the provided DataPlane to its ControlPlane only.
+func generateNewAdminServiceForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: dataplane.Namespace,
+			Name:      dataplaneutils.AdminServiceName(dataplane.Name),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": dataplane.Name},
+			Ports: []corev1.ServicePort{{
+				Name:       "admin",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       dataplaneutils.DefaultAPISIXAdminPort,
+				TargetPort: intstr.FromInt(int(dataplaneutils.GetAdminPort(dataplane))),
+			}},
+		},
+	}
+}
+
+// dataplaneCertificateSubject returns the subject of the mTLS certificate of a
+// DataPlane, which is served by its Admin API exposed by the provided Service.
+func dataplaneCertificateSubject(namespace, serviceName string) string {
+	return fmt.Sprintf("%s.%s.svc", serviceName, namespace)
+}
+
+// generateContainerPortsForDataPlane returns the ports of the proxy container
+// of the provided DataPlane: one per port exposed by its Service, plus the
+// metrics and Admin API ports. In DaemonSet deployment mode the proxy ports
+// are also bound on the nodes.
+func generateContainerPortsForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) []corev1.ContainerPort {
+	servicePorts := dataplaneutils.GetServicePorts(dataplane)
+	ports := make([]corev1.ContainerPort, 0, len(servicePorts)+2)
+	for _, port := range servicePorts {
+		containerPort := corev1.ContainerPort{
+			Name:          dataplaneutils.ServicePortName(port),
+			ContainerPort: dataplaneutils.ProxyPortFor(port),
+			Protocol:      dataplaneutils.TransportProtocolFor(port.Protocol),
+		}
+		if dataplaneutils.GetDeploymentMode(dataplane) == apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet {
+			containerPort.HostPort = containerPort.ContainerPort
+		}
+		ports = append(ports, containerPort)
+	}
+	return append(ports,
+		corev1.ContainerPort{
+			Name:          "metrics",
+			ContainerPort: consts.DataPlaneMetricsPort,
+			Protocol:      corev1.ProtocolTCP,
+		},
+		corev1.ContainerPort{
+			Name:          "admin-ssl",
+			ContainerPort: dataplaneutils.GetAdminPort(dataplane),
+			Protocol:      corev1.ProtocolTCP,
+		},
+	)
+}
+
+// generateEnvForDataPlane returns the environment of the proxy container of
+// the provided DataPlane: the user supplied environment, completed with the
+// credentials of its config center and with its Admin API key, which are
+// referenced by its configuration file. The generated variables always win.
+func generateEnvForDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) []corev1.EnvVar {
+	generatedEnv := append(dataplaneutils.GenerateConfigCenterEnvVars(dataplane),
+		dataplaneutils.GenerateAdminKeyEnvVar(dataplane.Name))
+	env := make([]corev1.EnvVar, 0, len(dataplane.Spec.Env)+len(generatedEnv))
+	for _, envVar := range dataplane.Spec.Env {
+		if !k8sutils.IsEnvVarPresent(envVar, generatedEnv) {
+			env = append(env, envVar)
+		}
+	}
+	return append(env, generatedEnv...)
+}
+
+// configHash returns the hash of the APISIX configuration file held by the
+// provided ConfigMap.
+func configHash(configMap *corev1.ConfigMap) string {
+	sum := sha256.Sum256([]byte(configMap.Data[dataplaneutils.ConfigFileName]))
+	return hex.EncodeToString(sum[:])
+}
+
+// setConfigHashAnnotation annotates the provided pod template with the hash
+// of the APISIX configuration file held by the provided ConfigMap, so that
+// changing the configuration triggers a rollout of the proxies: files
+// mounted with a subPath are not updated in running pods.
+func setConfigHashAnnotation(template *corev1.PodTemplateSpec, configMap *corev1.ConfigMap) {
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[consts.APISIXConfigHashAnnotation] = configHash(configMap)
+}
+
+// -----------------------------------------------------------------------------
+// DataPlane - Private Functions - Status
+// -----------------------------------------------------------------------------
+
+// generateAddressesForDataPlane returns the addresses that the provided
+// Service of a DataPlane is reachable at: the ingress points of its load
+// balancer, the addresses of the provided nodes if it's a NodePort Service,
+// or its cluster IP otherwise. The external IP of a node is preferred over
+// its internal IP.
+func generateAddressesForDataPlane(svc *corev1.Service, nodes []corev1.Node) []apisixoperatorv1alpha1.DataPlaneAddress {
+	addresses := make([]apisixoperatorv1alpha1.DataPlaneAddress, 0)
+
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				addresses = append(addresses, newDataPlaneAddress(apisixoperatorv1alpha1.DataPlaneAddressTypeIPAddress, ingress.IP))
+			}
+			if ingress.Hostname != "" {
+				addresses = append(addresses, newDataPlaneAddress(apisixoperatorv1alpha1.DataPlaneAddressTypeHostname, ingress.Hostname))
+			}
+		}
+	case corev1.ServiceTypeNodePort:
+		for _, node := range nodes {
+			if ip := nodeAddress(node); ip != "" {
+				addresses = append(addresses, newDataPlaneAddress(apisixoperatorv1alpha1.DataPlaneAddressTypeIPAddress, ip))
+			}
+		}
+	default:
+		if svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != corev1.ClusterIPNone {
+			addresses = append(addresses, newDataPlaneAddress(apisixoperatorv1alpha1.DataPlaneAddressTypeIPAddress, svc.Spec.ClusterIP))
+		}
+	}
+	return addresses
+}
+
+func newDataPlaneAddress(addressType apisixoperatorv1alpha1.DataPlaneAddressType, value string) apisixoperatorv1alpha1.DataPlaneAddress {
+	return apisixoperatorv1alpha1.DataPlaneAddress{
+		Type:  addressType,
+		Value: value,
+	}
+}
+
+// nodeAddress returns the external IP of the provided node, or its internal
+// IP if it has none.
+func nodeAddress(node corev1.Node) string {
+	var internalIP string
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case corev1.NodeExternalIP:
+			return address.Address
+		case corev1.NodeInternalIP:
+			if internalIP == "" {
+				internalIP = address.Address
+			}
+		}
+	}
+	return internalIP
+}
+
+// proxyImageForDataPlane returns the image of the proxy container of the
+// provided pod template of a DataPlane.
+func proxyImageForDataPlane(template *corev1.PodTemplateSpec) string {
+	if container := k8sresources.GetPodContainerByName(&template.Spec, consts.DataPlaneProxyContainerName); container != nil {
+		return container.Image
+	}
+	return ""
 }
 
 // -----------------------------------------------------------------------------
 // DataPlane - Private Functions - Kubernetes Object Labels
 // -----------------------------------------------------------------------------
 
+func addLabelForAdminKey(obj client.Object) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[consts.GatewayOperatorControlledLabel] = consts.AdminKeyManagedLabelValue
+	obj.SetLabels(labels)
+}
+
+func addLabelForAdminService(obj client.Object) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[consts.GatewayOperatorControlledLabel] = consts.AdminServiceManagedLabelValue
+	obj.SetLabels(labels)
+}
+
 func addLabelForDataplane(obj client.Object) {
 	labels := obj.GetLabels()
 	if labels == nil {
@@ -192,5 +612,65 @@ func addLabelForDataplane(obj client.Object) {
 // -----------------------------------------------------------------------------
 
 func dataplaneSpecDeepEqual(spec1, spec2 *apisixoperatorv1alpha1.DataPlaneDeploymentOptions) bool {
-	return deploymentOptionsDeepEqual(&spec1.DeploymentOptions, &spec2.DeploymentOptions)
+	if !deploymentOptionsDeepEqual(&spec1.DeploymentOptions, &spec2.DeploymentOptions) {
+		return false
+	}
+
+	if spec1.DeploymentMode != spec2.DeploymentMode {
+		return false
+	}
+
+	if !reflect.DeepEqual(spec1.Replicas, spec2.Replicas) {
+		return false
+	}
+
+	return reflect.DeepEqual(spec1.Scaling, spec2.Scaling)
+}
+
+// servicePortsEqual compares the ports of a Service with the generated ones,
+// ignoring the node ports allocated by the API server.
+func servicePortsEqual(existing, generated []corev1.ServicePort) bool {
+	if len(existing) != len(generated) {
+		return false
+	}
+	for i := range existing {
+		if existing[i].Name != generated[i].Name ||
+			existing[i].Protocol != generated[i].Protocol ||
+			existing[i].Port != generated[i].Port ||
+			existing[i].TargetPort != generated[i].TargetPort ||
+			generated[i].NodePort != 0 && existing[i].NodePort != generated[i].NodePort {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureServiceAnnotations sets the annotations of the generated Service on
+// the existing one, and removes the ones last applied from the DataPlane
+// which are no longer generated. The annotations set by other controllers,
+// e.g. cloud load balancer controllers, are left untouched. It returns true
+// if the annotations were updated.
+func ensureServiceAnnotations(existing, generated *corev1.Service) bool {
+	annotations := make(map[string]string, len(existing.Annotations))
+	for key, value := range existing.Annotations {
+		annotations[key] = value
+	}
+	for _, key := range strings.Split(existing.Annotations[consts.LastAppliedAnnotationsAnnotation], ",") {
+		if _, ok := generated.Annotations[key]; !ok {
+			delete(annotations, key)
+		}
+	}
+	if _, ok := generated.Annotations[consts.LastAppliedAnnotationsAnnotation]; !ok {
+		delete(annotations, consts.LastAppliedAnnotationsAnnotation)
+	}
+	for key, value := range generated.Annotations {
+		annotations[key] = value
+	}
+
+	if reflect.DeepEqual(annotations, existing.Annotations) ||
+		len(annotations) == 0 && len(existing.Annotations) == 0 {
+		return false
+	}
+	existing.Annotations = annotations
+	return true
 }
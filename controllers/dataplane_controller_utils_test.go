@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
+)
+
+func TestGenerateAddressesForDataPlane(t *testing.T) {
+	ip := func(value string) apisixoperatorv1alpha1.DataPlaneAddress {
+		return newDataPlaneAddress(apisixoperatorv1alpha1.DataPlaneAddressTypeIPAddress, value)
+	}
+	hostname := func(value string) apisixoperatorv1alpha1.DataPlaneAddress {
+		return newDataPlaneAddress(apisixoperatorv1alpha1.DataPlaneAddressTypeHostname, value)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		svc      *corev1.Service
+		nodes    []corev1.Node
+		expected []apisixoperatorv1alpha1.DataPlaneAddress
+	}{
+		{
+			name: "load balancer without ingress points has no addresses",
+			svc: &corev1.Service{
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, ClusterIP: "10.0.0.1"},
+			},
+			expected: []apisixoperatorv1alpha1.DataPlaneAddress{},
+		},
+		{
+			name: "load balancer with ip and hostname ingress points",
+			svc: &corev1.Service{
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, ClusterIP: "10.0.0.1"},
+				Status: corev1.ServiceStatus{
+					LoadBalancer: corev1.LoadBalancerStatus{
+						Ingress: []corev1.LoadBalancerIngress{
+							{IP: "1.2.3.4"},
+							{Hostname: "lb.example.com"},
+						},
+					},
+				},
+			},
+			expected: []apisixoperatorv1alpha1.DataPlaneAddress{ip("1.2.3.4"), hostname("lb.example.com")},
+		},
+		{
+			name: "node port service falls back to the addresses of the nodes",
+			svc: &corev1.Service{
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort, ClusterIP: "10.0.0.1"},
+			},
+			nodes: []corev1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "internal"},
+					Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+						{Type: corev1.NodeHostName, Address: "internal"},
+						{Type: corev1.NodeInternalIP, Address: "192.168.0.1"},
+					}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "external"},
+					Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+						{Type: corev1.NodeInternalIP, Address: "192.168.0.2"},
+						{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+					}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "unaddressed"},
+				},
+			},
+			expected: []apisixoperatorv1alpha1.DataPlaneAddress{ip("192.168.0.1"), ip("1.2.3.4")},
+		},
+		{
+			name: "cluster ip service falls back to its cluster ip",
+			svc: &corev1.Service{
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: "10.0.0.1"},
+			},
+			expected: []apisixoperatorv1alpha1.DataPlaneAddress{ip("10.0.0.1")},
+		},
+		{
+			name: "headless service has no addresses",
+			svc: &corev1.Service{
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone},
+			},
+			expected: []apisixoperatorv1alpha1.DataPlaneAddress{},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, generateAddressesForDataPlane(tt.svc, tt.nodes))
+		})
+	}
+}
+
+func TestGeneratePodTemplateSpecForDataPlaneDNSPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		override *corev1.PodTemplateSpec
+		expected corev1.DNSPolicy
+	}{
+		{
+			name:     "pods in their own network namespace keep the default DNS policy",
+			expected: "",
+		},
+		{
+			name:     "pods in the network namespace of the nodes resolve the Services of the cluster",
+			override: &corev1.PodTemplateSpec{Spec: corev1.PodSpec{HostNetwork: true}},
+			expected: corev1.DNSClusterFirstWithHostNet,
+		},
+		{
+			name: "the DNS policy set explicitly is kept",
+			override: &corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				HostNetwork: true,
+				DNSPolicy:   corev1.DNSDefault,
+			}},
+			expected: corev1.DNSDefault,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dataplane := &apisixoperatorv1alpha1.DataPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+			}
+			dataplane.Spec.PodTemplateSpec = tt.override
+			template, err := generatePodTemplateSpecForDataPlane(dataplane, "cert", "config")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, template.Spec.DNSPolicy)
+		})
+	}
+}
+
+func TestGenerateServicesForDataPlaneAdminPort(t *testing.T) {
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	dataplane.Spec.Network.Services = &apisixoperatorv1alpha1.DataPlaneServices{
+		Ingress: &apisixoperatorv1alpha1.DataPlaneServiceOptions{
+			Ports: []apisixoperatorv1alpha1.DataPlaneServicePort{
+				{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+				{Port: 443, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTPS},
+			},
+		},
+	}
+
+	t.Log("the proxy Service only exposes the ports of the listeners")
+	service := generateNewServiceForDataplane(dataplane)
+	require.Len(t, service.Spec.Ports, 2)
+	for _, port := range service.Spec.Ports {
+		require.NotEqual(t, dataplaneutils.GetAdminPort(dataplane), port.TargetPort.IntVal)
+	}
+
+	t.Log("the Admin API is only exposed inside the cluster")
+	adminService := generateNewAdminServiceForDataPlane(dataplane)
+	require.Equal(t, corev1.ServiceTypeClusterIP, adminService.Spec.Type)
+	require.Len(t, adminService.Spec.Ports, 1)
+	require.Equal(t, dataplaneutils.GetAdminPort(dataplane), adminService.Spec.Ports[0].TargetPort.IntVal)
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlaneReconciler - Watch Predicates
+// -----------------------------------------------------------------------------
+
+func (r *DataPlaneReconciler) isClusterCASecret(obj client.Object) bool {
+	return obj.GetNamespace() == r.ClusterCASecretNamespace && obj.GetName() == r.ClusterCASecretName
+}
+
+// -----------------------------------------------------------------------------
+// DataPlaneReconciler - Watch Map Funcs
+// -----------------------------------------------------------------------------
+
+// getDataPlanesForClusterCASecret enqueues all the DataPlanes when the cluster
+// CA changes, so that their certificates get re-issued.
+func (r *DataPlaneReconciler) getDataPlanesForClusterCASecret(obj client.Object) (recs []reconcile.Request) {
+	ctx := context.Background()
+
+	if _, ok := obj.(*corev1.Secret); !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "Secret", "found", reflect.TypeOf(obj),
+		)
+		return
+	}
+
+	dataplanes := &apisixoperatorv1alpha1.DataPlaneList{}
+	if err := r.Client.List(ctx, dataplanes); err != nil {
+		log.FromContext(ctx).Error(err, "could not list dataplanes in map func")
+		return
+	}
+
+	for _, dataplane := range dataplanes.Items {
+		recs = append(recs, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: dataplane.Namespace,
+				Name:      dataplane.Name,
+			},
+		})
+	}
+
+	return
+}
+
+// getDataPlaneForCertificateSigningRequest enqueues the DataPlane a
+// CertificateSigningRequest was submitted for, either for its mTLS certificate
+// or for the server or client certificate of its etcd cluster, so that the
+// certificate is stored once issued.
+func (r *DataPlaneReconciler) getDataPlaneForCertificateSigningRequest(obj client.Object) []reconcile.Request {
+	ctx := context.Background()
+	recs := certificateSigningRequestOwnerRequests(ctx, obj, consts.DataPlaneManagedLabelValue)
+	recs = append(recs, certificateSigningRequestOwnerRequests(ctx, obj, consts.EtcdManagedLabelValue)...)
+	return append(recs, certificateSigningRequestOwnerRequests(ctx, obj, consts.EtcdClientManagedLabelValue)...)
+}
+
+// getDataPlaneForCertManagerSecret enqueues the DataPlane owning the cert-manager
+// Certificate stored in the provided Secret, either its mTLS certificate or the
+// server or client certificate of its etcd cluster, so that the certificate is
+// rolled out once issued or renewed.
+func (r *DataPlaneReconciler) getDataPlaneForCertManagerSecret(obj client.Object) []reconcile.Request {
+	return certManagerSecretOwnerRequests(context.Background(), r.Client, obj, "DataPlane")
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+)
+
+// -----------------------------------------------------------------------------
+// GatewayReconciler
+// -----------------------------------------------------------------------------
+
+// GatewayReconciler reconciles a Gateway object
+type GatewayReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// ControllerName is the name this operator uses to claim GatewayClasses
+	// through their .spec.controllerName field. Only Gateways of those
+	// GatewayClasses are reconciled.
+	ControllerName string
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// watch Gateway objects, filtering out any Gateways which are not
+		// configured with a supported GatewayClass controller name.
+		For(&gatewayv1alpha2.Gateway{},
+			builder.WithPredicates(predicate.NewPredicateFuncs(r.gatewayHasMatchingGatewayClass))).
+		// watch for changes in DataPlanes created by the gateway controller
+		Owns(&apisixoperatorv1alpha1.DataPlane{}).
+		// watch for changes in ControlPlanes created by the gateway controller
+		Owns(&apisixoperatorv1alpha1.ControlPlane{}).
+		// watch for changes in GatewayClasses so that Gateways waiting for
+		// their GatewayClass to be accepted are reconciled.
+		Watches(
+			&source.Kind{Type: &gatewayv1alpha2.GatewayClass{}},
+			handler.EnqueueRequestsFromMapFunc(r.listGatewaysForGatewayClass),
+			builder.WithPredicates(predicate.NewPredicateFuncs(r.gatewayClassMatchesController))).
+		// watch for changes in APISIXConfigurations so that their options are
+		// propagated to the DataPlanes and ControlPlanes of the Gateways.
+		Watches(
+			&source.Kind{Type: &apisixoperatorv1alpha1.APISIXConfiguration{}},
+			handler.EnqueueRequestsFromMapFunc(r.listGatewaysForAPISIXConfiguration)).
+		Complete(r)
+}
+
+// Reconcile moves the current state of an object to the intended state.
+func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithName("Gateway")
+
+	debug(log, "reconciling Gateway resource", req)
+	gateway := new(gatewayv1alpha2.Gateway)
+	if err := r.Client.Get(ctx, req.NamespacedName, gateway); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	debug(log, "checking GatewayClass of Gateway resource", gateway)
+	gatewayClass, err := r.verifyGatewayClassSupport(ctx, gateway)
+	if err != nil {
+		if errors.Is(err, operatorerrors.ErrUnsupportedGateway) {
+			debug(log, "Gateway is not supported by this operator, ignoring", gateway, "error", err)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if !gatewayutils.IsAccepted(gatewayClass) {
+		debug(log, "GatewayClass of Gateway resource is not accepted yet, waiting", gateway)
+		return ctrl.Result{}, nil // requeue will be triggered by the GatewayClass status update
+	}
+
+	debug(log, "retrieving APISIXConfiguration of Gateway resource", gateway)
+	apisixConfiguration, err := r.getAPISIXConfigurationForGatewayClass(ctx, gatewayClass)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	oldGateway := gateway.DeepCopy()
+
+	if !gatewayutils.IsScheduled(gateway) {
+		debug(log, "marking Gateway resource as scheduled", gateway)
+		ensureGatewayIsMarkedScheduled(gateway)
+		return ctrl.Result{}, r.Client.Status().Update(ctx, gateway) // status update will requeue
+	}
+
+	debug(log, "ensuring DataPlane for Gateway resource", gateway)
+	createdOrUpdated, dataplane, err := r.ensureDataPlaneForGateway(ctx, gateway, apisixConfiguration)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if createdOrUpdated {
+		debug(log, "DataPlane for Gateway created/updated", gateway)
+		return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
+	}
+
+	debug(log, "ensuring ControlPlane for Gateway resource", gateway)
+	createdOrUpdated, controlplane, err := r.ensureControlPlaneForGateway(ctx, gateway, apisixConfiguration, dataplane)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if createdOrUpdated {
+		debug(log, "ControlPlane for Gateway created/updated", gateway)
+		return ctrl.Result{}, nil // requeue will be triggered by the creation or update of the owned object
+	}
+
+	debug(log, "determining Gateway addresses from the DataPlane status", gateway)
+	addresses := gatewayAddressesFromDataPlane(dataplane)
+
+	ensureGatewayStatus(gateway, dataplane, controlplane, addresses)
+	if equality.Semantic.DeepEqual(oldGateway.Status, gateway.Status) {
+		debug(log, "reconciliation complete for Gateway resource, status is up to date", gateway)
+		return ctrl.Result{}, nil
+	}
+
+	debug(log, "updating Gateway resource status", gateway, "ready", gatewayutils.IsReady(gateway))
+	if err := r.Client.Status().Update(ctx, gateway); err != nil {
+		if k8serrors.IsConflict(err) {
+			debug(log, "conflict found when updating Gateway status, retrying", gateway)
+			return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	debug(log, "reconciliation complete for Gateway resource", gateway)
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,11 @@
+package controllers
+
+// -----------------------------------------------------------------------------
+// GatewayReconciler - RBAC
+// -----------------------------------------------------------------------------
+
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=dataplanes,verbs=create;get;list;watch;update;patch
+//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=controlplanes,verbs=create;get;list;watch;update;patch
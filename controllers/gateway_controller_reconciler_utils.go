@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+)
+
+// -----------------------------------------------------------------------------
+// GatewayReconciler - GatewayClass Support
+// -----------------------------------------------------------------------------
+
+// verifyGatewayClassSupport retrieves the GatewayClass of the provided Gateway
+// and returns an error wrapping ErrUnsupportedGateway if the GatewayClass is
+// missing or not controlled by this operator.
+func (r *GatewayReconciler) verifyGatewayClassSupport(
+	ctx context.Context,
+	gateway *gatewayv1alpha2.Gateway,
+) (*gatewayv1alpha2.GatewayClass, error) {
+	if gateway.Spec.GatewayClassName == "" {
+		return nil, fmt.Errorf("%w: gatewayClassName is not set", operatorerrors.ErrUnsupportedGateway)
+	}
+
+	gatewayClass := &gatewayv1alpha2.GatewayClass{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: string(gateway.Spec.GatewayClassName)}, gatewayClass); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: gatewayclass %s not found", operatorerrors.ErrUnsupportedGateway, gateway.Spec.GatewayClassName)
+		}
+		return nil, err
+	}
+
+	if !gatewayutils.IsGatewayClassControlled(gatewayClass, r.ControllerName) {
+		return nil, fmt.Errorf("%w: gatewayclass %s is controlled by %s",
+			operatorerrors.ErrUnsupportedGateway, gatewayClass.Name, gatewayClass.Spec.ControllerName)
+	}
+
+	return gatewayClass, nil
+}
+
+// -----------------------------------------------------------------------------
+// GatewayReconciler - Owned Resource Management
+// -----------------------------------------------------------------------------
+
+// getAPISIXConfigurationForGatewayClass returns the APISIXConfiguration
+// referenced by the provided GatewayClass, or nil if the GatewayClass doesn't
+// reference any.
+func (r *GatewayReconciler) getAPISIXConfigurationForGatewayClass(
+	ctx context.Context,
+	gatewayClass *gatewayv1alpha2.GatewayClass,
+) (*apisixoperatorv1alpha1.APISIXConfiguration, error) {
+	apisixConfiguration, err := gatewayutils.GetAPISIXConfigurationForGatewayClass(ctx, r.Client, gatewayClass)
+	if err != nil {
+		if errors.Is(err, operatorerrors.ErrObjectMissingParametersRef) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return apisixConfiguration, nil
+}
+
+func (r *GatewayReconciler) ensureDataPlaneForGateway(
+	ctx context.Context,
+	gateway *gatewayv1alpha2.Gateway,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+) (createdOrUpdated bool, dataplane *apisixoperatorv1alpha1.DataPlane, err error) {
+	dataplanes, err := gatewayutils.ListDataPlanesForGateway(ctx, r.Client, gateway)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(dataplanes)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d dataplanes for Gateway currently unsupported: expected 1 or less", count)
+	}
+
+	generatedDataPlane := generateDataPlaneForGateway(gateway, apisixConfiguration)
+	k8sutils.SetOwnerForObject(generatedDataPlane, gateway)
+	gatewayutils.LabelObjectAsGatewayManaged(generatedDataPlane)
+
+	if count == 1 {
+		var updated bool
+		existingDataPlane := &dataplanes[0]
+		updated, existingDataPlane.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingDataPlane.ObjectMeta, generatedDataPlane.ObjectMeta)
+
+		// the deployment options of the DataPlane follow the APISIXConfiguration
+		// of the GatewayClass.
+		if !dataplaneSpecDeepEqual(&existingDataPlane.Spec.DataPlaneDeploymentOptions, &generatedDataPlane.Spec.DataPlaneDeploymentOptions) {
+			existingDataPlane.Spec.DataPlaneDeploymentOptions = generatedDataPlane.Spec.DataPlaneDeploymentOptions
+			updated = true
+		}
+
+		// the ports of the DataPlane follow the listeners of the Gateway.
+		if !reflect.DeepEqual(getDataPlaneIngressPorts(existingDataPlane), getDataPlaneIngressPorts(generatedDataPlane)) {
+			setDataPlaneIngressPorts(existingDataPlane, getDataPlaneIngressPorts(generatedDataPlane))
+			updated = true
+		}
+
+		if updated {
+			return true, existingDataPlane, r.Client.Update(ctx, existingDataPlane)
+		}
+		return false, existingDataPlane, nil
+	}
+
+	return true, generatedDataPlane, r.Client.Create(ctx, generatedDataPlane)
+}
+
+func (r *GatewayReconciler) ensureControlPlaneForGateway(
+	ctx context.Context,
+	gateway *gatewayv1alpha2.Gateway,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) (createdOrUpdated bool, controlplane *apisixoperatorv1alpha1.ControlPlane, err error) {
+	controlplanes, err := gatewayutils.ListControlPlanesForGateway(ctx, r.Client, gateway)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(controlplanes)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d controlplanes for Gateway currently unsupported: expected 1 or less", count)
+	}
+
+	generatedControlPlane := generateControlPlaneForGateway(gateway, apisixConfiguration, dataplane.Name)
+	k8sutils.SetOwnerForObject(generatedControlPlane, gateway)
+	gatewayutils.LabelObjectAsGatewayManaged(generatedControlPlane)
+
+	if count == 1 {
+		var updated bool
+		existingControlPlane := &controlplanes[0]
+
+		// the ControlPlane controller adds its DataPlane to the owners of the
+		// ControlPlane: keep those owners, only the Gateway one is ours.
+		for _, ownerRef := range existingControlPlane.OwnerReferences {
+			if ownerRef.UID != gateway.UID {
+				generatedControlPlane.OwnerReferences = append(generatedControlPlane.OwnerReferences, ownerRef)
+			}
+		}
+		updated, existingControlPlane.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingControlPlane.ObjectMeta, generatedControlPlane.ObjectMeta)
+
+		// the deployment options of the ControlPlane follow the APISIXConfiguration
+		// of the GatewayClass, and the DataPlane of a Gateway may have been
+		// replaced, in which case the ControlPlane needs to be pointed at the
+		// new one.
+		if !controlplaneSpecDeepEqual(&existingControlPlane.Spec.ControlPlaneDeploymentOptions, &generatedControlPlane.Spec.ControlPlaneDeploymentOptions) {
+			existingControlPlane.Spec.ControlPlaneDeploymentOptions = generatedControlPlane.Spec.ControlPlaneDeploymentOptions
+			updated = true
+		}
+		if existingControlPlane.Spec.GatewayClass == nil || *existingControlPlane.Spec.GatewayClass != *generatedControlPlane.Spec.GatewayClass {
+			existingControlPlane.Spec.GatewayClass = generatedControlPlane.Spec.GatewayClass
+			updated = true
+		}
+
+		if updated {
+			return true, existingControlPlane, r.Client.Update(ctx, existingControlPlane)
+		}
+		return false, existingControlPlane, nil
+	}
+
+	return true, generatedControlPlane, r.Client.Create(ctx, generatedControlPlane)
+}
@@ -0,0 +1,381 @@
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	controlplaneutils "github.com/chever-john/apisix-operator/internal/utils/controlplane"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+)
+
+// -----------------------------------------------------------------------------
+// Gateway - Private Functions - Generators
+// -----------------------------------------------------------------------------
+
+// generateDataPlaneForGateway generates the DataPlane of the provided
+// Gateway, configured with the DataPlane options of its APISIXConfiguration
+// and exposing the ports of its listeners. The defaults set by the defaulting
+// webhook are set as well so that the result can be compared with the existing
+// DataPlane.
+func generateDataPlaneForGateway(
+	gateway *gatewayv1alpha2.Gateway,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+) *apisixoperatorv1alpha1.DataPlane {
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    gateway.Namespace,
+			GenerateName: fmt.Sprintf("%s-", gateway.Name),
+		},
+	}
+
+	if apisixConfiguration != nil && apisixConfiguration.Spec.DataPlaneDeploymentOptions != nil {
+		dataplane.Spec.DataPlaneDeploymentOptions = *apisixConfiguration.Spec.DataPlaneDeploymentOptions.DeepCopy()
+	}
+	dataplaneutils.SetDataPlaneDefaults(&dataplane.Spec.DataPlaneDeploymentOptions)
+
+	if ports, _ := dataPlanePortsForGateway(gateway, dataplane); len(ports) > 0 {
+		dataplane.Spec.Network.Services = &apisixoperatorv1alpha1.DataPlaneServices{
+			Ingress: &apisixoperatorv1alpha1.DataPlaneServiceOptions{
+				Ports: ports,
+			},
+		}
+	}
+
+	return dataplane
+}
+
+// generateControlPlaneForGateway generates the ControlPlane of the provided
+// Gateway, configured with the ControlPlane options of its APISIXConfiguration.
+// The defaults set by the defaulting webhook are set as well so that the
+// result can be compared with the existing ControlPlane.
+func generateControlPlaneForGateway(
+	gateway *gatewayv1alpha2.Gateway,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	dataplaneName string,
+) *apisixoperatorv1alpha1.ControlPlane {
+	gatewayClassName := gateway.Spec.GatewayClassName
+	controlplane := &apisixoperatorv1alpha1.ControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    gateway.Namespace,
+			GenerateName: fmt.Sprintf("%s-", gateway.Name),
+		},
+		Spec: apisixoperatorv1alpha1.ControlPlaneSpec{
+			GatewayClass: &gatewayClassName,
+		},
+	}
+
+	if apisixConfiguration != nil && apisixConfiguration.Spec.ControlPlaneDeploymentOptions != nil {
+		controlplane.Spec.ControlPlaneDeploymentOptions = *apisixConfiguration.Spec.ControlPlaneDeploymentOptions.DeepCopy()
+	}
+	// the ControlPlane of a Gateway always manages the DataPlane of the Gateway.
+	controlplane.Spec.DataPlane = &dataplaneName
+	controlplaneutils.SetControlPlaneDefaults(&controlplane.Spec.ControlPlaneDeploymentOptions)
+
+	return controlplane
+}
+
+// -----------------------------------------------------------------------------
+// Gateway - Private Functions - Listeners
+// -----------------------------------------------------------------------------
+
+// listenerIssue describes why the DataPlane of a Gateway can't serve one of
+// its listeners.
+type listenerIssue struct {
+	conditionType gatewayv1alpha2.ListenerConditionType
+	reason        gatewayv1alpha2.ListenerConditionReason
+	message       string
+}
+
+// dataPlanePortsForGateway maps the listeners of the provided Gateway to the
+// ports exposed by its DataPlane. Listeners sharing a port with the same
+// protocol are served by the same DataPlane port. Listeners which APISIX
+// can't serve are not mapped: the reason why is returned instead, indexed by
+// listener name. The provided DataPlane, configured for the Gateway, determines
+// the ports reserved by the proxy.
+func dataPlanePortsForGateway(
+	gateway *gatewayv1alpha2.Gateway,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+) ([]apisixoperatorv1alpha1.DataPlaneServicePort, map[gatewayv1alpha2.SectionName]listenerIssue) {
+	type portKey struct {
+		port      int32
+		transport corev1.Protocol
+	}
+
+	var ports []apisixoperatorv1alpha1.DataPlaneServicePort
+	issues := make(map[gatewayv1alpha2.SectionName]listenerIssue)
+	servicePorts := make(map[portKey]apisixoperatorv1alpha1.ProxyProtocol)
+	proxyPorts := make(map[portKey]int32)
+
+	for _, listener := range gateway.Spec.Listeners {
+		protocol, ok := proxyProtocolForListener(listener.Protocol)
+		if !ok {
+			issues[listener.Name] = listenerIssue{
+				conditionType: gatewayv1alpha2.ListenerConditionDetached,
+				reason:        gatewayv1alpha2.ListenerReasonUnsupportedProtocol,
+				message:       fmt.Sprintf("protocol %s is not supported by APISIX", listener.Protocol),
+			}
+			continue
+		}
+
+		port := int32(listener.Port)
+		proxyPort := dataplaneutils.ProxyPortForServicePort(port)
+		if dataplaneutils.IsReservedProxyPort(dataplane, proxyPort) {
+			issues[listener.Name] = listenerIssue{
+				conditionType: gatewayv1alpha2.ListenerConditionDetached,
+				reason:        gatewayv1alpha2.ListenerReasonPortUnavailable,
+				message:       fmt.Sprintf("port %d is served by APISIX on port %d, which is reserved", port, proxyPort),
+			}
+			continue
+		}
+
+		transport := dataplaneutils.TransportProtocolFor(protocol)
+		if existing, ok := servicePorts[portKey{port, transport}]; ok {
+			if existing != protocol {
+				issues[listener.Name] = listenerIssue{
+					conditionType: gatewayv1alpha2.ListenerConditionConflicted,
+					reason:        gatewayv1alpha2.ListenerReasonProtocolConflict,
+					message:       fmt.Sprintf("port %d is already used by a listener with protocol %s", port, existing),
+				}
+			}
+			continue
+		}
+		if other, ok := proxyPorts[portKey{proxyPort, transport}]; ok {
+			issues[listener.Name] = listenerIssue{
+				conditionType: gatewayv1alpha2.ListenerConditionDetached,
+				reason:        gatewayv1alpha2.ListenerReasonPortUnavailable,
+				message:       fmt.Sprintf("port %d is served by APISIX on port %d, which is already used by port %d", port, proxyPort, other),
+			}
+			continue
+		}
+
+		servicePorts[portKey{port, transport}] = protocol
+		proxyPorts[portKey{proxyPort, transport}] = port
+		ports = append(ports, apisixoperatorv1alpha1.DataPlaneServicePort{
+			Port:     port,
+			Protocol: protocol,
+		})
+	}
+
+	return ports, issues
+}
+
+// proxyProtocolForListener returns the protocol the APISIX proxy serves a
+// listener with, and false if APISIX can't serve the listener protocol.
+func proxyProtocolForListener(protocol gatewayv1alpha2.ProtocolType) (apisixoperatorv1alpha1.ProxyProtocol, bool) {
+	switch protocol {
+	case gatewayv1alpha2.HTTPProtocolType:
+		return apisixoperatorv1alpha1.ProxyProtocolHTTP, true
+	case gatewayv1alpha2.HTTPSProtocolType:
+		return apisixoperatorv1alpha1.ProxyProtocolHTTPS, true
+	case gatewayv1alpha2.TLSProtocolType:
+		return apisixoperatorv1alpha1.ProxyProtocolTLS, true
+	case gatewayv1alpha2.TCPProtocolType:
+		return apisixoperatorv1alpha1.ProxyProtocolTCP, true
+	case gatewayv1alpha2.UDPProtocolType:
+		return apisixoperatorv1alpha1.ProxyProtocolUDP, true
+	}
+	return "", false
+}
+
+// -----------------------------------------------------------------------------
+// Gateway - Private Functions - Status Management
+// -----------------------------------------------------------------------------
+
+// ensureGatewayIsMarkedScheduled sets the Scheduled condition of the
+// provided Gateway to true.
+func ensureGatewayIsMarkedScheduled(gateway *gatewayv1alpha2.Gateway) {
+	meta.SetStatusCondition(&gateway.Status.Conditions, metav1.Condition{
+		Type:               string(gatewayv1alpha2.GatewayConditionScheduled),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1alpha2.GatewayReasonScheduled),
+		Message:            "Gateway is scheduled for provisioning by the APISIX operator",
+		ObservedGeneration: gateway.Generation,
+	})
+}
+
+// ensureGatewayStatus computes the Ready condition, the listener statuses and
+// the addresses of the provided Gateway from the state of its DataPlane and
+// ControlPlane. Conditions whose status did not change keep their original
+// LastTransitionTime, so that the resulting status can be compared with the
+// persisted one to decide whether an update is needed.
+func ensureGatewayStatus(
+	gateway *gatewayv1alpha2.Gateway,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	controlplane *apisixoperatorv1alpha1.ControlPlane,
+	addresses []gatewayv1alpha2.GatewayAddress,
+) {
+	readyCondition := metav1.Condition{
+		Type:               string(gatewayv1alpha2.GatewayConditionReady),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(k8sutils.ResourceReadyReason),
+		ObservedGeneration: gateway.Generation,
+	}
+	_, issues := dataPlanePortsForGateway(gateway, dataplane)
+	switch {
+	case len(issues) > 0:
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = string(gatewayv1alpha2.GatewayReasonListenersNotValid)
+		readyCondition.Message = fmt.Sprintf("%d listener(s) can't be served by APISIX", len(issues))
+	case !k8sutils.IsReady(dataplane):
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = string(gatewayv1alpha2.GatewayReasonListenersNotReady)
+		readyCondition.Message = fmt.Sprintf("DataPlane %s is not ready yet", dataplane.Name)
+	case !k8sutils.IsReady(controlplane):
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = string(gatewayv1alpha2.GatewayReasonListenersNotReady)
+		readyCondition.Message = fmt.Sprintf("ControlPlane %s is not ready yet", controlplane.Name)
+	case len(addresses) == 0:
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = string(gatewayv1alpha2.GatewayReasonAddressNotAssigned)
+		readyCondition.Message = "no address has been assigned to the DataPlane Service yet"
+	}
+
+	gateway.Status.Addresses = addresses
+	gateway.Status.Listeners = generateListenerStatuses(gateway, dataplane, readyCondition.Status == metav1.ConditionTrue)
+	meta.SetStatusCondition(&gateway.Status.Conditions, readyCondition)
+}
+
+// generateListenerStatuses returns the status of every listener of the
+// provided Gateway, preserving the conditions which did not change. Listeners
+// which APISIX can't serve are reported as detached or conflicted, and never
+// become ready.
+func generateListenerStatuses(
+	gateway *gatewayv1alpha2.Gateway,
+	dataplane *apisixoperatorv1alpha1.DataPlane,
+	ready bool,
+) []gatewayv1alpha2.ListenerStatus {
+	_, issues := dataPlanePortsForGateway(gateway, dataplane)
+
+	existing := make(map[gatewayv1alpha2.SectionName]gatewayv1alpha2.ListenerStatus, len(gateway.Status.Listeners))
+	for _, listenerStatus := range gateway.Status.Listeners {
+		existing[listenerStatus.Name] = listenerStatus
+	}
+
+	statuses := make([]gatewayv1alpha2.ListenerStatus, 0, len(gateway.Spec.Listeners))
+	for _, listener := range gateway.Spec.Listeners {
+		listenerStatus := gatewayv1alpha2.ListenerStatus{
+			Name:           listener.Name,
+			SupportedKinds: supportedRouteKindsForProtocol(listener.Protocol),
+			Conditions:     []metav1.Condition{},
+		}
+		if old, ok := existing[listener.Name]; ok {
+			listenerStatus.AttachedRoutes = old.AttachedRoutes
+			listenerStatus.Conditions = old.Conditions
+		}
+
+		detachedCondition := metav1.Condition{
+			Type:               string(gatewayv1alpha2.ListenerConditionDetached),
+			Status:             metav1.ConditionFalse,
+			Reason:             string(gatewayv1alpha2.ListenerReasonAttached),
+			ObservedGeneration: gateway.Generation,
+		}
+		conflictedCondition := metav1.Condition{
+			Type:               string(gatewayv1alpha2.ListenerConditionConflicted),
+			Status:             metav1.ConditionFalse,
+			Reason:             string(gatewayv1alpha2.ListenerReasonNoConflicts),
+			ObservedGeneration: gateway.Generation,
+		}
+		readyCondition := metav1.Condition{
+			Type:               string(gatewayv1alpha2.ListenerConditionReady),
+			Status:             metav1.ConditionTrue,
+			Reason:             string(gatewayv1alpha2.ListenerReasonReady),
+			ObservedGeneration: gateway.Generation,
+		}
+
+		if issue, ok := issues[listener.Name]; ok {
+			issueCondition := &detachedCondition
+			if issue.conditionType == gatewayv1alpha2.ListenerConditionConflicted {
+				issueCondition = &conflictedCondition
+			}
+			issueCondition.Status = metav1.ConditionTrue
+			issueCondition.Reason = string(issue.reason)
+			issueCondition.Message = issue.message
+
+			readyCondition.Status = metav1.ConditionFalse
+			readyCondition.Reason = string(gatewayv1alpha2.ListenerReasonInvalid)
+			readyCondition.Message = issue.message
+		} else if !ready {
+			readyCondition.Status = metav1.ConditionFalse
+			readyCondition.Reason = string(gatewayv1alpha2.ListenerReasonPending)
+			readyCondition.Message = "waiting for the DataPlane and ControlPlane of the Gateway to be ready"
+		}
+		meta.SetStatusCondition(&listenerStatus.Conditions, detachedCondition)
+		meta.SetStatusCondition(&listenerStatus.Conditions, conflictedCondition)
+		meta.SetStatusCondition(&listenerStatus.Conditions, readyCondition)
+
+		statuses = append(statuses, listenerStatus)
+	}
+
+	return statuses
+}
+
+// supportedRouteKindsForProtocol returns the kinds of routes which can be
+// attached to a listener using the provided protocol.
+func supportedRouteKindsForProtocol(protocol gatewayv1alpha2.ProtocolType) []gatewayv1alpha2.RouteGroupKind {
+	group := gatewayv1alpha2.Group(gatewayv1alpha2.GroupName)
+
+	var kind gatewayv1alpha2.Kind
+	switch protocol {
+	case gatewayv1alpha2.HTTPProtocolType, gatewayv1alpha2.HTTPSProtocolType:
+		kind = "HTTPRoute"
+	case gatewayv1alpha2.TLSProtocolType:
+		kind = "TLSRoute"
+	case gatewayv1alpha2.TCPProtocolType:
+		kind = "TCPRoute"
+	case gatewayv1alpha2.UDPProtocolType:
+		kind = "UDPRoute"
+	default:
+		return []gatewayv1alpha2.RouteGroupKind{}
+	}
+
+	return []gatewayv1alpha2.RouteGroupKind{{Group: &group, Kind: kind}}
+}
+
+// gatewayAddressesFromDataPlane returns the addresses that the provided
+// DataPlane reports it is reachable at in its status.
+func gatewayAddressesFromDataPlane(dataplane *apisixoperatorv1alpha1.DataPlane) []gatewayv1alpha2.GatewayAddress {
+	addresses := make([]gatewayv1alpha2.GatewayAddress, 0, len(dataplane.Status.Addresses))
+	for _, address := range dataplane.Status.Addresses {
+		addresses = append(addresses, newGatewayAddress(gatewayv1alpha2.AddressType(address.Type), address.Value))
+	}
+	return addresses
+}
+
+func newGatewayAddress(addressType gatewayv1alpha2.AddressType, value string) gatewayv1alpha2.GatewayAddress {
+	return gatewayv1alpha2.GatewayAddress{
+		Type:  &addressType,
+		Value: value,
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Gateway - Private Functions - DataPlane Ports
+// -----------------------------------------------------------------------------
+
+// getDataPlaneIngressPorts returns the ports explicitly configured on the
+// ingress Service of the provided DataPlane.
+func getDataPlaneIngressPorts(dataplane *apisixoperatorv1alpha1.DataPlane) []apisixoperatorv1alpha1.DataPlaneServicePort {
+	services := dataplane.Spec.Network.Services
+	if services == nil || services.Ingress == nil {
+		return nil
+	}
+	return services.Ingress.Ports
+}
+
+// setDataPlaneIngressPorts sets the ports of the ingress Service of the
+// provided DataPlane, leaving its other network options untouched.
+func setDataPlaneIngressPorts(dataplane *apisixoperatorv1alpha1.DataPlane, ports []apisixoperatorv1alpha1.DataPlaneServicePort) {
+	if dataplane.Spec.Network.Services == nil {
+		dataplane.Spec.Network.Services = &apisixoperatorv1alpha1.DataPlaneServices{}
+	}
+	if dataplane.Spec.Network.Services.Ingress == nil {
+		dataplane.Spec.Network.Services.Ingress = &apisixoperatorv1alpha1.DataPlaneServiceOptions{}
+	}
+	dataplane.Spec.Network.Services.Ingress.Ports = ports
+}
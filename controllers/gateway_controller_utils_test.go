@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+)
+
+func TestGatewayAddressesFromDataPlane(t *testing.T) {
+	ipType := gatewayv1alpha2.IPAddressType
+	hostnameType := gatewayv1alpha2.HostnameAddressType
+
+	for _, tt := range []struct {
+		name      string
+		addresses []apisixoperatorv1alpha1.DataPlaneAddress
+		expected  []gatewayv1alpha2.GatewayAddress
+	}{
+		{
+			name:     "dataplane without addresses",
+			expected: []gatewayv1alpha2.GatewayAddress{},
+		},
+		{
+			name: "dataplane with ip and hostname addresses",
+			addresses: []apisixoperatorv1alpha1.DataPlaneAddress{
+				{Type: apisixoperatorv1alpha1.DataPlaneAddressTypeIPAddress, Value: "1.2.3.4"},
+				{Type: apisixoperatorv1alpha1.DataPlaneAddressTypeHostname, Value: "lb.example.com"},
+			},
+			expected: []gatewayv1alpha2.GatewayAddress{
+				{Type: &ipType, Value: "1.2.3.4"},
+				{Type: &hostnameType, Value: "lb.example.com"},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dataplane := &apisixoperatorv1alpha1.DataPlane{
+				Status: apisixoperatorv1alpha1.DataPlaneStatus{Addresses: tt.addresses},
+			}
+			require.Equal(t, tt.expected, gatewayAddressesFromDataPlane(dataplane))
+		})
+	}
+}
+
+func TestGenerateListenerStatuses(t *testing.T) {
+	gateway := &gatewayv1alpha2.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec: gatewayv1alpha2.GatewaySpec{
+			Listeners: []gatewayv1alpha2.Listener{
+				{Name: "http", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 80},
+				{Name: "udp", Protocol: gatewayv1alpha2.UDPProtocolType, Port: 53},
+			},
+		},
+	}
+
+	statuses := generateListenerStatuses(gateway, &apisixoperatorv1alpha1.DataPlane{}, false)
+	require.Len(t, statuses, 2)
+	require.Equal(t, gatewayv1alpha2.Kind("HTTPRoute"), statuses[0].SupportedKinds[0].Kind)
+	require.Equal(t, gatewayv1alpha2.Kind("UDPRoute"), statuses[1].SupportedKinds[0].Kind)
+	for _, status := range statuses {
+		require.True(t, meta.IsStatusConditionFalse(status.Conditions, string(gatewayv1alpha2.ListenerConditionReady)))
+	}
+
+	gateway.Status.Listeners = statuses
+	statuses = generateListenerStatuses(gateway, &apisixoperatorv1alpha1.DataPlane{}, true)
+	for _, status := range statuses {
+		condition := meta.FindStatusCondition(status.Conditions, string(gatewayv1alpha2.ListenerConditionReady))
+		require.NotNil(t, condition)
+		require.Equal(t, metav1.ConditionTrue, condition.Status)
+		require.Equal(t, int64(2), condition.ObservedGeneration)
+		require.True(t, meta.IsStatusConditionFalse(status.Conditions, string(gatewayv1alpha2.ListenerConditionDetached)))
+	}
+
+	gateway.Spec.Listeners = append(gateway.Spec.Listeners,
+		gatewayv1alpha2.Listener{Name: "custom", Protocol: "example.com/custom", Port: 8000},
+	)
+	statuses = generateListenerStatuses(gateway, &apisixoperatorv1alpha1.DataPlane{}, true)
+	require.Len(t, statuses, 3)
+	require.True(t, meta.IsStatusConditionTrue(statuses[2].Conditions, string(gatewayv1alpha2.ListenerConditionDetached)))
+	condition := meta.FindStatusCondition(statuses[2].Conditions, string(gatewayv1alpha2.ListenerConditionReady))
+	require.NotNil(t, condition)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, string(gatewayv1alpha2.ListenerReasonInvalid), condition.Reason)
+}
+
+func TestDataPlanePortsForGateway(t *testing.T) {
+	adminPort := int32(9500)
+	for _, tt := range []struct {
+		name           string
+		dataplane      *apisixoperatorv1alpha1.DataPlane
+		listeners      []gatewayv1alpha2.Listener
+		expectedPorts  []apisixoperatorv1alpha1.DataPlaneServicePort
+		expectedIssues map[gatewayv1alpha2.SectionName]gatewayv1alpha2.ListenerConditionReason
+	}{
+		{
+			name: "all supported protocols are mapped",
+			listeners: []gatewayv1alpha2.Listener{
+				{Name: "http", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 80},
+				{Name: "https", Protocol: gatewayv1alpha2.HTTPSProtocolType, Port: 443},
+				{Name: "tls", Protocol: gatewayv1alpha2.TLSProtocolType, Port: 8443},
+				{Name: "tcp", Protocol: gatewayv1alpha2.TCPProtocolType, Port: 53},
+				{Name: "udp", Protocol: gatewayv1alpha2.UDPProtocolType, Port: 53},
+			},
+			expectedPorts: []apisixoperatorv1alpha1.DataPlaneServicePort{
+				{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+				{Port: 443, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTPS},
+				{Port: 8443, Protocol: apisixoperatorv1alpha1.ProxyProtocolTLS},
+				{Port: 53, Protocol: apisixoperatorv1alpha1.ProxyProtocolTCP},
+				{Port: 53, Protocol: apisixoperatorv1alpha1.ProxyProtocolUDP},
+			},
+			expectedIssues: map[gatewayv1alpha2.SectionName]gatewayv1alpha2.ListenerConditionReason{},
+		},
+		{
+			name: "listeners sharing a port and protocol share the DataPlane port",
+			listeners: []gatewayv1alpha2.Listener{
+				{Name: "foo", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 80},
+				{Name: "bar", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 80},
+			},
+			expectedPorts: []apisixoperatorv1alpha1.DataPlaneServicePort{
+				{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+			},
+			expectedIssues: map[gatewayv1alpha2.SectionName]gatewayv1alpha2.ListenerConditionReason{},
+		},
+		{
+			name: "listeners APISIX can't serve are reported",
+			listeners: []gatewayv1alpha2.Listener{
+				{Name: "http", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 80},
+				{Name: "custom", Protocol: "example.com/custom", Port: 8000},
+				{Name: "admin", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 9444},
+				{Name: "status", Protocol: gatewayv1alpha2.TCPProtocolType, Port: 8100},
+				{Name: "mapped", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 9080},
+				{Name: "conflict", Protocol: gatewayv1alpha2.HTTPSProtocolType, Port: 80},
+			},
+			expectedPorts: []apisixoperatorv1alpha1.DataPlaneServicePort{
+				{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+			},
+			expectedIssues: map[gatewayv1alpha2.SectionName]gatewayv1alpha2.ListenerConditionReason{
+				"custom":   gatewayv1alpha2.ListenerReasonUnsupportedProtocol,
+				"admin":    gatewayv1alpha2.ListenerReasonPortUnavailable,
+				"status":   gatewayv1alpha2.ListenerReasonPortUnavailable,
+				"mapped":   gatewayv1alpha2.ListenerReasonPortUnavailable,
+				"conflict": gatewayv1alpha2.ListenerReasonProtocolConflict,
+			},
+		},
+		{
+			name: "the admin port configured on the dataplane is reserved",
+			dataplane: &apisixoperatorv1alpha1.DataPlane{
+				Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+					Config: &apisixoperatorv1alpha1.DataPlaneConfig{
+						Admin: &apisixoperatorv1alpha1.DataPlaneAdminAPIOptions{Port: &adminPort},
+					},
+				},
+			},
+			listeners: []gatewayv1alpha2.Listener{
+				{Name: "default-admin", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 9444},
+				{Name: "unused", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 9180},
+				{Name: "admin", Protocol: gatewayv1alpha2.HTTPProtocolType, Port: 9500},
+			},
+			expectedPorts: []apisixoperatorv1alpha1.DataPlaneServicePort{
+				{Port: 9444, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+				{Port: 9180, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+			},
+			expectedIssues: map[gatewayv1alpha2.SectionName]gatewayv1alpha2.ListenerConditionReason{
+				"admin": gatewayv1alpha2.ListenerReasonPortUnavailable,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := &gatewayv1alpha2.Gateway{
+				Spec: gatewayv1alpha2.GatewaySpec{Listeners: tt.listeners},
+			}
+			dataplane := tt.dataplane
+			if dataplane == nil {
+				dataplane = &apisixoperatorv1alpha1.DataPlane{}
+			}
+
+			ports, issues := dataPlanePortsForGateway(gateway, dataplane)
+			require.Equal(t, tt.expectedPorts, ports)
+			reasons := make(map[gatewayv1alpha2.SectionName]gatewayv1alpha2.ListenerConditionReason, len(issues))
+			for name, issue := range issues {
+				reasons[name] = issue.reason
+			}
+			require.Equal(t, tt.expectedIssues, reasons)
+		})
+	}
+}
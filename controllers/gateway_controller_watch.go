@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+)
+
+// -----------------------------------------------------------------------------
+// GatewayReconciler - Watch Predicates
+// -----------------------------------------------------------------------------
+
+func (r *GatewayReconciler) gatewayHasMatchingGatewayClass(obj client.Object) bool {
+	ctx := context.Background()
+
+	gateway, ok := obj.(*gatewayv1alpha2.Gateway)
+	if !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run predicate function",
+			"expected", "Gateway", "found", reflect.TypeOf(obj),
+		)
+		return false
+	}
+
+	gatewayClass := &gatewayv1alpha2.GatewayClass{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: string(gateway.Spec.GatewayClassName)}, gatewayClass); err != nil {
+		// filtering here is just an optimization, the reconciler will check the
+		// class again. If we fail here it's most likely because of some failure
+		// of the Kubernetes API and it's technically better to enqueue the object
+		// than to drop it for eventual consistency during cluster outages.
+		log.FromContext(ctx).Error(err, "could not retrieve gatewayclass in predicate func")
+		return true
+	}
+
+	return gatewayutils.IsGatewayClassControlled(gatewayClass, r.ControllerName)
+}
+
+func (r *GatewayReconciler) gatewayClassMatchesController(obj client.Object) bool {
+	gatewayClass, ok := obj.(*gatewayv1alpha2.GatewayClass)
+	if !ok {
+		log.FromContext(context.Background()).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run predicate function",
+			"expected", "GatewayClass", "found", reflect.TypeOf(obj),
+		)
+		return false
+	}
+
+	return gatewayutils.IsGatewayClassControlled(gatewayClass, r.ControllerName)
+}
+
+// -----------------------------------------------------------------------------
+// GatewayReconciler - Watch Map Funcs
+// -----------------------------------------------------------------------------
+
+func (r *GatewayReconciler) listGatewaysForGatewayClass(obj client.Object) (recs []reconcile.Request) {
+	ctx := context.Background()
+
+	gatewayClass, ok := obj.(*gatewayv1alpha2.GatewayClass)
+	if !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "GatewayClass", "found", reflect.TypeOf(obj),
+		)
+		return
+	}
+
+	gateways := &gatewayv1alpha2.GatewayList{}
+	if err := r.Client.List(ctx, gateways); err != nil {
+		log.FromContext(ctx).Error(err, "could not list gateways in map func")
+		return
+	}
+
+	for _, gateway := range gateways.Items {
+		if string(gateway.Spec.GatewayClassName) == gatewayClass.Name {
+			recs = append(recs, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: gateway.Namespace,
+					Name:      gateway.Name,
+				},
+			})
+		}
+	}
+
+	return
+}
+
+func (r *GatewayReconciler) listGatewaysForAPISIXConfiguration(obj client.Object) (recs []reconcile.Request) {
+	ctx := context.Background()
+	apisixConfiguration, ok := obj.(*apisixoperatorv1alpha1.APISIXConfiguration)
+	if !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "APISIXConfiguration", "found", reflect.TypeOf(obj),
+		)
+		return
+	}
+
+	gatewayClasses := &gatewayv1alpha2.GatewayClassList{}
+	if err := r.Client.List(ctx, gatewayClasses); err != nil {
+		log.FromContext(ctx).Error(err, "could not list gatewayclasses in map func")
+		return
+	}
+
+	for _, gatewayClass := range gatewayClasses.Items {
+		if !gatewayutils.IsGatewayClassControlled(&gatewayClass, r.ControllerName) ||
+			!gatewayutils.IsParametersRefForAPISIXConfiguration(gatewayClass.Spec.ParametersRef, apisixConfiguration) {
+			continue
+		}
+		recs = append(recs, r.listGatewaysForGatewayClass(&gatewayClass)...)
+	}
+
+	return
+}
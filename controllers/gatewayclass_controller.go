@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+)
+
+// -----------------------------------------------------------------------------
+// GatewayClassReconciler
+// -----------------------------------------------------------------------------
+
+// GatewayClassReconciler reconciles a GatewayClass object
+type GatewayClassReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// ControllerName is the name this operator uses to claim GatewayClasses
+	// through their .spec.controllerName field.
+	ControllerName string
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GatewayClassReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// watch GatewayClass objects, filtering out any GatewayClasses which
+		// are not configured with a supported controllerName.
+		For(&gatewayv1alpha2.GatewayClass{},
+			builder.WithPredicates(predicate.NewPredicateFuncs(r.gatewayClassMatchesController))).
+		// watch for changes in APISIXConfigurations referenced by GatewayClasses
+		// so that a missing configuration being created (or deleted) is
+		// reflected in the Accepted condition.
+		Watches(
+			&source.Kind{Type: &apisixoperatorv1alpha1.APISIXConfiguration{}},
+			handler.EnqueueRequestsFromMapFunc(r.listGatewayClassesForAPISIXConfiguration)).
+		Complete(r)
+}
+
+// Reconcile moves the current state of an object to the intended state.
+func (r *GatewayClassReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx).WithName("GatewayClass")
+
+	debug(log, "reconciling GatewayClass resource", req)
+	gatewayClass := new(gatewayv1alpha2.GatewayClass)
+	if err := r.Client.Get(ctx, req.NamespacedName, gatewayClass); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !gatewayutils.IsGatewayClassControlled(gatewayClass, r.ControllerName) {
+		debug(log, "GatewayClass is not controlled by this operator, ignoring", gatewayClass)
+		return ctrl.Result{}, nil
+	}
+
+	debug(log, "validating GatewayClass parametersRef", gatewayClass)
+	condition := k8sutils.NewCondition(
+		k8sutils.ConditionType(gatewayv1alpha2.GatewayClassConditionStatusAccepted),
+		metav1.ConditionTrue,
+		k8sutils.ConditionReason(gatewayv1alpha2.GatewayClassReasonAccepted),
+		"GatewayClass is accepted by the APISIX operator",
+	)
+	if _, err := gatewayutils.GetAPISIXConfigurationForGatewayClass(ctx, r.Client, gatewayClass); err != nil {
+		switch {
+		case errors.Is(err, operatorerrors.ErrObjectMissingParametersRef):
+			// a parametersRef is optional: a GatewayClass without one is
+			// provisioned with the default configuration.
+		case errors.Is(err, operatorerrors.ErrInvalidParametersRef):
+			info(log, "GatewayClass has invalid parametersRef", gatewayClass, "error", err)
+			condition = k8sutils.NewCondition(
+				k8sutils.ConditionType(gatewayv1alpha2.GatewayClassConditionStatusAccepted),
+				metav1.ConditionFalse,
+				k8sutils.ConditionReason(gatewayv1alpha2.GatewayClassReasonInvalidParameters),
+				err.Error(),
+			)
+		default:
+			return ctrl.Result{}, fmt.Errorf("failed to validate parametersRef of GatewayClass %s: %w", gatewayClass.Name, err)
+		}
+	}
+	condition.ObservedGeneration = gatewayClass.Generation
+
+	gwc := gatewayClassDecorator{gatewayClass}
+	if !gwc.ensureAcceptedCondition(condition) {
+		debug(log, "GatewayClass status is up to date", gatewayClass)
+		return ctrl.Result{}, nil
+	}
+
+	debug(log, "updating GatewayClass status", gatewayClass, "accepted", condition.Status)
+	if err := r.Client.Status().Update(ctx, gatewayClass); err != nil {
+		if k8serrors.IsConflict(err) {
+			debug(log, "conflict found when updating GatewayClass status, retrying", gatewayClass)
+			return ctrl.Result{Requeue: true, RequeueAfter: requeueWithoutBackoff}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	debug(log, "reconciliation complete for GatewayClass resource", gatewayClass)
+	return ctrl.Result{}, nil
+}
@@ -0,0 +1,9 @@
+package controllers
+
+// -----------------------------------------------------------------------------
+// GatewayClassReconciler - RBAC
+// -----------------------------------------------------------------------------
+
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apisix-operator.apisix-operator.apisix.apache.org,resources=apisixconfigurations,verbs=get;list;watch
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+)
+
+// -----------------------------------------------------------------------------
+// GatewayClass - Decorator
+// -----------------------------------------------------------------------------
+
+// gatewayClassDecorator wraps a GatewayClass so that it implements the
+// k8sutils.ConditionsAware interface.
+type gatewayClassDecorator struct {
+	*gatewayv1alpha2.GatewayClass
+}
+
+func (gwc gatewayClassDecorator) GetConditions() []metav1.Condition {
+	return gwc.Status.Conditions
+}
+
+func (gwc gatewayClassDecorator) SetConditions(conditions []metav1.Condition) {
+	gwc.Status.Conditions = conditions
+}
+
+// ensureAcceptedCondition sets the provided Accepted condition on the
+// GatewayClass, and returns true if the status of the GatewayClass changed.
+func (gwc gatewayClassDecorator) ensureAcceptedCondition(condition metav1.Condition) bool {
+	current, present := k8sutils.GetCondition(k8sutils.ConditionType(condition.Type), gwc)
+	if present &&
+		current.Status == condition.Status &&
+		current.Reason == condition.Reason &&
+		current.Message == condition.Message &&
+		current.ObservedGeneration == condition.ObservedGeneration {
+		return false
+	}
+	k8sutils.SetCondition(condition, gwc)
+	return true
+}
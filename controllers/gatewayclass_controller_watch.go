@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+)
+
+// -----------------------------------------------------------------------------
+// GatewayClassReconciler - Watch Predicates
+// -----------------------------------------------------------------------------
+
+func (r *GatewayClassReconciler) gatewayClassMatchesController(obj client.Object) bool {
+	gatewayClass, ok := obj.(*gatewayv1alpha2.GatewayClass)
+	if !ok {
+		log.FromContext(context.Background()).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run predicate function",
+			"expected", "GatewayClass", "found", reflect.TypeOf(obj),
+		)
+		return false
+	}
+
+	return gatewayutils.IsGatewayClassControlled(gatewayClass, r.ControllerName)
+}
+
+// -----------------------------------------------------------------------------
+// GatewayClassReconciler - Watch Map Funcs
+// -----------------------------------------------------------------------------
+
+func (r *GatewayClassReconciler) listGatewayClassesForAPISIXConfiguration(obj client.Object) (recs []reconcile.Request) {
+	ctx := context.Background()
+
+	apisixConfiguration, ok := obj.(*apisixoperatorv1alpha1.APISIXConfiguration)
+	if !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "APISIXConfiguration", "found", reflect.TypeOf(obj),
+		)
+		return
+	}
+
+	gatewayClasses := &gatewayv1alpha2.GatewayClassList{}
+	if err := r.Client.List(ctx, gatewayClasses); err != nil {
+		log.FromContext(ctx).Error(err, "could not list gatewayclasses in map func")
+		return
+	}
+
+	for _, gatewayClass := range gatewayClasses.Items {
+		if !gatewayutils.IsGatewayClassControlled(&gatewayClass, r.ControllerName) {
+			continue
+		}
+		if gatewayutils.IsParametersRefForAPISIXConfiguration(gatewayClass.Spec.ParametersRef, apisixConfiguration) {
+			recs = append(recs, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: gatewayClass.Name},
+			})
+		}
+	}
+
+	return
+}
@@ -1,15 +1,21 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,8 +24,11 @@ import (
 	"github.com/cloudflare/cfssl/signer"
 	"github.com/cloudflare/cfssl/signer/local"
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -27,7 +36,9 @@ import (
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 	"github.com/chever-john/apisix-operator/internal/consts"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
 	"github.com/chever-john/apisix-operator/internal/manager/logging"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
 	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
 	k8sresources "github.com/chever-john/apisix-operator/internal/utils/kubernetes/resources"
 )
@@ -100,7 +111,11 @@ func signCertificate(csr certificatesv1.CertificateSigningRequest, ca *corev1.Se
 		usages = append(usages, string(usage))
 	}
 
-	certExpiryDuration := time.Second * time.Duration(*csr.Spec.ExpirationSeconds)
+	// the expiration is optional, the requests made by the operator always set it.
+	certExpiryDuration := consts.DefaultClusterCertificateLifetime
+	if csr.Spec.ExpirationSeconds != nil {
+		certExpiryDuration = time.Second * time.Duration(*csr.Spec.ExpirationSeconds)
+	}
 	durationUntilExpiry := caCert.NotAfter.Sub(time.Now()) //nolint:gosimple
 	if durationUntilExpiry <= 0 {
 		return nil, fmt.Errorf("the signer has expired: %v", caCert.NotAfter)
@@ -128,17 +143,103 @@ func signCertificate(csr certificatesv1.CertificateSigningRequest, ca *corev1.Se
 	return certBytes, nil
 }
 
-// maybeCreateCertificateSecret creates a namespace/name Secret for subject signed by the CA in the
-// mtlsCASecretNamespace/mtlsCASecretName Secret, or does nothing if a namespace/name Secret is
-// already present. It returns a boolean indicating if it created a Secret and an error indicating
-// any failures it encountered.
+// certificateIssuer issues the certificates securing the communication between ControlPlanes and
+// DataPlanes, and stores them in a TLS Secret holding the ca.crt, tls.crt and tls.key keys.
+type certificateIssuer interface {
+	// ensureCertificateSecret ensures a certificate for subject is issued to owner. It returns a boolean
+	// indicating if it created or updated any object, and the Secret holding the certificate, which is
+	// nil if the certificate has not been issued yet.
+	ensureCertificateSecret(ctx context.Context,
+		owner client.Object,
+		subject string,
+		usages []certificatesv1.KeyUsage,
+		k8sClient client.Client,
+	) (bool, *corev1.Secret, error)
+}
+
+// newCertificateIssuer returns the issuer of the mTLS certificates: cert-manager if a cert-manager
+// issuer is referenced, the cluster CA in the mtlsCASecretNamespace/mtlsCASecretName Secret otherwise,
+// either through CertificateSigningRequests or in memory.
+func newCertificateIssuer(
+	certManagerIssuer *CertManagerIssuerRef,
+	useCertificateSigningRequests bool,
+	mtlsCASecretName, mtlsCASecretNamespace string,
+	lifetime, renewBefore time.Duration,
+) certificateIssuer {
+	if lifetime <= 0 {
+		lifetime = consts.DefaultClusterCertificateLifetime
+	}
+	if renewBefore <= 0 {
+		renewBefore = consts.DefaultClusterCertificateRenewBefore
+	}
+
+	if certManagerIssuer != nil {
+		return &certManagerCertificateIssuer{
+			issuerRef:   *certManagerIssuer,
+			lifetime:    lifetime,
+			renewBefore: renewBefore,
+		}
+	}
+	if useCertificateSigningRequests {
+		return &csrCertificateIssuer{
+			caSecretName:      mtlsCASecretName,
+			caSecretNamespace: mtlsCASecretNamespace,
+			lifetime:          lifetime,
+			renewBefore:       renewBefore,
+		}
+	}
+	return &clusterCACertificateIssuer{
+		caSecretName:      mtlsCASecretName,
+		caSecretNamespace: mtlsCASecretNamespace,
+		lifetime:          lifetime,
+		renewBefore:       renewBefore,
+	}
+}
+
+// maybeCreateCertificateSecret ensures a certificate for subject is issued to owner by the provided issuer.
+// It returns a boolean indicating if it created or updated any object, the Secret holding the certificate,
+// and an error indicating any failures it encountered. The boolean is true as well while the certificate
+// has not been issued yet, in which case the returned Secret is nil.
 func maybeCreateCertificateSecret(ctx context.Context,
 	owner client.Object,
-	subject, mtlsCASecretName, mtlsCASecretNamespace string,
+	subject string,
 	usages []certificatesv1.KeyUsage,
+	issuer certificateIssuer,
 	k8sClient client.Client,
 ) (bool, *corev1.Secret, error) {
 	logger := log.FromContext(ctx).WithName("MTLSCertificateCreation")
+	ctx = log.IntoContext(ctx, logger)
+
+	createdOrUpdated, secret, err := issuer.ensureCertificateSecret(ctx, owner, subject, usages, k8sClient)
+	if err != nil {
+		return false, nil, err
+	}
+	if secret == nil {
+		debug(logger, "mTLS certificate not issued yet, waiting", owner)
+		return true, nil, nil
+	}
+	return createdOrUpdated, secret, nil
+}
+
+// clusterCACertificateIssuer is the default certificateIssuer, which signs certificates in memory
+// with the cluster CA held in the caSecretNamespace/caSecretName Secret.
+type clusterCACertificateIssuer struct {
+	caSecretName      string
+	caSecretNamespace string
+	lifetime          time.Duration
+	renewBefore       time.Duration
+}
+
+// ensureCertificateSecret creates a Secret owned by owner holding a certificate for subject signed by
+// the cluster CA. If the Secret is already present, the certificate it holds is re-issued when it is due
+// for renewal, when it was not signed by the current CA or when it was issued for a different subject.
+func (i *clusterCACertificateIssuer) ensureCertificateSecret(ctx context.Context,
+	owner client.Object,
+	subject string,
+	usages []certificatesv1.KeyUsage,
+	k8sClient client.Client,
+) (bool, *corev1.Secret, error) {
+	logger := log.FromContext(ctx)
 	setCALogger(logger)
 
 	selectorKey, selectorValue := getManagedLabelForOwner(owner)
@@ -158,6 +259,12 @@ func maybeCreateCertificateSecret(ctx context.Context,
 		return false, nil, fmt.Errorf("found %d mTLS secrets for DataPlane currently unsupported: expected 1 or less", count)
 	}
 
+	ca := &corev1.Secret{}
+	err = k8sClient.Get(ctx, client.ObjectKey{Namespace: i.caSecretNamespace, Name: i.caSecretName}, ca)
+	if err != nil {
+		return false, nil, err
+	}
+
 	ownerPrefix := getPrefixForOwner(owner)
 	generatedSecret := k8sresources.GenerateNewTLSSecret(owner.GetNamespace(), owner.GetName(), ownerPrefix)
 	k8sutils.SetOwnerForObject(generatedSecret, owner)
@@ -167,12 +274,83 @@ func maybeCreateCertificateSecret(ctx context.Context,
 		var updated bool
 		existingSecret := &secrets[0]
 		updated, existingSecret.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingSecret.ObjectMeta, generatedSecret.ObjectMeta)
+
+		renew, reason := certificateNeedsRenewal(existingSecret, ca, subject, i.renewBefore, time.Now())
+		if renew {
+			debug(logger, "re-issuing mTLS certificate", existingSecret, "reason", reason)
+			existingSecret.Data, err = issueCertificate(owner, subject, usages, i.lifetime, ca)
+			if err != nil {
+				return false, nil, err
+			}
+			updated = true
+		}
+
 		if updated {
 			return true, existingSecret, k8sClient.Update(ctx, existingSecret)
 		}
 		return false, existingSecret, nil
 	}
 
+	generatedSecret.Data, err = issueCertificate(owner, subject, usages, i.lifetime, ca)
+	if err != nil {
+		return false, nil, err
+	}
+
+	err = k8sClient.Create(ctx, generatedSecret)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return true, generatedSecret, nil
+}
+
+// issueCertificate generates a new private key for subject and returns the Secret data holding it along
+// with the certificate signed for it by the CA in the provided Secret, valid for lifetime.
+func issueCertificate(
+	owner client.Object,
+	subject string,
+	usages []certificatesv1.KeyUsage,
+	lifetime time.Duration,
+	ca *corev1.Secret,
+) (map[string][]byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	csr, err := generateCertificateSigningRequest(owner, subject, usages, lifetime, priv)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := signCertificate(*csr, ca)
+	if err != nil {
+		return nil, err
+	}
+	privDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		"ca.crt":  ca.Data["tls.crt"],
+		"tls.crt": signed,
+		"tls.key": pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: privDer,
+		}),
+	}, nil
+}
+
+// generateCertificateSigningRequest returns a CertificateSigningRequest for subject signed with the provided
+// private key, requesting a certificate valid for lifetime from the operator signer.
+func generateCertificateSigningRequest(
+	owner client.Object,
+	subject string,
+	usages []certificatesv1.KeyUsage,
+	lifetime time.Duration,
+	priv *ecdsa.PrivateKey,
+) (*certificatesv1.CertificateSigningRequest, error) {
 	template := x509.CertificateRequest{
 		Subject: pkix.Name{
 			CommonName:   subject,
@@ -183,26 +361,20 @@ func maybeCreateCertificateSecret(ctx context.Context,
 		DNSNames:           []string{subject},
 	}
 
-	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		return false, nil, err
-	}
-
 	der, err := x509.CreateCertificateRequest(rand.Reader, &template, priv)
 	if err != nil {
-		return false, nil, err
+		return nil, err
 	}
 
-	// This is effectively a placeholder so long as we handle signing internally. When actually creating CSR resources,
-	// this string is used by signers to filter which resources they pay attention to
-	signerName := "apisix-operator.apisix.apache.org/mtls"
-	// TODO This creates certificates that last for 10 years as an arbitrarily long period for the alpha. A production-
-	// ready implementation should use a shorter lifetime and rotate certificates. Rotation requires some mechanism to
-	// recognize that certificates have expired (ideally without permissions to read Secrets across the cluster) and
-	// to get Deployments to acknowledge them.
-	expiration := int32(315400000)
+	if lifetime <= 0 {
+		lifetime = consts.DefaultClusterCertificateLifetime
+	}
+	if maxLifetime := time.Duration(math.MaxInt32) * time.Second; lifetime > maxLifetime {
+		lifetime = maxLifetime
+	}
+	expiration := int32(lifetime.Seconds())
 
-	csr := certificatesv1.CertificateSigningRequest{
+	return &certificatesv1.CertificateSigningRequest{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: owner.GetNamespace(),
 			Name:      owner.GetName(),
@@ -212,42 +384,207 @@ func maybeCreateCertificateSecret(ctx context.Context,
 				Type:  "CERTIFICATE REQUEST",
 				Bytes: der,
 			}),
-			SignerName:        signerName,
+			// signers use this name to filter the CertificateSigningRequests they pay attention to.
+			SignerName:        consts.MTLSCertificateSignerName,
 			ExpirationSeconds: &expiration,
 			Usages:            usages,
 		},
+	}, nil
+}
+
+// -----------------------------------------------------------------------------
+// Private Functions - Certificate rotation
+// -----------------------------------------------------------------------------
+
+// parseCertificateSecret returns the certificate held by the provided TLS Secret.
+func parseCertificateSecret(secret *corev1.Secret) (*x509.Certificate, error) {
+	block, _ := pem.Decode(secret.Data["tls.crt"])
+	if block == nil {
+		return nil, fmt.Errorf("secret %s/%s does not contain a PEM encoded certificate", secret.Namespace, secret.Name)
 	}
+	return x509.ParseCertificate(block.Bytes)
+}
 
-	ca := &corev1.Secret{}
-	err = k8sClient.Get(ctx, client.ObjectKey{Namespace: mtlsCASecretNamespace, Name: mtlsCASecretName}, ca)
-	if err != nil {
-		return false, nil, err
+// certificateRenewalTime returns the time at which the provided certificate has to be re-issued. The
+// certificate is renewed renewBefore its expiration, unless that is longer than a third of its validity
+// (e.g. when it was capped by the expiration of the CA), in which case it's renewed after two thirds of it.
+func certificateRenewalTime(cert *x509.Certificate, renewBefore time.Duration) time.Time {
+	if renewBefore <= 0 {
+		renewBefore = consts.DefaultClusterCertificateRenewBefore
 	}
+	if validity := cert.NotAfter.Sub(cert.NotBefore); renewBefore > validity/3 {
+		renewBefore = validity / 3
+	}
+	return cert.NotAfter.Add(-renewBefore)
+}
 
-	signed, err := signCertificate(csr, ca)
-	if err != nil {
-		return false, nil, err
+// certificateNeedsRenewal returns true, along with the reason, if the certificate held by the provided
+// Secret has to be re-issued.
+func certificateNeedsRenewal(secret, ca *corev1.Secret, subject string, renewBefore time.Duration, now time.Time) (bool, string) {
+	if !bytes.Equal(secret.Data["ca.crt"], ca.Data["tls.crt"]) {
+		return true, "cluster CA changed"
 	}
-	privDer, err := x509.MarshalECPrivateKey(priv)
+
+	cert, err := parseCertificateSecret(secret)
 	if err != nil {
-		return false, nil, err
+		return true, fmt.Sprintf("invalid certificate: %v", err)
 	}
 
-	generatedSecret.StringData = map[string]string{
-		"ca.crt":  string(ca.Data["tls.crt"]),
-		"tls.crt": string(signed),
-		"tls.key": string(pem.EncodeToMemory(&pem.Block{
-			Type:  "EC PRIVATE KEY",
-			Bytes: privDer,
-		})),
+	if len(cert.DNSNames) != 1 || cert.DNSNames[0] != subject {
+		return true, "certificate subject changed"
 	}
 
-	err = k8sClient.Create(ctx, generatedSecret)
+	if !now.Before(certificateRenewalTime(cert, renewBefore)) {
+		return true, "certificate is due for renewal"
+	}
+
+	return false, ""
+}
+
+// certificateRenewalRequeueAfter returns the amount of time after which the owner of the provided
+// certificate Secret has to be reconciled again to re-issue it.
+func certificateRenewalRequeueAfter(secret *corev1.Secret, renewBefore time.Duration) time.Duration {
+	cert, err := parseCertificateSecret(secret)
 	if err != nil {
-		return false, nil, err
+		// the certificate will be re-issued on the next reconciliation, which is going
+		// to be triggered by the update of the Secret.
+		return 0
 	}
+	// an additional second makes sure the certificate is due for renewal by then.
+	return time.Until(certificateRenewalTime(cert, renewBefore)) + time.Second
+}
 
-	return true, generatedSecret, nil
+// certificateHash returns the hash of the certificate held by the provided Secret.
+func certificateHash(secret *corev1.Secret) string {
+	sum := sha256.Sum256(secret.Data["tls.crt"])
+	return hex.EncodeToString(sum[:])
+}
+
+// podTemplateHashAnnotations are the pod template annotations holding the hashes of the certificates
+// and of the configuration files mounted in the pods managed by the operator.
+var podTemplateHashAnnotations = []string{
+	consts.ClusterCertificateHashAnnotation,
+	consts.EtcdCertificateHashAnnotation,
+	consts.APISIXConfigHashAnnotation,
+	consts.AdminKeyHashAnnotation,
+}
+
+// setCertificateHashAnnotation annotates the provided pod template with the hash of the certificate held
+// by the provided Secret, so that re-issuing the certificate triggers a rollout of the pods mounting it.
+func setCertificateHashAnnotation(template *corev1.PodTemplateSpec, annotation string, certSecret *corev1.Secret) {
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[annotation] = certificateHash(certSecret)
+}
+
+// setAdminKeyHashAnnotation annotates the provided pod template with the hash of the Admin API key held by
+// the provided Secret, so that rotating the key triggers a rollout of the pods reading it from their
+// environment.
+func setAdminKeyHashAnnotation(template *corev1.PodTemplateSpec, adminKeySecret *corev1.Secret) {
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	sum := sha256.Sum256(adminKeySecret.Data[dataplaneutils.AdminKeySecretKey])
+	template.Annotations[consts.AdminKeyHashAnnotation] = hex.EncodeToString(sum[:])
+}
+
+// setLastAppliedAnnotations records the keys of the annotations of the provided
+// pod template overrides on the template they were merged in, so that they're
+// removed from the existing template once unset from the overrides.
+func setLastAppliedAnnotations(template, overrides *corev1.PodTemplateSpec) {
+	if overrides == nil || len(overrides.Annotations) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(overrides.Annotations))
+	for key := range overrides.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	template.Annotations[consts.LastAppliedAnnotationsAnnotation] = strings.Join(keys, ",")
+}
+
+// ensurePodTemplateIsUpdated replaces the pod template of the provided existing
+// workload with the generated one if they semantically differ, and returns true
+// if it did. The generated template is defaulted by the API server beforehand,
+// through a dry run of the update, so that the defaulted fields don't cause
+// updates while any other drift, whether caused by a change of the owner or by
+// a manual edit, is reverted. The annotations set by others on the existing
+// template, e.g. by kubectl rollout restart, are preserved, while the ones last
+// applied from the pod template overrides and no longer generated are removed.
+func ensurePodTemplateIsUpdated(
+	ctx context.Context,
+	k8sClient client.Client,
+	existing client.Object,
+	generated *corev1.PodTemplateSpec,
+) (bool, error) {
+	dryRun := existing.DeepCopyObject().(client.Object)
+	existingTemplate, dryRunTemplate := podTemplateForObject(existing), podTemplateForObject(dryRun)
+	if existingTemplate == nil {
+		return false, fmt.Errorf("%w: expected a Deployment, a DaemonSet or a StatefulSet, found %T", operatorerrors.ErrUnexpectedObject, existing)
+	}
+
+	desired := generated.DeepCopy()
+	desired.Annotations = make(map[string]string, len(existingTemplate.Annotations)+len(generated.Annotations))
+	for key, value := range existingTemplate.Annotations {
+		desired.Annotations[key] = value
+	}
+	for _, key := range strings.Split(existingTemplate.Annotations[consts.LastAppliedAnnotationsAnnotation], ",") {
+		delete(desired.Annotations, key)
+	}
+	delete(desired.Annotations, consts.LastAppliedAnnotationsAnnotation)
+	for key, value := range generated.Annotations {
+		desired.Annotations[key] = value
+	}
+	ensurePodTemplateHashAnnotations(desired, generated)
+
+	*dryRunTemplate = *desired
+	if err := k8sClient.Update(ctx, dryRun, client.DryRunAll); err != nil {
+		return false, err
+	}
+
+	if equality.Semantic.DeepEqual(existingTemplate, dryRunTemplate) {
+		return false, nil
+	}
+	*existingTemplate = *desired
+	return true, nil
+}
+
+// podTemplateForObject returns the pod template of the provided workload, nil
+// if it's neither a Deployment, a DaemonSet nor a StatefulSet.
+func podTemplateForObject(obj client.Object) *corev1.PodTemplateSpec {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return &o.Spec.Template
+	case *appsv1.DaemonSet:
+		return &o.Spec.Template
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template
+	}
+	return nil
+}
+
+// ensurePodTemplateHashAnnotations copies the hash annotations from the generated pod template to the
+// existing one. It returns true if the existing pod template was updated.
+func ensurePodTemplateHashAnnotations(existing, generated *corev1.PodTemplateSpec) bool {
+	var updated bool
+	for _, annotation := range podTemplateHashAnnotations {
+		hash, ok := generated.Annotations[annotation]
+		existingHash, existingOK := existing.Annotations[annotation]
+		if hash == existingHash && ok == existingOK {
+			continue
+		}
+		if !ok {
+			delete(existing.Annotations, annotation)
+		} else {
+			if existing.Annotations == nil {
+				existing.Annotations = make(map[string]string)
+			}
+			existing.Annotations[annotation] = hash
+		}
+		updated = true
+	}
+	return updated
 }
 
 // -----------------------------------------------------------------------------
@@ -279,27 +616,101 @@ func debug(log logr.Logger, msg string, rawOBJ interface{}, keysAndValues ...int
 }
 
 // -----------------------------------------------------------------------------
-// DeploymentOptions - Private Functions - Equality Checks
+// PodDisruptionBudget - Private Functions
 // -----------------------------------------------------------------------------
 
-func deploymentOptionsDeepEqual(opts1, opts2 *apisixoperatorv1alpha1.DeploymentOptions) bool {
-	if !reflect.DeepEqual(opts1.ContainerImage, opts2.ContainerImage) {
-		return false
+// ensurePodDisruptionBudgetForOwner ensures a PodDisruptionBudget protecting
+// the pods of the provided DataPlane or ControlPlane exists if the provided
+// options ask for one, and deletes it otherwise. It returns true if any
+// PodDisruptionBudget was created, updated or deleted.
+func ensurePodDisruptionBudgetForOwner(
+	ctx context.Context,
+	c client.Client,
+	owner client.Object,
+	opts *apisixoperatorv1alpha1.PodDisruptionBudget,
+) (bool, error) {
+	labelKey, labelValue := getManagedLabelForOwner(owner)
+	pdbs, err := k8sutils.ListPodDisruptionBudgetsForOwner(ctx, c, labelKey, labelValue, owner.GetNamespace(), owner.GetUID())
+	if err != nil {
+		return false, err
 	}
 
-	if !reflect.DeepEqual(opts1.Version, opts2.Version) {
-		return false
+	if opts == nil {
+		var deleted bool
+		for i := range pdbs {
+			if err := c.Delete(ctx, &pdbs[i]); client.IgnoreNotFound(err) != nil {
+				return false, err
+			}
+			deleted = true
+		}
+		return deleted, nil
 	}
 
-	if !reflect.DeepEqual(opts1.Env, opts2.Env) {
-		return false
+	count := len(pdbs)
+	if count > 1 {
+		return false, fmt.Errorf("found %d poddisruptionbudgets for %s currently unsupported: expected 1 or less",
+			count, getPrefixForOwner(owner))
 	}
 
-	if !reflect.DeepEqual(opts1.EnvFrom, opts2.EnvFrom) {
-		return false
+	generatedPDB := generatePodDisruptionBudgetForOwner(owner, opts)
+	k8sutils.SetOwnerForObject(generatedPDB, owner)
+	addLabelForOwner(generatedPDB, owner)
+
+	if count == 1 {
+		var updated bool
+		existingPDB := &pdbs[0]
+		updated, existingPDB.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingPDB.ObjectMeta, generatedPDB.ObjectMeta)
+
+		if !reflect.DeepEqual(existingPDB.Spec.MinAvailable, generatedPDB.Spec.MinAvailable) ||
+			!reflect.DeepEqual(existingPDB.Spec.MaxUnavailable, generatedPDB.Spec.MaxUnavailable) ||
+			!reflect.DeepEqual(existingPDB.Spec.Selector, generatedPDB.Spec.Selector) {
+			existingPDB.Spec.MinAvailable = generatedPDB.Spec.MinAvailable
+			existingPDB.Spec.MaxUnavailable = generatedPDB.Spec.MaxUnavailable
+			existingPDB.Spec.Selector = generatedPDB.Spec.Selector
+			updated = true
+		}
+
+		if updated {
+			return true, c.Update(ctx, existingPDB)
+		}
+		return false, nil
+	}
+
+	return true, c.Create(ctx, generatedPDB)
+}
+
+// generatePodDisruptionBudgetForOwner returns the PodDisruptionBudget
+// selecting the pods of the provided DataPlane or ControlPlane, labelled with
+// its name.
+func generatePodDisruptionBudgetForOwner(
+	owner client.Object,
+	opts *apisixoperatorv1alpha1.PodDisruptionBudget,
+) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    owner.GetNamespace(),
+			GenerateName: fmt.Sprintf("%s-%s-", getPrefixForOwner(owner), owner.GetName()),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable:   opts.MinAvailable,
+			MaxUnavailable: opts.MaxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": owner.GetName(),
+				},
+			},
+		},
 	}
+}
 
-	return true
+// -----------------------------------------------------------------------------
+// DeploymentOptions - Private Functions - Equality Checks
+// -----------------------------------------------------------------------------
+
+// deploymentOptionsDeepEqual compares every deployment option, semantically so
+// that e.g. an empty and an unset environment are considered equal.
+func deploymentOptionsDeepEqual(opts1, opts2 *apisixoperatorv1alpha1.DeploymentOptions) bool {
+	return equality.Semantic.DeepEqual(opts1, opts2)
 }
 
 // -----------------------------------------------------------------------------
@@ -312,6 +723,10 @@ func getPrefixForOwner(owner client.Object) string {
 		return consts.ControlPlanePrefix
 	case *apisixoperatorv1alpha1.DataPlane:
 		return consts.DataPlanePrefix
+	case *etcdCertificateOwner:
+		return consts.EtcdPrefix
+	case *etcdClientCertificateOwner:
+		return consts.EtcdClientPrefix
 	}
 	return ""
 }
@@ -322,6 +737,10 @@ func getManagedLabelForOwner(owner client.Object) (key string, value string) {
 		return consts.GatewayOperatorControlledLabel, consts.ControlPlaneManagedLabelValue
 	case *apisixoperatorv1alpha1.DataPlane:
 		return consts.GatewayOperatorControlledLabel, consts.DataPlaneManagedLabelValue
+	case *etcdCertificateOwner:
+		return consts.GatewayOperatorControlledLabel, consts.EtcdManagedLabelValue
+	case *etcdClientCertificateOwner:
+		return consts.GatewayOperatorControlledLabel, consts.EtcdClientManagedLabelValue
 	}
 	return "", ""
 }
@@ -333,8 +752,12 @@ func getManagedLabelForOwner(owner client.Object) (key string, value string) {
 func addLabelForOwner(obj client.Object, owner client.Object) {
 	switch owner.(type) {
 	case *apisixoperatorv1alpha1.ControlPlane:
-		// addLabelForControlPlane(obj)
+		addLabelForControlPlane(obj)
 	case *apisixoperatorv1alpha1.DataPlane:
 		addLabelForDataplane(obj)
+	case *etcdCertificateOwner:
+		addLabelForEtcd(obj)
+	case *etcdClientCertificateOwner:
+		addLabelForEtcdClient(obj)
 	}
 }
@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+)
+
+// -----------------------------------------------------------------------------
+// cert-manager - Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	// CertManagerGroup is the API group of the cert-manager resources.
+	CertManagerGroup = "cert-manager.io"
+
+	// CertManagerClusterIssuerKind is the kind of the cert-manager cluster
+	// scoped issuers.
+	CertManagerClusterIssuerKind = "ClusterIssuer"
+
+	// CertManagerIssuerKind is the kind of the cert-manager namespaced issuers.
+	CertManagerIssuerKind = "Issuer"
+
+	// certManagerCertificateNameAnnotation is set by cert-manager on the Secrets
+	// it stores the certificates in, to the name of their Certificate.
+	certManagerCertificateNameAnnotation = "cert-manager.io/certificate-name"
+)
+
+// certManagerCertificateGVK is the GroupVersionKind of the cert-manager
+// Certificates. They are handled as unstructured objects so that the operator
+// doesn't depend on cert-manager.
+var certManagerCertificateGVK = schema.GroupVersionKind{
+	Group:   CertManagerGroup,
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// CertManagerIssuerRef references the cert-manager Issuer or ClusterIssuer
+// issuing the mTLS certificates of ControlPlanes and DataPlanes.
+type CertManagerIssuerRef struct {
+	// Name is the name of the issuer.
+	Name string
+	// Kind is the kind of the issuer, either ClusterIssuer or Issuer. An Issuer
+	// has to be present in the namespace of every ControlPlane and DataPlane.
+	Kind string
+	// Group is the API group of the issuer, cert-manager.io unless an external
+	// issuer is used.
+	Group string
+}
+
+// newCertManagerCertificate returns an empty cert-manager Certificate.
+func newCertManagerCertificate() *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certManagerCertificateGVK)
+	return certificate
+}
+
+// -----------------------------------------------------------------------------
+// cert-manager - Certificate Issuer
+// -----------------------------------------------------------------------------
+
+// certManagerCertificateIssuer is a certificateIssuer delegating the issuance
+// and the renewal of the certificates to cert-manager, so that the operator
+// doesn't need to hold the private key of a CA.
+type certManagerCertificateIssuer struct {
+	issuerRef   CertManagerIssuerRef
+	lifetime    time.Duration
+	renewBefore time.Duration
+}
+
+// ensureCertificateSecret ensures a cert-manager Certificate owned by owner is
+// present for subject, and returns the Secret cert-manager stores it in once it
+// has been issued. The Secret is managed by cert-manager, which only sets the
+// Certificate as its owner when run with --enable-certificate-owner-ref, hence
+// it's tracked through the Certificate (see certManagerSecretOwnerRequests).
+func (i *certManagerCertificateIssuer) ensureCertificateSecret(ctx context.Context,
+	owner client.Object,
+	subject string,
+	usages []certificatesv1.KeyUsage,
+	k8sClient client.Client,
+) (bool, *corev1.Secret, error) {
+	logger := log.FromContext(ctx)
+
+	generatedCertificate := i.generateCertificate(owner, subject, usages)
+
+	existingCertificate := newCertManagerCertificate()
+	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(generatedCertificate), existingCertificate)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return false, nil, err
+		}
+		debug(logger, "creating cert-manager Certificate", generatedCertificate)
+		return true, nil, k8sClient.Create(ctx, generatedCertificate)
+	}
+
+	if !k8sutils.IsOwnedByRefUID(existingCertificate, owner.GetUID()) {
+		return false, nil, fmt.Errorf("cert-manager Certificate %s/%s is not owned by %s",
+			existingCertificate.GetNamespace(), existingCertificate.GetName(), owner.GetName())
+	}
+	updatedCertificate := existingCertificate.DeepCopy()
+	addLabelForOwner(updatedCertificate, owner)
+	updatedCertificate.Object["spec"] = generatedCertificate.Object["spec"]
+	if !equality.Semantic.DeepEqual(existingCertificate, updatedCertificate) {
+		debug(logger, "updating cert-manager Certificate", updatedCertificate)
+		return true, nil, k8sClient.Update(ctx, updatedCertificate)
+	}
+
+	// the Secret is used as soon as it holds a certificate, regardless of the readiness
+	// of the Certificate, which is not ready while it's being renewed.
+	secret := &corev1.Secret{}
+	err = k8sClient.Get(ctx, client.ObjectKey{Namespace: owner.GetNamespace(), Name: generatedCertificate.GetName()}, secret)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	if len(secret.Data["tls.crt"]) == 0 {
+		return false, nil, nil
+	}
+	return false, secret, nil
+}
+
+// certManagerCertificateName returns the name of the cert-manager Certificate
+// issued to owner, and of the Secret it's stored in.
+func certManagerCertificateName(owner client.Object) string {
+	return fmt.Sprintf("%s-%s-mtls", getPrefixForOwner(owner), owner.GetName())
+}
+
+// generateCertificate returns the cert-manager Certificate for subject owned
+// by owner. The Certificate and the Secret it's stored in are named after the
+// owner.
+func (i *certManagerCertificateIssuer) generateCertificate(
+	owner client.Object,
+	subject string,
+	usages []certificatesv1.KeyUsage,
+) *unstructured.Unstructured {
+	name := certManagerCertificateName(owner)
+
+	// cert-manager uses the same key usage names as the CertificateSigningRequests.
+	certificateUsages := make([]interface{}, 0, len(usages))
+	for _, usage := range usages {
+		certificateUsages = append(certificateUsages, string(usage))
+	}
+
+	issuerRef := map[string]interface{}{
+		"name": i.issuerRef.Name,
+		"kind": i.issuerRef.Kind,
+	}
+	if i.issuerRef.Group != "" {
+		issuerRef["group"] = i.issuerRef.Group
+	}
+
+	certificate := newCertManagerCertificate()
+	certificate.SetNamespace(owner.GetNamespace())
+	certificate.SetName(name)
+	k8sutils.SetOwnerForObject(certificate, owner)
+	addLabelForOwner(certificate, owner)
+
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName":  name,
+		"commonName":  subject,
+		"dnsNames":    []interface{}{subject},
+		"duration":    i.lifetime.String(),
+		"renewBefore": i.renewBefore.String(),
+		"usages":      certificateUsages,
+		"subject": map[string]interface{}{
+			"organizations": []interface{}{"Apisix, Inc."},
+			"countries":     []interface{}{"US"},
+		},
+		"privateKey": map[string]interface{}{
+			"algorithm":      "ECDSA",
+			"size":           int64(256),
+			"rotationPolicy": "Always",
+		},
+		"issuerRef": issuerRef,
+	}
+
+	return certificate
+}
+
+// -----------------------------------------------------------------------------
+// cert-manager - Watch Helpers
+// -----------------------------------------------------------------------------
+
+// isCertManagerSecret returns whether the provided object is a Secret cert-manager
+// stores a certificate in.
+func isCertManagerSecret(obj client.Object) bool {
+	_, ok := obj.GetAnnotations()[certManagerCertificateNameAnnotation]
+	return ok
+}
+
+// certManagerSecretOwnerRequests maps a Secret cert-manager stores a certificate
+// in to the owners of kind ownerKind of its Certificate. The Secret itself isn't
+// owned by them, as it's managed by cert-manager.
+func certManagerSecretOwnerRequests(ctx context.Context, k8sClient client.Client, obj client.Object, ownerKind string) (recs []reconcile.Request) {
+	certificate := newCertManagerCertificate()
+	err := k8sClient.Get(ctx, client.ObjectKey{
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetAnnotations()[certManagerCertificateNameAnnotation],
+	}, certificate)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "failed to map cert-manager Secret to its Certificate", "secret", obj.GetName())
+		}
+		return
+	}
+
+	for _, ownerRef := range certificate.GetOwnerReferences() {
+		if ownerRef.Kind == ownerKind {
+			recs = append(recs, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: certificate.GetNamespace(),
+					Name:      ownerRef.Name,
+				},
+			})
+		}
+	}
+	return
+}
@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/chever-john/apisix-operator/internal/consts"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+	k8sresources "github.com/chever-john/apisix-operator/internal/utils/kubernetes/resources"
+)
+
+// -----------------------------------------------------------------------------
+// CertificateSigningRequest - Certificate Issuer
+// -----------------------------------------------------------------------------
+
+// csrCertificateIssuer is a certificateIssuer submitting CertificateSigningRequests
+// to the API server for the operator signer, which are then approved and signed
+// with the cluster CA by the CertificateSigningRequestReconciler. Unlike the
+// in-memory signing, this leaves an audit trail of every issued certificate.
+//
+// The private key is generated once and stored in the owner's Secret before the
+// first request is submitted, and it's reused to renew the certificate, so that
+// the Secret always holds a matching key pair.
+type csrCertificateIssuer struct {
+	caSecretName      string
+	caSecretNamespace string
+	lifetime          time.Duration
+	renewBefore       time.Duration
+}
+
+// ensureCertificateSecret ensures the Secret owned by owner holds a certificate
+// for subject issued through a CertificateSigningRequest. A new request is
+// submitted when the certificate is missing, due for renewal, not signed by the
+// current CA or issued for a different subject, and the certificate it was
+// issued is copied to the Secret.
+func (i *csrCertificateIssuer) ensureCertificateSecret(ctx context.Context,
+	owner client.Object,
+	subject string,
+	usages []certificatesv1.KeyUsage,
+	k8sClient client.Client,
+) (bool, *corev1.Secret, error) {
+	logger := log.FromContext(ctx)
+
+	selectorKey, selectorValue := getManagedLabelForOwner(owner)
+	secrets, err := k8sutils.ListSecretsForOwner(
+		ctx,
+		k8sClient,
+		selectorKey,
+		selectorValue,
+		owner.GetUID(),
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	count := len(secrets)
+	if count > 1 {
+		return false, nil, fmt.Errorf("found %d mTLS secrets for DataPlane currently unsupported: expected 1 or less", count)
+	}
+
+	ownerPrefix := getPrefixForOwner(owner)
+	generatedSecret := k8sresources.GenerateNewTLSSecret(owner.GetNamespace(), owner.GetName(), ownerPrefix)
+	k8sutils.SetOwnerForObject(generatedSecret, owner)
+	addLabelForOwner(generatedSecret, owner)
+
+	if count == 0 {
+		key, err := generatePrivateKeyPEM()
+		if err != nil {
+			return false, nil, err
+		}
+		// TLS Secrets are required to hold both the certificate and the key, the
+		// certificate is set once it is issued.
+		generatedSecret.Data = map[string][]byte{
+			"ca.crt":  {},
+			"tls.crt": {},
+			"tls.key": key,
+		}
+		return true, nil, k8sClient.Create(ctx, generatedSecret)
+	}
+
+	var updated bool
+	existingSecret := &secrets[0]
+	updated, existingSecret.ObjectMeta = k8sutils.EnsureObjectMetaIsUpdated(existingSecret.ObjectMeta, generatedSecret.ObjectMeta)
+	if updated {
+		return true, issuedCertificateSecret(existingSecret), k8sClient.Update(ctx, existingSecret)
+	}
+
+	ca := &corev1.Secret{}
+	err = k8sClient.Get(ctx, client.ObjectKey{Namespace: i.caSecretNamespace, Name: i.caSecretName}, ca)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if issuedCertificateSecret(existingSecret) != nil {
+		renew, reason := certificateNeedsRenewal(existingSecret, ca, subject, i.renewBefore, time.Now())
+		if !renew {
+			return false, existingSecret, nil
+		}
+		debug(logger, "renewing mTLS certificate", existingSecret, "reason", reason)
+	}
+
+	if csrName := existingSecret.Annotations[consts.CertificateSigningRequestAnnotation]; csrName != "" {
+		csr := &certificatesv1.CertificateSigningRequest{}
+		err := k8sClient.Get(ctx, client.ObjectKey{Name: csrName}, csr)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return false, nil, err
+		}
+
+		// a request which has been deleted (e.g. garbage collected) is submitted again.
+		if err == nil {
+			// a denied or failed request is kept referenced rather than submitted again,
+			// which would most likely fail the same way. A new request is submitted once
+			// it's deleted, either manually or by the API server after an hour.
+			if condition, failed := certificateSigningRequestFailure(csr); failed {
+				return false, nil, fmt.Errorf("%w: CertificateSigningRequest %s for Secret %s/%s was %s: %s: %s",
+					operatorerrors.ErrCertificateNotIssued, csr.Name, existingSecret.Namespace, existingSecret.Name,
+					strings.ToLower(string(condition.Type)), condition.Reason, condition.Message)
+			}
+
+			if len(csr.Status.Certificate) == 0 {
+				debug(logger, "waiting for CertificateSigningRequest to be issued", existingSecret, "csr", csr.Name)
+				return false, issuedCertificateSecret(existingSecret), nil
+			}
+
+			debug(logger, "storing mTLS certificate issued for CertificateSigningRequest", existingSecret, "csr", csr.Name)
+			if existingSecret.Data == nil {
+				existingSecret.Data = make(map[string][]byte)
+			}
+			existingSecret.Data["tls.crt"] = csr.Status.Certificate
+			existingSecret.Data["ca.crt"] = ca.Data["tls.crt"]
+			delete(existingSecret.Annotations, consts.CertificateSigningRequestAnnotation)
+			return true, existingSecret, k8sClient.Update(ctx, existingSecret)
+		}
+	}
+
+	priv, err := parsePrivateKeyPEM(existingSecret.Data["tls.key"])
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid private key in Secret %s/%s: %w", existingSecret.Namespace, existingSecret.Name, err)
+	}
+	csr, err := generateCertificateSigningRequest(owner, subject, usages, i.lifetime, priv)
+	if err != nil {
+		return false, nil, err
+	}
+	// CertificateSigningRequests are cluster scoped, hence they can't be owned by
+	// the namespaced owner. They are rather tracked through annotations, and
+	// garbage collected by the API server once issued.
+	csr.ObjectMeta.Namespace = ""
+	csr.ObjectMeta.Name = ""
+	csr.ObjectMeta.GenerateName = fmt.Sprintf("%s-%s-%s-", ownerPrefix, owner.GetNamespace(), owner.GetName())
+	addLabelForOwner(csr, owner)
+	csr.ObjectMeta.Annotations = map[string]string{
+		consts.CertificateOwnerAnnotation:  client.ObjectKeyFromObject(owner).String(),
+		consts.CertificateSecretAnnotation: client.ObjectKeyFromObject(existingSecret).String(),
+	}
+	if err := k8sClient.Create(ctx, csr); err != nil {
+		return false, nil, err
+	}
+	debug(logger, "submitted CertificateSigningRequest", existingSecret, "csr", csr.Name)
+
+	if existingSecret.Annotations == nil {
+		existingSecret.Annotations = make(map[string]string)
+	}
+	existingSecret.Annotations[consts.CertificateSigningRequestAnnotation] = csr.Name
+	return true, issuedCertificateSecret(existingSecret), k8sClient.Update(ctx, existingSecret)
+}
+
+// issuedCertificateSecret returns the provided Secret if it holds a certificate,
+// nil otherwise.
+func issuedCertificateSecret(secret *corev1.Secret) *corev1.Secret {
+	if len(secret.Data["tls.crt"]) == 0 {
+		return nil
+	}
+	return secret
+}
+
+// certificateSigningRequestFailure returns the condition indicating the provided
+// CertificateSigningRequest was denied or failed, if any.
+func certificateSigningRequestFailure(csr *certificatesv1.CertificateSigningRequest) (certificatesv1.CertificateSigningRequestCondition, bool) {
+	for _, condition := range csr.Status.Conditions {
+		if (condition.Type == certificatesv1.CertificateDenied || condition.Type == certificatesv1.CertificateFailed) &&
+			condition.Status == corev1.ConditionTrue {
+			return condition, true
+		}
+	}
+	return certificatesv1.CertificateSigningRequestCondition{}, false
+}
+
+// parseNamespacedName parses a namespace/name string as set in the annotations
+// of the CertificateSigningRequests submitted by the operator.
+func parseNamespacedName(value string) (types.NamespacedName, bool) {
+	namespace, name, found := strings.Cut(value, "/")
+	if !found || namespace == "" || name == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}, true
+}
+
+// certificateSigningRequestOwnerRequests returns the reconciliation request for
+// the owner of the provided CertificateSigningRequest, if it was submitted by
+// the operator for an owner managed with the provided label value.
+func certificateSigningRequestOwnerRequests(ctx context.Context, obj client.Object, managedLabelValue string) []reconcile.Request {
+	csr, ok := obj.(*certificatesv1.CertificateSigningRequest)
+	if !ok {
+		log.FromContext(ctx).Error(
+			operatorerrors.ErrUnexpectedObject,
+			"failed to run map funcs",
+			"expected", "CertificateSigningRequest", "found", reflect.TypeOf(obj),
+		)
+		return nil
+	}
+
+	if csr.Labels[consts.GatewayOperatorControlledLabel] != managedLabelValue {
+		return nil
+	}
+	owner, ok := parseNamespacedName(csr.Annotations[consts.CertificateOwnerAnnotation])
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: owner}}
+}
+
+// -----------------------------------------------------------------------------
+// CertificateSigningRequest - Private Keys
+// -----------------------------------------------------------------------------
+
+// generatePrivateKeyPEM returns a new PEM encoded ECDSA private key.
+func generatePrivateKeyPEM() ([]byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	privDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "EC PRIVATE KEY",
+		Bytes: privDer,
+	}), nil
+}
+
+// parsePrivateKeyPEM parses a PEM encoded ECDSA private key.
+func parsePrivateKeyPEM(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded private key found")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
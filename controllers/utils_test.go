@@ -0,0 +1,401 @@
+package controllers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+)
+
+// defaultingClient is a client defaulting the pod templates of the workloads
+// it creates and updates, dry run or not, like the API server does, for the
+// fields the tests rely on. The fake client doesn't default objects.
+type defaultingClient struct {
+	client.Client
+}
+
+func (c defaultingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	setTestPodTemplateDefaults(obj)
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c defaultingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	setTestPodTemplateDefaults(obj)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func setTestPodTemplateDefaults(obj client.Object) {
+	template := podTemplateForObject(obj)
+	if template == nil {
+		return
+	}
+	spec := &template.Spec
+	if spec.SchedulerName == "" {
+		spec.SchedulerName = corev1.DefaultSchedulerName
+	}
+	for i := range spec.Containers {
+		container := &spec.Containers[i]
+		if container.TerminationMessagePath == "" {
+			container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+		}
+		if spec.HostNetwork {
+			for j := range container.Ports {
+				if container.Ports[j].HostPort == 0 {
+					container.Ports[j].HostPort = container.Ports[j].ContainerPort
+				}
+			}
+		}
+	}
+}
+
+func newTestCASecret(t *testing.T, name string) *corev1.Secret {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+	privDer, err := x509.MarshalECPrivateKey(priv)
+	require.NoError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apisix-operator-system", Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			"tls.crt": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			"tls.key": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privDer}),
+		},
+	}
+}
+
+func TestCertificateNeedsRenewal(t *testing.T) {
+	ca := newTestCASecret(t, "ca")
+	otherCA := newTestCASecret(t, "other-ca")
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+	}
+	usages := []certificatesv1.KeyUsage{certificatesv1.UsageServerAuth}
+
+	data, err := issueCertificate(dataplane, "test.default.svc", usages, 90*24*time.Hour, ca)
+	require.NoError(t, err)
+	secret := &corev1.Secret{Data: data}
+
+	for _, tt := range []struct {
+		name        string
+		ca          *corev1.Secret
+		subject     string
+		renewBefore time.Duration
+		now         time.Time
+		expected    bool
+	}{
+		{
+			name:        "certificate is not due for renewal",
+			ca:          ca,
+			subject:     "test.default.svc",
+			renewBefore: 7 * 24 * time.Hour,
+			now:         time.Now(),
+			expected:    false,
+		},
+		{
+			name:        "certificate is due for renewal",
+			ca:          ca,
+			subject:     "test.default.svc",
+			renewBefore: 7 * 24 * time.Hour,
+			now:         time.Now().Add(85 * 24 * time.Hour),
+			expected:    true,
+		},
+		{
+			name:        "renewal is capped to a third of the certificate validity",
+			ca:          ca,
+			subject:     "test.default.svc",
+			renewBefore: 80 * 24 * time.Hour,
+			now:         time.Now().Add(24 * time.Hour),
+			expected:    false,
+		},
+		{
+			name:        "cluster CA changed",
+			ca:          otherCA,
+			subject:     "test.default.svc",
+			renewBefore: 7 * 24 * time.Hour,
+			now:         time.Now(),
+			expected:    true,
+		},
+		{
+			name:        "certificate subject changed",
+			ca:          ca,
+			subject:     "other.default.svc",
+			renewBefore: 7 * 24 * time.Hour,
+			now:         time.Now(),
+			expected:    true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			renew, _ := certificateNeedsRenewal(secret, tt.ca, tt.subject, tt.renewBefore, tt.now)
+			require.Equal(t, tt.expected, renew)
+		})
+	}
+}
+
+func TestMaybeCreateCertificateSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	ca := newTestCASecret(t, "ca")
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+	}
+	usages := []certificatesv1.KeyUsage{certificatesv1.UsageServerAuth}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(ca, dataplane).Build()
+	ctx := context.Background()
+
+	ensure := func(lifetime, renewBefore time.Duration) (bool, *corev1.Secret) {
+		issuer := newCertificateIssuer(nil, false, ca.Name, ca.Namespace, lifetime, renewBefore)
+		createdOrUpdated, secret, err := maybeCreateCertificateSecret(ctx, dataplane, "test.default.svc", usages, issuer, c)
+		require.NoError(t, err)
+		return createdOrUpdated, secret
+	}
+
+	t.Log("issuing a certificate with the configured lifetime")
+	createdOrUpdated, secret := ensure(24*time.Hour, time.Hour)
+	require.True(t, createdOrUpdated)
+	cert, err := parseCertificateSecret(secret)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(24*time.Hour), cert.NotAfter, 10*time.Minute)
+	hash := certificateHash(secret)
+
+	t.Log("keeping the certificate until it is due for renewal")
+	createdOrUpdated, secret = ensure(24*time.Hour, time.Hour)
+	require.False(t, createdOrUpdated)
+	require.Equal(t, hash, certificateHash(secret))
+	requeueAfter := certificateRenewalRequeueAfter(secret, time.Hour)
+	require.InDelta(t, (23 * time.Hour).Seconds(), requeueAfter.Seconds(), (10 * time.Minute).Seconds())
+
+	t.Log("re-issuing the certificate when the cluster CA changes")
+	newCA := newTestCASecret(t, "ca")
+	ca.Data = newCA.Data
+	require.NoError(t, c.Update(ctx, ca))
+	createdOrUpdated, secret = ensure(24*time.Hour, time.Hour)
+	require.True(t, createdOrUpdated)
+	require.NotEqual(t, hash, certificateHash(secret))
+	require.Equal(t, newCA.Data["tls.crt"], secret.Data["ca.crt"])
+
+	secrets := &corev1.SecretList{}
+	require.NoError(t, c.List(ctx, secrets, client.InNamespace("default")))
+	require.Len(t, secrets.Items, 1)
+}
+
+func TestMaybeCreateCertificateSecretWithCertManager(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apisixoperatorv1alpha1.SchemeGroupVersion.String(), Kind: "DataPlane"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+	}
+	usages := []certificatesv1.KeyUsage{certificatesv1.UsageServerAuth}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(dataplane).Build()
+	ctx := context.Background()
+
+	issuer := newCertificateIssuer(&CertManagerIssuerRef{
+		Name:  "test-issuer",
+		Kind:  CertManagerClusterIssuerKind,
+		Group: CertManagerGroup,
+	}, false, "", "", 24*time.Hour, time.Hour)
+	ensure := func() (bool, *corev1.Secret) {
+		createdOrUpdated, secret, err := maybeCreateCertificateSecret(ctx, dataplane, "test.default.svc", usages, issuer, c)
+		require.NoError(t, err)
+		return createdOrUpdated, secret
+	}
+
+	t.Log("creating a cert-manager Certificate referencing the issuer")
+	createdOrUpdated, secret := ensure()
+	require.True(t, createdOrUpdated)
+	require.Nil(t, secret)
+
+	certificate := newCertManagerCertificate()
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "dataplane-test-mtls"}, certificate))
+	require.Len(t, certificate.GetOwnerReferences(), 1)
+	require.Equal(t, dataplane.UID, certificate.GetOwnerReferences()[0].UID)
+	issuerName, _, _ := unstructured.NestedString(certificate.Object, "spec", "issuerRef", "name")
+	require.Equal(t, "test-issuer", issuerName)
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	require.Equal(t, []string{"test.default.svc"}, dnsNames)
+	duration, _, _ := unstructured.NestedString(certificate.Object, "spec", "duration")
+	require.Equal(t, "24h0m0s", duration)
+
+	t.Log("waiting for cert-manager to issue the certificate")
+	createdOrUpdated, secret = ensure()
+	require.True(t, createdOrUpdated)
+	require.Nil(t, secret)
+
+	t.Log("using the Secret issued by cert-manager without taking ownership of it")
+	ca := newTestCASecret(t, "ca")
+	data, err := issueCertificate(dataplane, "test.default.svc", usages, 24*time.Hour, ca)
+	require.NoError(t, err)
+	require.NoError(t, c.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "dataplane-test-mtls",
+			Annotations: map[string]string{certManagerCertificateNameAnnotation: "dataplane-test-mtls"},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: data,
+	}))
+	createdOrUpdated, secret = ensure()
+	require.False(t, createdOrUpdated)
+	require.NotNil(t, secret)
+	require.Equal(t, data["tls.crt"], secret.Data["tls.crt"])
+	require.Empty(t, secret.GetOwnerReferences())
+	require.Empty(t, secret.GetLabels())
+
+	t.Log("tracking the Secret issued by cert-manager through its Certificate")
+	require.True(t, isCertManagerSecret(secret))
+	require.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test"}}},
+		certManagerSecretOwnerRequests(ctx, c, secret, "DataPlane"))
+	require.Empty(t, certManagerSecretOwnerRequests(ctx, c, secret, "ControlPlane"))
+}
+
+func TestEnsurePodDisruptionBudgetForOwner(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+
+	controlplane := &apisixoperatorv1alpha1.ControlPlane{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apisixoperatorv1alpha1.SchemeGroupVersion.String(), Kind: "ControlPlane"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "test-uid"},
+	}
+	c := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(controlplane).Build()
+	ctx := context.Background()
+
+	listPDBs := func() []policyv1.PodDisruptionBudget {
+		pdbs := &policyv1.PodDisruptionBudgetList{}
+		require.NoError(t, c.List(ctx, pdbs, client.InNamespace("default")))
+		return pdbs.Items
+	}
+
+	minAvailable := intstr.FromInt(1)
+	changed, err := ensurePodDisruptionBudgetForOwner(ctx, c, controlplane,
+		&apisixoperatorv1alpha1.PodDisruptionBudget{MinAvailable: &minAvailable})
+	require.NoError(t, err)
+	require.True(t, changed)
+	pdbs := listPDBs()
+	require.Len(t, pdbs, 1)
+	require.Equal(t, map[string]string{"app": "test"}, pdbs[0].Spec.Selector.MatchLabels)
+	require.Equal(t, &minAvailable, pdbs[0].Spec.MinAvailable)
+	require.Equal(t, consts.ControlPlaneManagedLabelValue, pdbs[0].Labels[consts.GatewayOperatorControlledLabel])
+
+	changed, err = ensurePodDisruptionBudgetForOwner(ctx, c, controlplane,
+		&apisixoperatorv1alpha1.PodDisruptionBudget{MinAvailable: &minAvailable})
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	t.Log("following the options of the owner")
+	maxUnavailable := intstr.FromString("50%")
+	changed, err = ensurePodDisruptionBudgetForOwner(ctx, c, controlplane,
+		&apisixoperatorv1alpha1.PodDisruptionBudget{MaxUnavailable: &maxUnavailable})
+	require.NoError(t, err)
+	require.True(t, changed)
+	pdbs = listPDBs()
+	require.Nil(t, pdbs[0].Spec.MinAvailable)
+	require.Equal(t, &maxUnavailable, pdbs[0].Spec.MaxUnavailable)
+
+	t.Log("deleting the PodDisruptionBudget once the owner no longer asks for it")
+	changed, err = ensurePodDisruptionBudgetForOwner(ctx, c, controlplane, nil)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Empty(t, listPDBs())
+}
+
+func TestEnsurePodTemplateIsUpdated(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	ctx := context.Background()
+
+	generated := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"app": "test"},
+			Annotations: map[string]string{
+				"example.com/kept":                      "true",
+				consts.LastAppliedAnnotationsAnnotation: "example.com/kept",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "test", Image: "example/test:1.0.0"}},
+		},
+	}
+	existing := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"},
+		Spec:       appsv1.DeploymentSpec{Template: *generated.DeepCopy()},
+	}
+	// the existing template holds the fields defaulted by the API server.
+	existing.Spec.Template.Spec.SchedulerName = corev1.DefaultSchedulerName
+	existing.Spec.Template.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+	c := defaultingClient{fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()}
+
+	t.Log("ignoring the fields defaulted by the API server")
+	updated, err := ensurePodTemplateIsUpdated(ctx, c, existing, generated)
+	require.NoError(t, err)
+	require.False(t, updated)
+	require.Equal(t, corev1.DefaultSchedulerName, existing.Spec.Template.Spec.SchedulerName)
+
+	t.Log("preserving the annotations set by others")
+	existing.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = "2022-10-01T00:00:00Z"
+	updated, err = ensurePodTemplateIsUpdated(ctx, c, existing, generated)
+	require.NoError(t, err)
+	require.False(t, updated)
+
+	t.Log("removing the annotations no longer set by the pod template overrides")
+	existing.Spec.Template.Annotations["example.com/removed"] = "true"
+	existing.Spec.Template.Annotations[consts.LastAppliedAnnotationsAnnotation] = "example.com/kept,example.com/removed"
+	updated, err = ensurePodTemplateIsUpdated(ctx, c, existing, generated)
+	require.NoError(t, err)
+	require.True(t, updated)
+	require.Equal(t, map[string]string{
+		"example.com/kept":                      "true",
+		consts.LastAppliedAnnotationsAnnotation: "example.com/kept",
+		"kubectl.kubernetes.io/restartedAt":     "2022-10-01T00:00:00Z",
+	}, existing.Spec.Template.Annotations)
+
+	t.Log("removing all the annotations once the pod template overrides are unset")
+	generated.Annotations = nil
+	updated, err = ensurePodTemplateIsUpdated(ctx, c, existing, generated)
+	require.NoError(t, err)
+	require.True(t, updated)
+	require.Equal(t, map[string]string{
+		"kubectl.kubernetes.io/restartedAt": "2022-10-01T00:00:00Z",
+	}, existing.Spec.Template.Annotations)
+}
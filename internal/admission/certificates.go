@@ -0,0 +1,516 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// -----------------------------------------------------------------------------
+// Admission Webhook - Certificates Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	// webhookCACommonName is the common name of the self-signed CA issuing the
+	// serving certificate of the admission webhook.
+	webhookCACommonName = "APISIX Operator Webhook CA"
+
+	// webhookCAValidity is the validity of the self-signed webhook CA.
+	webhookCAValidity = 10 * 365 * 24 * time.Hour
+
+	// webhookCertificateValidity is the validity of the serving certificate of
+	// the admission webhook.
+	webhookCertificateValidity = 365 * 24 * time.Hour
+
+	// webhookCertificateRenewBefore is the amount of time before their
+	// expiration at which the webhook CA and serving certificate are renewed.
+	webhookCertificateRenewBefore = 30 * 24 * time.Hour
+
+	// DefaultCertificateSyncPeriod is the default period at which the webhook
+	// certificates and configuration are checked.
+	DefaultCertificateSyncPeriod = 10 * time.Minute
+
+	// caCertKey and caPrivateKeyKey are the keys of the Secret holding the
+	// webhook CA, next to the serving certificate and key.
+	caCertKey       = "ca.crt"
+	caPrivateKeyKey = "ca.key"
+
+	// previousCACertKey is the key of the Secret holding the CA replaced by the
+	// last renewal of the webhook CA. It's kept in the caBundle until it
+	// expires, so that the API server keeps trusting the replicas of the
+	// operator which still serve a certificate it issued.
+	previousCACertKey = "ca.previous.crt"
+)
+
+// -----------------------------------------------------------------------------
+// Admission Webhook - Certificate Manager
+// -----------------------------------------------------------------------------
+
+// CertificateManager manages the serving certificate of the admission webhook
+// server, and the Validating and MutatingWebhookConfigurations through which
+// the API server sends it the validation and defaulting requests.
+//
+// A self-signed CA and the serving certificate it issues are stored in a Secret
+// shared by all the replicas of the operator, and renewed before they expire.
+// The serving certificate is written to the directory the webhook server loads
+// it from, and the CA is set as the caBundle of the webhook configurations,
+// along with the CA it replaced until that one expires.
+type CertificateManager struct {
+	Client client.Client
+	Logger logr.Logger
+
+	// SecretName and SecretNamespace locate the Secret holding the webhook CA
+	// and serving certificate.
+	SecretName      string
+	SecretNamespace string
+
+	// ServiceName, ServiceNamespace and ServicePort locate the Service the API
+	// server reaches the webhook server through.
+	ServiceName      string
+	ServiceNamespace string
+	ServicePort      int32
+
+	// ValidatingWebhookConfigurationName is the name of the managed
+	// ValidatingWebhookConfiguration, which isn't managed if empty.
+	ValidatingWebhookConfigurationName string
+
+	// MutatingWebhookConfigurationName is the name of the managed
+	// MutatingWebhookConfiguration, which isn't managed if empty.
+	MutatingWebhookConfigurationName string
+
+	// CertDir is the directory the webhook server loads tls.crt and tls.key from.
+	CertDir string
+
+	// SyncPeriod is the period at which the certificates and the
+	// configuration are checked once started, DefaultCertificateSyncPeriod if
+	// unset.
+	SyncPeriod time.Duration
+
+	// now returns the current time, it's overridden in tests.
+	now func() time.Time
+}
+
+// Ensure makes sure the webhook certificates are issued and valid, writes the
+// serving certificate to CertDir and points the webhook configurations to the
+// webhook server with the CA as caBundle. It has to be run before
+// starting the webhook server, which requires the certificate to be present.
+//
+// The replicas of the operator run it concurrently on startup: the steps
+// failing because another replica updated the same object in the meantime are
+// retried with a fresh copy of it.
+func (m *CertificateManager) Ensure(ctx context.Context) error {
+	var secret *corev1.Secret
+	if err := retry.OnError(retry.DefaultRetry, isConcurrentUpdateError, func() (err error) {
+		secret, err = m.ensureCertificateSecret(ctx)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to ensure webhook certificate secret %s/%s: %w", m.SecretNamespace, m.SecretName, err)
+	}
+	if err := m.writeCertificateFiles(secret); err != nil {
+		return fmt.Errorf("failed to write webhook certificate to %s: %w", m.CertDir, err)
+	}
+	caBundle := m.caBundle(secret)
+	if m.ValidatingWebhookConfigurationName != "" {
+		if err := retry.OnError(retry.DefaultRetry, isConcurrentUpdateError, func() error {
+			return m.ensureValidatingWebhookConfiguration(ctx, caBundle)
+		}); err != nil {
+			return fmt.Errorf("failed to ensure ValidatingWebhookConfiguration %s: %w", m.ValidatingWebhookConfigurationName, err)
+		}
+	}
+	if m.MutatingWebhookConfigurationName != "" {
+		if err := retry.OnError(retry.DefaultRetry, isConcurrentUpdateError, func() error {
+			return m.ensureMutatingWebhookConfiguration(ctx, caBundle)
+		}); err != nil {
+			return fmt.Errorf("failed to ensure MutatingWebhookConfiguration %s: %w", m.MutatingWebhookConfigurationName, err)
+		}
+	}
+	return nil
+}
+
+// Start periodically runs Ensure until ctx is done, so that the certificates are
+// renewed and changes to the webhook configurations are reverted.
+func (m *CertificateManager) Start(ctx context.Context) error {
+	syncPeriod := m.SyncPeriod
+	if syncPeriod <= 0 {
+		syncPeriod = DefaultCertificateSyncPeriod
+	}
+
+	ticker := time.NewTicker(syncPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := m.Ensure(ctx); err != nil {
+				m.Logger.Error(err, "failed to ensure admission webhook certificates")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements LeaderElectionRunnable: every replica serves
+// the webhook, hence has to keep its certificate up to date.
+func (m *CertificateManager) NeedLeaderElection() bool {
+	return false
+}
+
+// dnsNames returns the names the webhook Service is reachable at.
+func (m *CertificateManager) dnsNames() []string {
+	return []string{
+		m.ServiceName,
+		fmt.Sprintf("%s.%s", m.ServiceName, m.ServiceNamespace),
+		fmt.Sprintf("%s.%s.svc", m.ServiceName, m.ServiceNamespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", m.ServiceName, m.ServiceNamespace),
+	}
+}
+
+func (m *CertificateManager) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// ensureCertificateSecret returns the Secret holding the webhook CA and serving
+// certificate, after generating it if it's missing or renewing the certificates
+// it holds if needed.
+func (m *CertificateManager) ensureCertificateSecret(ctx context.Context) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := m.Client.Get(ctx, client.ObjectKey{Namespace: m.SecretNamespace, Name: m.SecretName}, secret)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		m.Logger.Info("webhook certificate secret not found, generating a self-signed CA and certificate",
+			"namespace", m.SecretNamespace, "name", m.SecretName)
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: m.SecretNamespace,
+				Name:      m.SecretName,
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: map[string][]byte{},
+		}
+		if err := m.issueCertificates(secret, true); err != nil {
+			return nil, err
+		}
+		if err := m.Client.Create(ctx, secret); err != nil {
+			// another replica of the operator may have created it in the meantime.
+			if k8serrors.IsAlreadyExists(err) {
+				return m.ensureCertificateSecret(ctx)
+			}
+			return nil, err
+		}
+		return secret, nil
+	}
+
+	renewCA, renewCertificate, reason := m.certificatesNeedRenewal(secret)
+	if !renewCA && !renewCertificate {
+		return secret, nil
+	}
+
+	m.Logger.Info("renewing webhook certificate", "namespace", m.SecretNamespace, "name", m.SecretName,
+		"reason", reason, "renewCA", renewCA)
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	if err := m.issueCertificates(secret, renewCA); err != nil {
+		return nil, err
+	}
+	return secret, m.Client.Update(ctx, secret)
+}
+
+// certificatesNeedRenewal returns whether the CA and the serving certificate
+// held by the provided Secret have to be renewed, along with the reason.
+func (m *CertificateManager) certificatesNeedRenewal(secret *corev1.Secret) (renewCA bool, renewCertificate bool, reason string) {
+	now := m.clock()
+
+	ca, _, err := parseKeyPair(secret.Data[caCertKey], secret.Data[caPrivateKeyKey])
+	if err != nil {
+		return true, true, fmt.Sprintf("invalid CA: %v", err)
+	}
+	if now.After(ca.NotAfter.Add(-webhookCertificateRenewBefore)) {
+		return true, true, "CA is due for renewal"
+	}
+
+	cert, _, err := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return false, true, fmt.Sprintf("invalid certificate: %v", err)
+	}
+	if err := cert.CheckSignatureFrom(ca); err != nil {
+		return false, true, "certificate is not signed by the CA"
+	}
+	if !reflect.DeepEqual(cert.DNSNames, m.dnsNames()) {
+		return false, true, "certificate DNS names changed"
+	}
+	if now.After(cert.NotAfter.Add(-webhookCertificateRenewBefore)) {
+		return false, true, "certificate is due for renewal"
+	}
+
+	return false, false, ""
+}
+
+// caBundle returns the CA bundle the API server has to trust to reach the
+// webhook server: the CA held by the provided Secret, followed by the CA it
+// replaced unless that one expired.
+func (m *CertificateManager) caBundle(secret *corev1.Secret) []byte {
+	caBundle := append([]byte{}, secret.Data[caCertKey]...)
+
+	previousPEM := secret.Data[previousCACertKey]
+	block, _ := pem.Decode(previousPEM)
+	if block == nil {
+		return caBundle
+	}
+	previous, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || m.clock().After(previous.NotAfter) {
+		return caBundle
+	}
+	return append(caBundle, previousPEM...)
+}
+
+// issueCertificates issues a new serving certificate into the provided Secret,
+// signed by a new self-signed CA if renewCA is set or by the CA it holds
+// otherwise. The replaced CA, if valid, is kept as the previous CA.
+func (m *CertificateManager) issueCertificates(secret *corev1.Secret, renewCA bool) error {
+	now := m.clock()
+
+	if renewCA {
+		if _, _, err := parseKeyPair(secret.Data[caCertKey], secret.Data[caPrivateKeyKey]); err == nil {
+			secret.Data[previousCACertKey] = secret.Data[caCertKey]
+		} else {
+			delete(secret.Data, previousCACertKey)
+		}
+
+		template := &x509.Certificate{
+			Subject: pkix.Name{
+				CommonName:   webhookCACommonName,
+				Organization: []string{"Apisix, Inc."},
+				Country:      []string{"US"},
+			},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.Add(webhookCAValidity),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		certPEM, keyPEM, err := generateCertificate(template, nil, nil)
+		if err != nil {
+			return err
+		}
+		secret.Data[caCertKey] = certPEM
+		secret.Data[caPrivateKeyKey] = keyPEM
+	}
+
+	ca, caKey, err := parseKeyPair(secret.Data[caCertKey], secret.Data[caPrivateKeyKey])
+	if err != nil {
+		return err
+	}
+
+	dnsNames := m.dnsNames()
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:   fmt.Sprintf("%s.%s.svc", m.ServiceName, m.ServiceNamespace),
+			Organization: []string{"Apisix, Inc."},
+			Country:      []string{"US"},
+		},
+		DNSNames:    dnsNames,
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(webhookCertificateValidity),
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certPEM, keyPEM, err := generateCertificate(template, ca, caKey)
+	if err != nil {
+		return err
+	}
+	secret.Data[corev1.TLSCertKey] = certPEM
+	secret.Data[corev1.TLSPrivateKeyKey] = keyPEM
+
+	return nil
+}
+
+// writeCertificateFiles writes the serving certificate and key held by the
+// provided Secret to CertDir, unless they are up to date. The webhook server
+// watches the files and reloads them when they change.
+func (m *CertificateManager) writeCertificateFiles(secret *corev1.Secret) error {
+	if err := os.MkdirAll(m.CertDir, 0o700); err != nil {
+		return err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		path := filepath.Join(m.CertDir, key)
+		existing, err := os.ReadFile(path)
+		if err == nil && bytes.Equal(existing, secret.Data[key]) {
+			continue
+		}
+
+		// the file is replaced atomically, so that it's never loaded half written.
+		tmp, err := os.CreateTemp(m.CertDir, "."+key)
+		if err != nil {
+			return err
+		}
+		_, err = tmp.Write(secret.Data[key])
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+			return err
+		}
+		m.Logger.Info("wrote webhook certificate file", "path", path)
+	}
+	return nil
+}
+
+// ensureValidatingWebhookConfiguration creates or updates the
+// ValidatingWebhookConfiguration sending the validation requests to the webhook
+// server, trusting the provided CA bundle.
+func (m *CertificateManager) ensureValidatingWebhookConfiguration(ctx context.Context, caBundle []byte) error {
+	generated := GenerateValidatingWebhookConfiguration(
+		m.ValidatingWebhookConfigurationName,
+		m.ServiceNamespace,
+		m.ServiceName,
+		m.ServicePort,
+		caBundle,
+	)
+
+	existing := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	err := m.Client.Get(ctx, client.ObjectKeyFromObject(generated), existing)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		m.Logger.Info("creating ValidatingWebhookConfiguration", "name", generated.Name)
+		return m.Client.Create(ctx, generated)
+	}
+
+	if equality.Semantic.DeepEqual(existing.Webhooks, generated.Webhooks) {
+		return nil
+	}
+	m.Logger.Info("updating ValidatingWebhookConfiguration", "name", generated.Name)
+	existing.Webhooks = generated.Webhooks
+	return m.Client.Update(ctx, existing)
+}
+
+// ensureMutatingWebhookConfiguration creates or updates the
+// MutatingWebhookConfiguration sending the defaulting requests to the webhook
+// server, trusting the provided CA bundle.
+func (m *CertificateManager) ensureMutatingWebhookConfiguration(ctx context.Context, caBundle []byte) error {
+	generated := GenerateMutatingWebhookConfiguration(
+		m.MutatingWebhookConfigurationName,
+		m.ServiceNamespace,
+		m.ServiceName,
+		m.ServicePort,
+		caBundle,
+	)
+
+	existing := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	err := m.Client.Get(ctx, client.ObjectKeyFromObject(generated), existing)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		m.Logger.Info("creating MutatingWebhookConfiguration", "name", generated.Name)
+		return m.Client.Create(ctx, generated)
+	}
+
+	if equality.Semantic.DeepEqual(existing.Webhooks, generated.Webhooks) {
+		return nil
+	}
+	m.Logger.Info("updating MutatingWebhookConfiguration", "name", generated.Name)
+	existing.Webhooks = generated.Webhooks
+	return m.Client.Update(ctx, existing)
+}
+
+// -----------------------------------------------------------------------------
+// Admission Webhook - Certificates Private Functions
+// -----------------------------------------------------------------------------
+
+// isConcurrentUpdateError returns true if the provided error was caused by
+// another replica of the operator creating or updating the same object.
+func isConcurrentUpdateError(err error) bool {
+	return k8serrors.IsConflict(err) || k8serrors.IsAlreadyExists(err)
+}
+
+// generateCertificate generates a new ECDSA key and the certificate for it
+// described by template, signed by parent with parentKey, or self-signed if
+// parent is nil. They are returned PEM encoded.
+func generateCertificate(template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) ([]byte, []byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+	template.SignatureAlgorithm = x509.ECDSAWithSHA256
+
+	if parent == nil {
+		parent, parentKey = template, priv
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &priv.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	privDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privDer}),
+		nil
+}
+
+// parseKeyPair parses the provided PEM encoded certificate and ECDSA private
+// key, and checks that they match.
+func parseKeyPair(certPEM, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, nil, fmt.Errorf("no PEM encoded private key found")
+	}
+	priv, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !priv.PublicKey.Equal(cert.PublicKey) {
+		return nil, nil, fmt.Errorf("private key does not match the certificate")
+	}
+
+	return cert, priv, nil
+}
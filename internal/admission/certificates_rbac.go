@@ -0,0 +1,9 @@
+package admission
+
+// -----------------------------------------------------------------------------
+// Admission Webhook - RBAC
+// -----------------------------------------------------------------------------
+
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=create;get;update
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=create;get;update
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=create;get;update
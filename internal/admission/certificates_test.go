@@ -0,0 +1,166 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCertificateManager_Ensure(t *testing.T) {
+	ctx := context.Background()
+	c := fakeclient.NewClientBuilder().Build()
+	now := time.Now()
+	m := &CertificateManager{
+		Client:                             c,
+		Logger:                             logr.Discard(),
+		SecretName:                         "webhook-certs",
+		SecretNamespace:                    "apisix-operator-system",
+		ServiceName:                        "webhook-service",
+		ServiceNamespace:                   "apisix-operator-system",
+		ServicePort:                        443,
+		ValidatingWebhookConfigurationName: "validating-webhook-configuration",
+		MutatingWebhookConfigurationName:   "mutating-webhook-configuration",
+		CertDir:                            filepath.Join(t.TempDir(), "certs"),
+		now:                                func() time.Time { return now },
+	}
+
+	ensure := func() (*corev1.Secret, *admissionregistrationv1.ValidatingWebhookConfiguration) {
+		require.NoError(t, m.Ensure(ctx))
+
+		secret := &corev1.Secret{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: m.SecretNamespace, Name: m.SecretName}, secret))
+		for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+			data, err := os.ReadFile(filepath.Join(m.CertDir, key))
+			require.NoError(t, err)
+			require.Equal(t, secret.Data[key], data)
+		}
+
+		configuration := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: m.ValidatingWebhookConfigurationName}, configuration))
+		require.Len(t, configuration.Webhooks, 1)
+		require.Equal(t, m.caBundle(secret), configuration.Webhooks[0].ClientConfig.CABundle)
+
+		mutatingConfiguration := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: m.MutatingWebhookConfigurationName}, mutatingConfiguration))
+		require.Len(t, mutatingConfiguration.Webhooks, 1)
+		require.Equal(t, m.caBundle(secret), mutatingConfiguration.Webhooks[0].ClientConfig.CABundle)
+		require.Equal(t, MutatingWebhookPath, *mutatingConfiguration.Webhooks[0].ClientConfig.Service.Path)
+		return secret, configuration
+	}
+
+	t.Log("generating the webhook certificates")
+	secret, configuration := ensure()
+	ca, _, err := parseKeyPair(secret.Data[caCertKey], secret.Data[caPrivateKeyKey])
+	require.NoError(t, err)
+	cert, _, err := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	require.NoError(t, err)
+	require.NoError(t, cert.CheckSignatureFrom(ca))
+	require.Contains(t, cert.DNSNames, "webhook-service.apisix-operator-system.svc")
+	require.Equal(t, secret.Data[caCertKey], configuration.Webhooks[0].ClientConfig.CABundle)
+	service := configuration.Webhooks[0].ClientConfig.Service
+	require.Equal(t, "webhook-service", service.Name)
+	require.Equal(t, ValidatingWebhookPath, *service.Path)
+
+	t.Log("keeping valid certificates")
+	kept, _ := ensure()
+	require.Equal(t, secret.Data, kept.Data)
+
+	t.Log("restoring the caBundle of the ValidatingWebhookConfiguration")
+	configuration.Webhooks[0].ClientConfig.CABundle = nil
+	require.NoError(t, c.Update(ctx, configuration))
+	ensure()
+
+	t.Log("renewing the serving certificate when it's due")
+	now = cert.NotAfter.Add(-webhookCertificateRenewBefore).Add(time.Hour)
+	renewed, _ := ensure()
+	require.Equal(t, secret.Data[caCertKey], renewed.Data[caCertKey])
+	require.NotEqual(t, secret.Data[corev1.TLSCertKey], renewed.Data[corev1.TLSCertKey])
+
+	t.Log("renewing the CA and the serving certificate when the CA is due")
+	previousCA := ca
+	now = ca.NotAfter.Add(-webhookCertificateRenewBefore).Add(time.Hour)
+	renewed, configuration = ensure()
+	require.NotEqual(t, secret.Data[caCertKey], renewed.Data[caCertKey])
+	cert, _, err = parseKeyPair(renewed.Data[corev1.TLSCertKey], renewed.Data[corev1.TLSPrivateKeyKey])
+	require.NoError(t, err)
+	ca, _, err = parseKeyPair(renewed.Data[caCertKey], renewed.Data[caPrivateKeyKey])
+	require.NoError(t, err)
+	require.NoError(t, cert.CheckSignatureFrom(ca))
+
+	t.Log("trusting the replaced CA along with the new one until it expires")
+	require.Equal(t, secret.Data[caCertKey], renewed.Data[previousCACertKey])
+	require.Equal(t, append(append([]byte{}, renewed.Data[caCertKey]...), secret.Data[caCertKey]...),
+		configuration.Webhooks[0].ClientConfig.CABundle)
+	now = previousCA.NotAfter.Add(time.Hour)
+	_, configuration = ensure()
+	require.Equal(t, renewed.Data[caCertKey], configuration.Webhooks[0].ClientConfig.CABundle)
+
+	t.Log("re-issuing the serving certificate when the Service changes")
+	m.ServiceName = "other-service"
+	reissued, configuration := ensure()
+	cert, _, err = parseKeyPair(reissued.Data[corev1.TLSCertKey], reissued.Data[corev1.TLSPrivateKeyKey])
+	require.NoError(t, err)
+	require.Contains(t, cert.DNSNames, "other-service.apisix-operator-system.svc")
+	require.Equal(t, "other-service", configuration.Webhooks[0].ClientConfig.Service.Name)
+}
+
+// conflictingClient fails the first update of each object with a conflict, as
+// if another replica of the operator updated it in the meantime.
+type conflictingClient struct {
+	client.Client
+	conflicts map[client.ObjectKey]bool
+}
+
+func (c *conflictingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	key := client.ObjectKeyFromObject(obj)
+	if !c.conflicts[key] {
+		c.conflicts[key] = true
+		return k8serrors.NewConflict(schema.GroupResource{}, obj.GetName(), fmt.Errorf("object was modified"))
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestCertificateManager_EnsureRetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	c := fakeclient.NewClientBuilder().Build()
+	now := time.Now()
+	m := &CertificateManager{
+		Client:                             c,
+		Logger:                             logr.Discard(),
+		SecretName:                         "webhook-certs",
+		SecretNamespace:                    "apisix-operator-system",
+		ServiceName:                        "webhook-service",
+		ServiceNamespace:                   "apisix-operator-system",
+		ServicePort:                        443,
+		ValidatingWebhookConfigurationName: "validating-webhook-configuration",
+		CertDir:                            filepath.Join(t.TempDir(), "certs"),
+		now:                                func() time.Time { return now },
+	}
+	require.NoError(t, m.Ensure(ctx))
+
+	t.Log("renewing the certificates while another replica updates them")
+	m.Client = &conflictingClient{Client: c, conflicts: map[client.ObjectKey]bool{}}
+	m.ServiceName = "other-service"
+	require.NoError(t, m.Ensure(ctx))
+
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: m.SecretNamespace, Name: m.SecretName}, secret))
+	cert, _, err := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	require.NoError(t, err)
+	require.Contains(t, cert.DNSNames, "other-service.apisix-operator-system.svc")
+	configuration := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: m.ValidatingWebhookConfigurationName}, configuration))
+	require.Equal(t, "other-service", configuration.Webhooks[0].ClientConfig.Service.Name)
+}
@@ -0,0 +1,174 @@
+package admission
+
+import (
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+)
+
+// -----------------------------------------------------------------------------
+// Admission Webhook - Configuration
+// -----------------------------------------------------------------------------
+
+// validatingWebhookName is the name of the webhook validating the operator
+// resources in the ValidatingWebhookConfiguration.
+const validatingWebhookName = "validations.apisix-operator.apisix.apache.org"
+
+// mutatingWebhookName is the name of the webhook defaulting the operator
+// resources in the MutatingWebhookConfiguration.
+const mutatingWebhookName = "defaults.apisix-operator.apisix.apache.org"
+
+// webhookTimeoutSeconds is the time the API server waits for the webhooks to
+// answer before applying the failure policy.
+const webhookTimeoutSeconds = 10
+
+// GenerateValidatingWebhookConfiguration returns the ValidatingWebhookConfiguration
+// sending the creations and updates of ControlPlanes, DataPlanes and
+// APISIXConfigurations, and the deletions of APISIXConfigurations, to the
+// webhook server served behind the provided Service, which is trusted through
+// caBundle.
+//
+// All the fields the API server would default are set explicitly, so that the
+// generated configuration can be compared with the existing one.
+func GenerateValidatingWebhookConfiguration(
+	name, serviceNamespace, serviceName string,
+	servicePort int32,
+	caBundle []byte,
+) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	var (
+		path           = ValidatingWebhookPath
+		failurePolicy  = admissionregistrationv1.Fail
+		matchPolicy    = admissionregistrationv1.Equivalent
+		sideEffects    = admissionregistrationv1.SideEffectClassNone
+		scope          = admissionregistrationv1.AllScopes
+		timeoutSeconds = int32(webhookTimeoutSeconds)
+	)
+
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{
+				Name: validatingWebhookName,
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{
+						Namespace: serviceNamespace,
+						Name:      serviceName,
+						Path:      &path,
+						Port:      &servicePort,
+					},
+					CABundle: caBundle,
+				},
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+							admissionregistrationv1.Update,
+						},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{apisixoperatorv1alpha1.SchemeGroupVersion.Group},
+							APIVersions: []string{apisixoperatorv1alpha1.SchemeGroupVersion.Version},
+							Resources: []string{
+								controlPlaneGVResource.Resource,
+								dataPlaneGVResource.Resource,
+							},
+							Scope: &scope,
+						},
+					},
+					{
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+							admissionregistrationv1.Update,
+							admissionregistrationv1.Delete,
+						},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{apisixoperatorv1alpha1.SchemeGroupVersion.Group},
+							APIVersions: []string{apisixoperatorv1alpha1.SchemeGroupVersion.Version},
+							Resources: []string{
+								apisixConfigurationGVResource.Resource,
+							},
+							Scope: &scope,
+						},
+					},
+				},
+				FailurePolicy:           &failurePolicy,
+				MatchPolicy:             &matchPolicy,
+				NamespaceSelector:       &metav1.LabelSelector{},
+				ObjectSelector:          &metav1.LabelSelector{},
+				SideEffects:             &sideEffects,
+				TimeoutSeconds:          &timeoutSeconds,
+				AdmissionReviewVersions: []string{"v1"},
+			},
+		},
+	}
+}
+
+// GenerateMutatingWebhookConfiguration returns the MutatingWebhookConfiguration
+// sending the creations and updates of ControlPlanes and DataPlanes to the
+// defaulting webhook served behind the provided Service, which is trusted
+// through caBundle.
+//
+// All the fields the API server would default are set explicitly, so that the
+// generated configuration can be compared with the existing one.
+func GenerateMutatingWebhookConfiguration(
+	name, serviceNamespace, serviceName string,
+	servicePort int32,
+	caBundle []byte,
+) *admissionregistrationv1.MutatingWebhookConfiguration {
+	var (
+		path               = MutatingWebhookPath
+		failurePolicy      = admissionregistrationv1.Fail
+		matchPolicy        = admissionregistrationv1.Equivalent
+		sideEffects        = admissionregistrationv1.SideEffectClassNone
+		scope              = admissionregistrationv1.AllScopes
+		timeoutSeconds     = int32(webhookTimeoutSeconds)
+		reinvocationPolicy = admissionregistrationv1.NeverReinvocationPolicy
+	)
+
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{
+				Name: mutatingWebhookName,
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{
+						Namespace: serviceNamespace,
+						Name:      serviceName,
+						Path:      &path,
+						Port:      &servicePort,
+					},
+					CABundle: caBundle,
+				},
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1.OperationType{
+							admissionregistrationv1.Create,
+							admissionregistrationv1.Update,
+						},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{apisixoperatorv1alpha1.SchemeGroupVersion.Group},
+							APIVersions: []string{apisixoperatorv1alpha1.SchemeGroupVersion.Version},
+							Resources: []string{
+								controlPlaneGVResource.Resource,
+								dataPlaneGVResource.Resource,
+							},
+							Scope: &scope,
+						},
+					},
+				},
+				FailurePolicy:           &failurePolicy,
+				MatchPolicy:             &matchPolicy,
+				NamespaceSelector:       &metav1.LabelSelector{},
+				ObjectSelector:          &metav1.LabelSelector{},
+				SideEffects:             &sideEffects,
+				TimeoutSeconds:          &timeoutSeconds,
+				AdmissionReviewVersions: []string{"v1"},
+				ReinvocationPolicy:      &reinvocationPolicy,
+			},
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	ctrladmission "sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	controlplaneutils "github.com/chever-john/apisix-operator/internal/utils/controlplane"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
+)
+
+// DefaultingRequestHandler handles the requests of defaulting objects: the
+// defaults of DataPlanes and ControlPlanes are set on admission, so that the
+// spec stored is the one the operator deploys.
+type DefaultingRequestHandler struct {
+	Logger logr.Logger
+}
+
+// NewDefaultingRequestHandler creates a DefaultingRequestHandler to handle
+// defaulting requests.
+func NewDefaultingRequestHandler(l logr.Logger) *DefaultingRequestHandler {
+	return &DefaultingRequestHandler{
+		Logger: l.WithValues("component", "defaulting-server"),
+	}
+}
+
+// ServeHTTP serves for HTTP requests.
+func (h *DefaultingRequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveAdmissionReview(w, r, h.Logger, h.handleDefaulting)
+}
+
+// handleDefaulting answers the provided request with the JSON patch setting the
+// defaults of the object being created or updated.
+func (h *DefaultingRequestHandler) handleDefaulting(_ context.Context, req *admissionv1.AdmissionRequest) (
+	*admissionv1.AdmissionResponse, error) {
+
+	if req == nil {
+		return emptyRequestResponse(), nil
+	}
+
+	var (
+		defaulted    interface{}
+		deserializer = codecs.UniversalDeserializer()
+	)
+
+	if req.Operation == admissionv1.Create || req.Operation == admissionv1.Update {
+		switch req.Resource {
+		case controlPlaneGVResource:
+			controlPlane := &apisixoperatorv1alpha1.ControlPlane{}
+			if _, _, err := deserializer.Decode(req.Object.Raw, nil, controlPlane); err != nil {
+				return nil, err
+			}
+			controlplaneutils.SetControlPlaneDefaults(&controlPlane.Spec.ControlPlaneDeploymentOptions)
+			defaulted = controlPlane
+		case dataPlaneGVResource:
+			dataPlane := &apisixoperatorv1alpha1.DataPlane{}
+			if _, _, err := deserializer.Decode(req.Object.Raw, nil, dataPlane); err != nil {
+				return nil, err
+			}
+			dataplaneutils.SetDataPlaneDefaults(&dataPlane.Spec.DataPlaneDeploymentOptions)
+			defaulted = dataPlane
+		}
+	}
+
+	response := ctrladmission.Allowed("")
+	if defaulted != nil {
+		raw, err := json.Marshal(defaulted)
+		if err != nil {
+			return nil, err
+		}
+		response = ctrladmission.PatchResponseFromRaw(req.Object.Raw, raw)
+		if !response.Allowed {
+			return nil, fmt.Errorf("failed to generate defaulting patch: %s", response.Result.Message)
+		}
+	}
+	if err := response.Complete(ctrladmission.Request{AdmissionRequest: *req}); err != nil {
+		return nil, err
+	}
+	return &response.AdmissionResponse, nil
+}
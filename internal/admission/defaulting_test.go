@@ -0,0 +1,169 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	controlplaneutils "github.com/chever-john/apisix-operator/internal/utils/controlplane"
+)
+
+func TestHandleDefaulting(t *testing.T) {
+	handler := NewDefaultingRequestHandler(logr.Discard())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	customImage := "registry.example/apisix"
+	customVersion := "3.0.0"
+	dataplaneName := "test-dataplane"
+
+	testCases := []struct {
+		name     string
+		resource metav1.GroupVersionResource
+		object   runtime.Object
+		// into is where the patched object is decoded.
+		into   runtime.Object
+		verify func(t *testing.T, obj runtime.Object)
+	}{
+		{
+			name:     "dataplane defaults",
+			resource: dataPlaneGVResource,
+			object: &apisixoperatorv1alpha1.DataPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-dataplane"},
+				Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+					DataPlaneDeploymentOptions: apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+						DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+							Env: []corev1.EnvVar{
+								{Name: "APISIX_NGINX_WORKER_PROCESSES", Value: "4"},
+							},
+						},
+					},
+				},
+			},
+			into: &apisixoperatorv1alpha1.DataPlane{},
+			verify: func(t *testing.T, obj runtime.Object) {
+				dataplane := obj.(*apisixoperatorv1alpha1.DataPlane)
+				require.Equal(t, consts.DefaultDataPlaneBaseImage, *dataplane.Spec.ContainerImage)
+				require.Equal(t, consts.DefaultDataPlaneTag, *dataplane.Spec.Version)
+				// the APISIX configuration is rendered into its configuration file, the
+				// environment is left untouched.
+				require.Equal(t, []corev1.EnvVar{{Name: "APISIX_NGINX_WORKER_PROCESSES", Value: "4"}}, dataplane.Spec.Env)
+			},
+		},
+		{
+			name:     "dataplane custom image",
+			resource: dataPlaneGVResource,
+			object: &apisixoperatorv1alpha1.DataPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-dataplane"},
+				Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+					DataPlaneDeploymentOptions: apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+						DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+							ContainerImage: &customImage,
+						},
+					},
+				},
+			},
+			into: &apisixoperatorv1alpha1.DataPlane{},
+			verify: func(t *testing.T, obj runtime.Object) {
+				dataplane := obj.(*apisixoperatorv1alpha1.DataPlane)
+				require.Equal(t, customImage, *dataplane.Spec.ContainerImage)
+				require.Nil(t, dataplane.Spec.Version)
+			},
+		},
+		{
+			name:     "controlplane defaults",
+			resource: controlPlaneGVResource,
+			object: &apisixoperatorv1alpha1.ControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-controlplane"},
+				Spec: apisixoperatorv1alpha1.ControlPlaneSpec{
+					ControlPlaneDeploymentOptions: apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+						DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+							Version: &customVersion,
+							Env: []corev1.EnvVar{
+								{Name: "CONTROLLER_LOG_LEVEL", Value: "debug"},
+								{Name: controlplaneutils.EnvVarPodName, Value: "test"},
+							},
+						},
+						DataPlane: &dataplaneName,
+					},
+				},
+			},
+			into: &apisixoperatorv1alpha1.ControlPlane{},
+			verify: func(t *testing.T, obj runtime.Object) {
+				controlplane := obj.(*apisixoperatorv1alpha1.ControlPlane)
+				require.Equal(t, consts.DefaultControlPlaneBaseImage, *controlplane.Spec.ContainerImage)
+				require.Equal(t, customVersion, *controlplane.Spec.Version)
+				require.Equal(t, dataplaneName, *controlplane.Spec.DataPlane)
+				managedEnv := make(map[string]corev1.EnvVar)
+				for _, envVar := range controlplaneutils.ManagedEnv("", "", "") {
+					managedEnv[envVar.Name] = envVar
+				}
+				require.Equal(t, []corev1.EnvVar{
+					{Name: "CONTROLLER_LOG_LEVEL", Value: "debug"},
+					managedEnv[controlplaneutils.EnvVarPodName],
+					managedEnv[controlplaneutils.EnvVarPodNamespace],
+					managedEnv[controlplaneutils.EnvVarApisixAdminTLSClientCertFile],
+					managedEnv[controlplaneutils.EnvVarApisixAdminTLSClientKeyFile],
+					managedEnv[controlplaneutils.EnvVarApisixAdminCACertFile],
+				}, controlplane.Spec.Env)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := json.Marshal(tc.object)
+			require.NoError(t, err)
+			review := &admissionv1.AdmissionReview{
+				Request: &admissionv1.AdmissionRequest{
+					UID:       "test-uid",
+					Resource:  tc.resource,
+					Operation: admissionv1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			}
+
+			buf, err := json.Marshal(review)
+			require.NoError(t, err)
+			resp, err := http.Post(server.URL, "application/json", bytes.NewReader(buf))
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			resp.Body.Close()
+			respReview := &admissionv1.AdmissionReview{}
+			require.NoError(t, json.Unmarshal(body, respReview))
+
+			response := respReview.Response
+			require.True(t, response.Allowed)
+			require.EqualValues(t, "test-uid", response.UID)
+
+			// objects which need no defaulting are admitted without a patch.
+			patched := raw
+			if len(response.Patch) > 0 {
+				require.NotNil(t, response.PatchType)
+				require.Equal(t, admissionv1.PatchTypeJSONPatch, *response.PatchType)
+
+				patch, err := jsonpatch.DecodePatch(response.Patch)
+				require.NoError(t, err)
+				patched, err = patch.Apply(raw)
+				require.NoError(t, err)
+			}
+			require.NoError(t, json.Unmarshal(patched, tc.into))
+			tc.verify(t, tc.into)
+		})
+	}
+}
@@ -25,18 +25,39 @@ var (
 	codecs = serializer.NewCodecFactory(scheme)
 )
 
-// NewWebhookServerFromManager creates a webhook server in manager.
-func NewWebhookServerFromManager(mgr ctrl.Manager, logger logr.Logger) *webhook.Server {
+// ValidatingWebhookPath is the path the validation requests are served on.
+const ValidatingWebhookPath = "/validate"
+
+// MutatingWebhookPath is the path the defaulting requests are served on.
+const MutatingWebhookPath = "/mutate"
+
+// NewWebhookServerFromManager creates a webhook server in manager, serving the
+// validation requests and, if enableDefaulting is set, the defaulting requests.
+func NewWebhookServerFromManager(mgr ctrl.Manager, logger logr.Logger, enableDefaulting bool) *webhook.Server {
 	hookServer := mgr.GetWebhookServer()
 	handler := NewRequestHandler(mgr.GetClient(), logger)
-	hookServer.Register("/validate", handler)
+	hookServer.Register(ValidatingWebhookPath, handler)
+	if enableDefaulting {
+		hookServer.Register(MutatingWebhookPath, NewDefaultingRequestHandler(logger))
+	}
 	return hookServer
 }
 
 // Validator is the interface of validating
 type Validator interface {
-	ValidateControlPlane(context.Context, apisixoperatorv1alpha1.ControlPlane) error
-	ValidateDataPlane(context.Context, apisixoperatorv1alpha1.DataPlane) error
+	// ValidateControlPlane validates a ControlPlane being created, or updated
+	// from the provided old ControlPlane.
+	ValidateControlPlane(context.Context, apisixoperatorv1alpha1.ControlPlane, *apisixoperatorv1alpha1.ControlPlane) error
+	// ValidateDataPlane validates a DataPlane being created, or updated from
+	// the provided old DataPlane.
+	ValidateDataPlane(context.Context, apisixoperatorv1alpha1.DataPlane, *apisixoperatorv1alpha1.DataPlane) error
+	// ValidateAPISIXConfiguration validates an APISIXConfiguration being
+	// created, or updated from the provided old APISIXConfiguration. The
+	// returned warnings are sent back to the user when the request is allowed.
+	ValidateAPISIXConfiguration(context.Context, apisixoperatorv1alpha1.APISIXConfiguration, *apisixoperatorv1alpha1.APISIXConfiguration) ([]string, error)
+	// ValidateAPISIXConfigurationDeletion validates the deletion of an
+	// APISIXConfiguration.
+	ValidateAPISIXConfigurationDeletion(context.Context, apisixoperatorv1alpha1.APISIXConfiguration) error
 }
 
 // RequestHandler handles the requests of validating objects.
@@ -51,6 +72,7 @@ type RequestHandler struct {
 func NewRequestHandler(c client.Client, l logr.Logger) *RequestHandler {
 	return &RequestHandler{
 		Validator: &validator{
+			client:             c,
 			dataplaneValidator: dataplane.NewValidator(c),
 		},
 		Logger: l.WithValues("component", "validation-server"),
@@ -59,46 +81,71 @@ func NewRequestHandler(c client.Client, l logr.Logger) *RequestHandler {
 
 // ServeHTTP serves for HTTP requests.
 func (h *RequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveAdmissionReview(w, r, h.Logger, h.handleValidation)
+}
+
+// serveAdmissionReview decodes the AdmissionReview sent in the provided
+// request, runs handle on it and writes back the AdmissionReview holding the
+// response.
+func serveAdmissionReview(
+	w http.ResponseWriter,
+	r *http.Request,
+	logger logr.Logger,
+	handle func(context.Context, *admissionv1.AdmissionRequest) (*admissionv1.AdmissionResponse, error),
+) {
 	if r.Body == nil {
-		h.Logger.Error(fmt.Errorf("empty body"), "received request with empty body")
+		logger.Error(fmt.Errorf("empty body"), "received request with empty body")
 		http.Error(w, "admission review object is missing", http.StatusBadRequest)
 		return
 	}
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
-		h.Logger.Error(err, "failed to read request from client")
+		logger.Error(err, "failed to read request from client")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	review := &admissionv1.AdmissionReview{}
 	if err := json.Unmarshal(data, review); err != nil {
-		h.Logger.Error(err, "failed to parse AdmissionReview object")
+		logger.Error(err, "failed to parse AdmissionReview object")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	response, err := h.handleValidation(r.Context(), review.Request)
+	response, err := handle(r.Context(), review.Request)
 	if err != nil {
-		h.Logger.Error(err, "failed to run validation")
+		logger.Error(err, "failed to handle admission request")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	review.Response = response
 	data, err = json.Marshal(review)
 	if err != nil {
-		h.Logger.Error(err, "failed to marshal response")
+		logger.Error(err, "failed to marshal response")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	_, err = w.Write(data)
 	if err != nil {
-		h.Logger.Error(err, "failed to write response")
+		logger.Error(err, "failed to write response")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
+// emptyRequestResponse is the response to AdmissionReviews without request.
+func emptyRequestResponse() *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Code:    http.StatusBadRequest,
+			Reason:  metav1.StatusReasonBadRequest,
+			Message: "empty request",
+			Status:  metav1.StatusFailure,
+		},
+	}
+}
+
 var (
 	controlPlaneGVResource = metav1.GroupVersionResource{
 		Group:    apisixoperatorv1alpha1.SchemeGroupVersion.Group,
@@ -110,27 +157,25 @@ var (
 		Version:  apisixoperatorv1alpha1.SchemeGroupVersion.Version,
 		Resource: "dataplanes",
 	}
+	apisixConfigurationGVResource = metav1.GroupVersionResource{
+		Group:    apisixoperatorv1alpha1.SchemeGroupVersion.Group,
+		Version:  apisixoperatorv1alpha1.SchemeGroupVersion.Version,
+		Resource: "apisixconfigurations",
+	}
 )
 
 func (h *RequestHandler) handleValidation(ctx context.Context, req *admissionv1.AdmissionRequest) (
 	*admissionv1.AdmissionResponse, error) {
 
 	if req == nil {
-		return &admissionv1.AdmissionResponse{
-			Allowed: false,
-			Result: &metav1.Status{
-				Code:    http.StatusBadRequest,
-				Reason:  metav1.StatusReasonBadRequest,
-				Message: "empty request",
-				Status:  metav1.StatusFailure,
-			},
-		}, nil
+		return emptyRequestResponse(), nil
 	}
 
 	var (
 		response     admissionv1.AdmissionResponse
 		ok           = true
 		msg          string
+		warnings     []string
 		deserializer = codecs.UniversalDeserializer()
 	)
 
@@ -142,7 +187,14 @@ func (h *RequestHandler) handleValidation(ctx context.Context, req *admissionv1.
 			if err != nil {
 				return nil, err
 			}
-			err = h.Validator.ValidateControlPlane(ctx, controlPlane)
+			var oldControlPlane *apisixoperatorv1alpha1.ControlPlane
+			if req.Operation == admissionv1.Update {
+				oldControlPlane = &apisixoperatorv1alpha1.ControlPlane{}
+				if _, _, err := deserializer.Decode(req.OldObject.Raw, nil, oldControlPlane); err != nil {
+					return nil, err
+				}
+			}
+			err = h.Validator.ValidateControlPlane(ctx, controlPlane, oldControlPlane)
 			if err != nil {
 				ok = false
 				msg = err.Error()
@@ -155,16 +207,57 @@ func (h *RequestHandler) handleValidation(ctx context.Context, req *admissionv1.
 			if err != nil {
 				return nil, err
 			}
-			err = h.Validator.ValidateDataPlane(ctx, dataPlane)
+			var oldDataPlane *apisixoperatorv1alpha1.DataPlane
+			if req.Operation == admissionv1.Update {
+				oldDataPlane = &apisixoperatorv1alpha1.DataPlane{}
+				if _, _, err := deserializer.Decode(req.OldObject.Raw, nil, oldDataPlane); err != nil {
+					return nil, err
+				}
+			}
+			err = h.Validator.ValidateDataPlane(ctx, dataPlane, oldDataPlane)
 			if err != nil {
 				ok = false
 				msg = err.Error()
 			}
 		}
+	case apisixConfigurationGVResource:
+		switch req.Operation {
+		case admissionv1.Create, admissionv1.Update:
+			apisixConfiguration := apisixoperatorv1alpha1.APISIXConfiguration{}
+			if _, _, err := deserializer.Decode(req.Object.Raw, nil, &apisixConfiguration); err != nil {
+				return nil, err
+			}
+			var oldAPISIXConfiguration *apisixoperatorv1alpha1.APISIXConfiguration
+			if req.Operation == admissionv1.Update {
+				oldAPISIXConfiguration = &apisixoperatorv1alpha1.APISIXConfiguration{}
+				if _, _, err := deserializer.Decode(req.OldObject.Raw, nil, oldAPISIXConfiguration); err != nil {
+					return nil, err
+				}
+			}
+			var err error
+			warnings, err = h.Validator.ValidateAPISIXConfiguration(ctx, apisixConfiguration, oldAPISIXConfiguration)
+			if err != nil {
+				ok = false
+				msg = err.Error()
+			}
+		case admissionv1.Delete:
+			// the object being deleted is only sent as the old object.
+			apisixConfiguration := apisixoperatorv1alpha1.APISIXConfiguration{}
+			if _, _, err := deserializer.Decode(req.OldObject.Raw, nil, &apisixConfiguration); err != nil {
+				return nil, err
+			}
+			if err := h.Validator.ValidateAPISIXConfigurationDeletion(ctx, apisixConfiguration); err != nil {
+				ok = false
+				msg = err.Error()
+			}
+		}
 	}
 
 	response.UID = req.UID
 	response.Allowed = ok
+	if ok {
+		response.Warnings = warnings
+	}
 
 	response.Result = &metav1.Status{
 		Message: msg,
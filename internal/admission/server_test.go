@@ -15,7 +15,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 	"github.com/chever-john/apisix-operator/internal/consts"
@@ -310,3 +312,97 @@ func TestHandleDataplaneValidation(t *testing.T) {
 	}
 
 }
+
+func TestHandleAPISIXConfigurationValidation(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(s))
+	require.NoError(t, gatewayv1alpha2.AddToScheme(s))
+
+	namespace := gatewayv1alpha2.Namespace("default")
+	c := fakeclient.NewClientBuilder().WithScheme(s).WithObjects(
+		&gatewayv1alpha2.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+			Spec: gatewayv1alpha2.GatewayClassSpec{
+				ControllerName: "apisix.apache.org/apisix-operator",
+				ParametersRef: &gatewayv1alpha2.ParametersReference{
+					Group:     gatewayv1alpha2.Group(apisixoperatorv1alpha1.SchemeGroupVersion.Group),
+					Kind:      "APISIXConfiguration",
+					Name:      "test-configuration",
+					Namespace: &namespace,
+				},
+			},
+		},
+	).Build()
+
+	handler := NewRequestHandler(c, logr.Discard())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	apisixConfiguration := &apisixoperatorv1alpha1.APISIXConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-configuration"},
+	}
+	updatedAPISIXConfiguration := apisixConfiguration.DeepCopy()
+	updatedAPISIXConfiguration.Spec.DataPlaneDeploymentOptions = &apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+		DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+			Env: []corev1.EnvVar{{Name: "APISIX_NGINX_WORKER_PROCESSES", Value: "4"}},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		operation admissionv1.Operation
+		object    runtime.Object
+		oldObject runtime.Object
+		allowed   bool
+		message   string
+		warnings  int
+	}{
+		{
+			name:      "update of a configuration in use",
+			operation: admissionv1.Update,
+			object:    updatedAPISIXConfiguration,
+			oldObject: apisixConfiguration,
+			allowed:   true,
+			warnings:  1,
+		},
+		{
+			name:      "deletion of a configuration in use",
+			operation: admissionv1.Delete,
+			oldObject: apisixConfiguration,
+			allowed:   false,
+			message:   "is used by GatewayClass test-gatewayclass and can't be deleted",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			review := &admissionv1.AdmissionReview{
+				Request: &admissionv1.AdmissionRequest{
+					Resource:  apisixConfigurationGVResource,
+					Namespace: apisixConfiguration.Namespace,
+					Name:      apisixConfiguration.Name,
+					Operation: tc.operation,
+					Object:    runtime.RawExtension{Object: tc.object},
+					OldObject: runtime.RawExtension{Object: tc.oldObject},
+				},
+			}
+
+			buf, err := json.Marshal(review)
+			require.NoError(t, err)
+			resp, err := http.Post(server.URL, "application/json", bytes.NewReader(buf))
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			resp.Body.Close()
+			respReview := &admissionv1.AdmissionReview{}
+			require.NoError(t, json.Unmarshal(body, respReview))
+
+			validationResp := respReview.Response
+			require.Equal(t, tc.allowed, validationResp.Allowed)
+			require.Contains(t, validationResp.Result.Message, tc.message)
+			require.Len(t, validationResp.Warnings, tc.warnings)
+		})
+	}
+}
@@ -2,19 +2,370 @@ package admission
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	controlplaneutils "github.com/chever-john/apisix-operator/internal/utils/controlplane"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+	k8sresources "github.com/chever-john/apisix-operator/internal/utils/kubernetes/resources"
 	dataplanevalidation "github.com/chever-john/apisix-operator/internal/validation/dataplane"
 )
 
 type validator struct {
+	client             client.Client
 	dataplaneValidator *dataplanevalidation.Validator
 }
 
-func (v *validator) ValidateControlPlane(ctx context.Context, controlPlane apisixoperatorv1alpha1.ControlPlane) error {
+// ValidateControlPlane validates the ControlPlane against the DataPlane it
+// references, the supported ingress controller versions and the environment
+// variables managed by the operator. oldControlPlane is the ControlPlane being
+// updated, nil on creation.
+//
+// On update, the DataPlane and the image are only validated if they changed,
+// so that the operator can still update ControlPlanes which became invalid,
+// e.g. after their DataPlane was deleted.
+func (v *validator) ValidateControlPlane(
+	ctx context.Context,
+	controlPlane apisixoperatorv1alpha1.ControlPlane,
+	oldControlPlane *apisixoperatorv1alpha1.ControlPlane,
+) error {
+	// the finalizers of a ControlPlane being deleted have to be removable.
+	if controlPlane.DeletionTimestamp != nil {
+		return nil
+	}
+
+	var (
+		specPath = field.NewPath("spec")
+		errs     field.ErrorList
+	)
+
+	dataplaneServiceName, dataplaneErrs := v.validateControlPlaneDataPlane(ctx, &controlPlane, specPath.Child("dataplane"))
+	if oldControlPlane == nil || !equality.Semantic.DeepEqual(controlPlane.Spec.DataPlane, oldControlPlane.Spec.DataPlane) {
+		errs = append(errs, dataplaneErrs...)
+	}
+	if oldControlPlane == nil ||
+		!equality.Semantic.DeepEqual(controlPlane.Spec.ContainerImage, oldControlPlane.Spec.ContainerImage) ||
+		!equality.Semantic.DeepEqual(controlPlane.Spec.Version, oldControlPlane.Spec.Version) {
+		errs = append(errs, validateControlPlaneImage(controlPlane.Spec.DeploymentOptions, specPath)...)
+	}
+	errs = append(errs, validateControlPlaneEnv(&controlPlane, oldControlPlane, dataplaneServiceName, specPath.Child("env"))...)
+
+	return errs.ToAggregate()
+}
+
+// ValidateDataPlane validates the DataPlane. oldDataPlane is the DataPlane
+// being updated, nil on creation.
+func (v *validator) ValidateDataPlane(
+	ctx context.Context,
+	dataPlane apisixoperatorv1alpha1.DataPlane,
+	oldDataPlane *apisixoperatorv1alpha1.DataPlane,
+) error {
+	if oldDataPlane == nil {
+		return v.dataplaneValidator.Validate(&dataPlane)
+	}
+	return v.dataplaneValidator.ValidateUpdate(&dataPlane, oldDataPlane)
+}
+
+// ValidateAPISIXConfiguration validates the DataPlane and ControlPlane options
+// of the APISIXConfiguration. oldAPISIXConfiguration is the APISIXConfiguration
+// being updated, nil on creation, in which case only the options which changed
+// are validated. Updating an APISIXConfiguration used by GatewayClasses is
+// allowed with a warning, as the changes are rolled out to all their Gateways.
+func (v *validator) ValidateAPISIXConfiguration(
+	ctx context.Context,
+	apisixConfiguration apisixoperatorv1alpha1.APISIXConfiguration,
+	oldAPISIXConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+) ([]string, error) {
+	if apisixConfiguration.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	var (
+		specPath = field.NewPath("spec")
+		errs     field.ErrorList
+	)
+
+	gatewayClasses, gateways, err := v.listAPISIXConfigurationConsumers(ctx, &apisixConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	dataplaneOpts := apisixConfiguration.Spec.DataPlaneDeploymentOptions
+	if oldAPISIXConfiguration == nil ||
+		!equality.Semantic.DeepEqual(dataplaneOpts, oldAPISIXConfiguration.Spec.DataPlaneDeploymentOptions) {
+		errs = append(errs, v.validateAPISIXConfigurationDataPlaneOptions(
+			&apisixConfiguration, gateways, specPath.Child("dataPlaneDeploymentOptions"))...)
+	}
+	controlplaneOpts := apisixConfiguration.Spec.ControlPlaneDeploymentOptions
+	if oldAPISIXConfiguration == nil ||
+		!equality.Semantic.DeepEqual(controlplaneOpts, oldAPISIXConfiguration.Spec.ControlPlaneDeploymentOptions) {
+		errs = append(errs, validateAPISIXConfigurationControlPlaneOptions(
+			&apisixConfiguration, specPath.Child("controlPlaneDeploymentOptions"))...)
+	}
+	if len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+
+	var warnings []string
+	if oldAPISIXConfiguration != nil && len(gatewayClasses) > 0 &&
+		!equality.Semantic.DeepEqual(apisixConfiguration.Spec, oldAPISIXConfiguration.Spec) {
+		warnings = append(warnings, fmt.Sprintf(
+			"APISIXConfiguration %s/%s is used by GatewayClass %s: the changes are rolled out to the DataPlanes and ControlPlanes of %d Gateway(s)",
+			apisixConfiguration.Namespace, apisixConfiguration.Name, strings.Join(gatewayClasses, ", "), len(gateways)))
+	}
+	return warnings, nil
+}
+
+// ValidateAPISIXConfigurationDeletion rejects the deletion of an
+// APISIXConfiguration used by GatewayClasses, which would leave them and their
+// Gateways without configuration.
+func (v *validator) ValidateAPISIXConfigurationDeletion(
+	ctx context.Context,
+	apisixConfiguration apisixoperatorv1alpha1.APISIXConfiguration,
+) error {
+	gatewayClasses, _, err := v.listAPISIXConfigurationConsumers(ctx, &apisixConfiguration)
+	if err != nil {
+		return err
+	}
+	if len(gatewayClasses) > 0 {
+		return fmt.Errorf("APISIXConfiguration %s/%s is used by GatewayClass %s and can't be deleted",
+			apisixConfiguration.Namespace, apisixConfiguration.Name, strings.Join(gatewayClasses, ", "))
+	}
+	return nil
+}
+
+// validateControlPlaneDataPlane checks that the DataPlane referenced by the
+// ControlPlane exists in its namespace and isn't used by another ControlPlane.
+// The name of the DataPlane Service is returned, if it has been created yet.
+func (v *validator) validateControlPlaneDataPlane(
+	ctx context.Context,
+	controlPlane *apisixoperatorv1alpha1.ControlPlane,
+	path *field.Path,
+) (string, field.ErrorList) {
+	if controlPlane.Spec.DataPlane == nil || *controlPlane.Spec.DataPlane == "" {
+		return "", nil
+	}
+	name := *controlPlane.Spec.DataPlane
+
+	if strings.Contains(name, "/") {
+		return "", field.ErrorList{field.Invalid(path, name,
+			fmt.Sprintf("cross-namespace references are not supported, the DataPlane must be in namespace %s", controlPlane.Namespace))}
+	}
+
+	dataplane, err := gatewayutils.GetDataPlaneForControlPlane(ctx, v.client, controlPlane)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", field.ErrorList{field.NotFound(path, name)}
+		}
+		return "", field.ErrorList{field.InternalError(path, err)}
+	}
+
+	controlPlanes := &apisixoperatorv1alpha1.ControlPlaneList{}
+	if err := v.client.List(ctx, controlPlanes, client.InNamespace(controlPlane.Namespace)); err != nil {
+		return "", field.ErrorList{field.InternalError(path, err)}
+	}
+	for _, other := range controlPlanes.Items {
+		if other.Name == controlPlane.Name || other.Spec.DataPlane == nil || *other.Spec.DataPlane != name {
+			continue
+		}
+		return "", field.ErrorList{field.Invalid(path, name,
+			fmt.Sprintf("the DataPlane is already used by ControlPlane %s", other.Name))}
+	}
+
+	// the Service is created along with the DataPlane, it's not known until then.
+	dataplaneServiceName, err := gatewayutils.GetDataplaneServiceName(ctx, v.client, dataplane)
+	if err != nil {
+		return "", nil
+	}
+	return dataplaneServiceName, nil
+}
+
+// validateControlPlaneImage checks that the ClusterRole of the ingress
+// controller version of the ControlPlane image, tagged with its version the way
+// the ControlPlane is deployed, can be generated. path is the path of the
+// deployment options.
+func validateControlPlaneImage(opts apisixoperatorv1alpha1.DeploymentOptions, path *field.Path) field.ErrorList {
+	if opts.ContainerImage == nil || *opts.ContainerImage == "" {
+		return nil
+	}
+	image := controlplaneutils.Image(opts)
+
+	if _, err := k8sresources.GenerateNewClusterRoleForControlPlane("", image); err != nil {
+		return field.ErrorList{field.Invalid(path.Child("containerImage"), *image, fmt.Sprintf("unsupported ingress controller version: %v", err))}
+	}
 	return nil
 }
 
-func (v *validator) ValidateDataPlane(ctx context.Context, dataPlane apisixoperatorv1alpha1.DataPlane) error {
-	return v.dataplaneValidator.Validate(&dataPlane)
+// validateControlPlaneEnv checks that the environment variables managed by the
+// operator aren't overridden. They are only accepted if they hold the values set
+// by the operator, or if they are left unchanged on update, since they are only
+// updated by the operator after the DataPlane has been changed.
+func validateControlPlaneEnv(
+	controlPlane *apisixoperatorv1alpha1.ControlPlane,
+	oldControlPlane *apisixoperatorv1alpha1.ControlPlane,
+	dataplaneServiceName string,
+	path *field.Path,
+) field.ErrorList {
+	var dataplaneName string
+	if controlPlane.Spec.DataPlane != nil {
+		dataplaneName = *controlPlane.Spec.DataPlane
+	}
+	managedEnv := make(map[string]corev1.EnvVar)
+	for _, envVar := range controlplaneutils.ManagedEnv(controlPlane.Namespace, dataplaneName, dataplaneServiceName) {
+		managedEnv[envVar.Name] = envVar
+	}
+
+	var errs field.ErrorList
+	for i, envVar := range controlPlane.Spec.Env {
+		if !controlplaneutils.IsManagedEnvVar(envVar.Name) {
+			continue
+		}
+		if expected, ok := managedEnv[envVar.Name]; ok && equality.Semantic.DeepEqual(envVar, expected) {
+			continue
+		}
+		if oldControlPlane != nil && containsEnvVar(oldControlPlane.Spec.Env, envVar) {
+			continue
+		}
+		errs = append(errs, field.Forbidden(path.Index(i),
+			fmt.Sprintf("%s is managed by the operator and can't be overridden", envVar.Name)))
+	}
+	return errs
+}
+
+func containsEnvVar(env []corev1.EnvVar, envVar corev1.EnvVar) bool {
+	for _, e := range env {
+		if equality.Semantic.DeepEqual(e, envVar) {
+			return true
+		}
+	}
+	return false
+}
+
+// listAPISIXConfigurationConsumers returns the names of the GatewayClasses
+// referencing the provided APISIXConfiguration through their parametersRef,
+// along with the Gateways of those GatewayClasses.
+func (v *validator) listAPISIXConfigurationConsumers(
+	ctx context.Context,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+) ([]string, []gatewayv1alpha2.Gateway, error) {
+	gatewayClassList := &gatewayv1alpha2.GatewayClassList{}
+	if err := v.client.List(ctx, gatewayClassList); err != nil {
+		return nil, nil, err
+	}
+	consumers := make(map[string]struct{})
+	var gatewayClasses []string
+	for i := range gatewayClassList.Items {
+		gatewayClass := &gatewayClassList.Items[i]
+		if gatewayutils.IsParametersRefForAPISIXConfiguration(gatewayClass.Spec.ParametersRef, apisixConfiguration) {
+			consumers[gatewayClass.Name] = struct{}{}
+			gatewayClasses = append(gatewayClasses, gatewayClass.Name)
+		}
+	}
+	if len(gatewayClasses) == 0 {
+		return nil, nil, nil
+	}
+	sort.Strings(gatewayClasses)
+
+	gatewayList := &gatewayv1alpha2.GatewayList{}
+	if err := v.client.List(ctx, gatewayList); err != nil {
+		return nil, nil, err
+	}
+	var gateways []gatewayv1alpha2.Gateway
+	for _, gateway := range gatewayList.Items {
+		if _, ok := consumers[string(gateway.Spec.GatewayClassName)]; ok {
+			gateways = append(gateways, gateway)
+		}
+	}
+	return gatewayClasses, gateways, nil
+}
+
+// validateAPISIXConfigurationDataPlaneOptions validates the DataPlane options
+// of the APISIXConfiguration: their deployment mode and scaling options the way
+// the ones of a DataPlane are, and their environment for each of the provided
+// Gateways, since the ConfigMaps and Secrets it references are resolved in the
+// namespace of the DataPlane of the Gateway. Until the configuration is used by
+// a Gateway, only the values set explicitly in the environment are validated.
+func (v *validator) validateAPISIXConfigurationDataPlaneOptions(
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	gateways []gatewayv1alpha2.Gateway,
+	path *field.Path,
+) field.ErrorList {
+	opts := apisixConfiguration.Spec.DataPlaneDeploymentOptions
+	if opts == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	if err := v.dataplaneValidator.ValidateDeploymentMode(opts); err != nil {
+		errs = append(errs, field.Invalid(path.Child("deploymentMode"), opts.DeploymentMode, err.Error()))
+	}
+	if err := v.dataplaneValidator.ValidateScaling(opts.Scaling); err != nil {
+		// the lower limit of the horizontal scaling is the only scaling option
+		// which can be invalid.
+		errs = append(errs, field.Invalid(path.Child("scaling", "horizontal", "minReplicas"),
+			*opts.Scaling.Horizontal.MinReplicas, err.Error()))
+	}
+
+	if len(gateways) == 0 {
+		explicitOpts := &apisixoperatorv1alpha1.DeploymentOptions{}
+		for _, envVar := range opts.Env {
+			if envVar.ValueFrom == nil {
+				explicitOpts.Env = append(explicitOpts.Env, envVar)
+			}
+		}
+		if err := v.dataplaneValidator.ValidateDeployOptions(apisixConfiguration.Namespace, explicitOpts); err != nil {
+			errs = append(errs, field.Invalid(path, opts.Env, err.Error()))
+		}
+		return errs
+	}
+
+	namespaces := make(map[string]struct{})
+	for _, gateway := range gateways {
+		if _, ok := namespaces[gateway.Namespace]; ok {
+			continue
+		}
+		namespaces[gateway.Namespace] = struct{}{}
+		if err := v.dataplaneValidator.ValidateDeployOptions(gateway.Namespace, &opts.DeploymentOptions); err != nil {
+			return append(errs, field.Invalid(path, opts.Env,
+				fmt.Sprintf("invalid for Gateway %s/%s: %s", gateway.Namespace, gateway.Name, err)))
+		}
+	}
+	return errs
+}
+
+// validateAPISIXConfigurationControlPlaneOptions validates the ControlPlane
+// options of the APISIXConfiguration: the ControlPlane of a Gateway always
+// manages the DataPlane of the Gateway, the image must be supported and the
+// environment variables managed by the operator can't be set.
+func validateAPISIXConfigurationControlPlaneOptions(
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+	path *field.Path,
+) field.ErrorList {
+	opts := apisixConfiguration.Spec.ControlPlaneDeploymentOptions
+	if opts == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	if opts.DataPlane != nil {
+		errs = append(errs, field.Forbidden(path.Child("dataplane"),
+			"the ControlPlane of a Gateway always manages the DataPlane of the Gateway"))
+	}
+	errs = append(errs, validateControlPlaneImage(opts.DeploymentOptions, path)...)
+	for i, envVar := range opts.Env {
+		if controlplaneutils.IsManagedEnvVar(envVar.Name) {
+			errs = append(errs, field.Forbidden(path.Child("env").Index(i),
+				fmt.Sprintf("%s is managed by the operator and can't be set", envVar.Name)))
+		}
+	}
+	return errs
 }
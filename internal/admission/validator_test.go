@@ -0,0 +1,380 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	controlplaneutils "github.com/chever-john/apisix-operator/internal/utils/controlplane"
+	gatewayutils "github.com/chever-john/apisix-operator/internal/utils/gateway"
+	dataplanevalidation "github.com/chever-john/apisix-operator/internal/validation/dataplane"
+)
+
+func TestValidateControlPlane(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(s))
+
+	dataplaneName := "test-dataplane"
+	usedDataplaneName := "used-dataplane"
+	missingDataplaneName := "missing"
+	otherNamespaceDataplaneName := "other/test-dataplane"
+	unsupportedImage := "apache/apisix-ingress-controller:1.5.0"
+	adminURL := controlplaneutils.ApisixAdminURL(dataplaneName, "default")
+
+	newControlPlane := func(dataplane *string, image *string, env ...corev1.EnvVar) *apisixoperatorv1alpha1.ControlPlane {
+		return &apisixoperatorv1alpha1.ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-controlplane"},
+			Spec: apisixoperatorv1alpha1.ControlPlaneSpec{
+				ControlPlaneDeploymentOptions: apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+					DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+						ContainerImage: image,
+						Env:            env,
+					},
+					DataPlane: dataplane,
+				},
+			},
+		}
+	}
+
+	withVersion := func(controlPlane *apisixoperatorv1alpha1.ControlPlane, version string) *apisixoperatorv1alpha1.ControlPlane {
+		controlPlane.Spec.Version = &version
+		return controlPlane
+	}
+	baseImage := consts.DefaultControlPlaneBaseImage
+
+	c := fakeclient.NewClientBuilder().WithScheme(s).WithObjects(
+		&apisixoperatorv1alpha1.DataPlane{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: dataplaneName, UID: "test-dataplane-uid"},
+		},
+		&apisixoperatorv1alpha1.DataPlane{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: usedDataplaneName},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "test-dataplane-svc",
+				Labels:    map[string]string{consts.GatewayOperatorControlledLabel: consts.DataPlaneManagedLabelValue},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: apisixoperatorv1alpha1.SchemeGroupVersion.String(),
+					Kind:       "DataPlane",
+					Name:       dataplaneName,
+					UID:        "test-dataplane-uid",
+				}},
+			},
+		},
+		&apisixoperatorv1alpha1.ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-controlplane"},
+			Spec: apisixoperatorv1alpha1.ControlPlaneSpec{
+				ControlPlaneDeploymentOptions: apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+					DataPlane: &usedDataplaneName,
+				},
+			},
+		},
+	).Build()
+	v := &validator{
+		client:             c,
+		dataplaneValidator: dataplanevalidation.NewValidator(c),
+	}
+
+	for _, tc := range []struct {
+		name            string
+		controlPlane    *apisixoperatorv1alpha1.ControlPlane
+		oldControlPlane *apisixoperatorv1alpha1.ControlPlane
+		errMsg          string
+	}{
+		{
+			name:         "no dataplane",
+			controlPlane: newControlPlane(nil, nil),
+		},
+		{
+			name: "env set by the operator",
+			controlPlane: newControlPlane(&dataplaneName, nil,
+				corev1.EnvVar{Name: controlplaneutils.EnvVarApisixAdminURL, Value: adminURL},
+				corev1.EnvVar{Name: "CONTROLLER_LOG_LEVEL", Value: "debug"},
+			),
+		},
+		{
+			name:         "dataplane not found",
+			controlPlane: newControlPlane(&missingDataplaneName, nil),
+			errMsg:       `spec.dataplane: Not found: "missing"`,
+		},
+		{
+			name:         "cross-namespace dataplane",
+			controlPlane: newControlPlane(&otherNamespaceDataplaneName, nil),
+			errMsg:       `spec.dataplane: Invalid value: "other/test-dataplane": cross-namespace references are not supported`,
+		},
+		{
+			name:         "dataplane used by another controlplane",
+			controlPlane: newControlPlane(&usedDataplaneName, nil),
+			errMsg:       `spec.dataplane: Invalid value: "used-dataplane": the DataPlane is already used by ControlPlane other-controlplane`,
+		},
+		{
+			name:         "unsupported image version",
+			controlPlane: newControlPlane(nil, &unsupportedImage),
+			errMsg:       `spec.containerImage: Invalid value: "apache/apisix-ingress-controller:1.5.0": unsupported ingress controller version`,
+		},
+		{
+			name:         "unsupported image version tag",
+			controlPlane: withVersion(newControlPlane(nil, &baseImage), "1.5.0"),
+			errMsg:       `spec.containerImage: Invalid value: "apache/apisix-ingress-controller:1.5.0": unsupported ingress controller version`,
+		},
+		{
+			name:         "supported image version tag",
+			controlPlane: withVersion(newControlPlane(nil, &baseImage), "2.5.0"),
+		},
+		{
+			name:            "unsupported image version tag on update",
+			controlPlane:    withVersion(newControlPlane(nil, &baseImage), "1.5.0"),
+			oldControlPlane: withVersion(newControlPlane(nil, &baseImage), "2.5.0"),
+			errMsg:          `spec.containerImage: Invalid value: "apache/apisix-ingress-controller:1.5.0": unsupported ingress controller version`,
+		},
+		{
+			name: "overridden admin url",
+			controlPlane: newControlPlane(&dataplaneName, nil,
+				corev1.EnvVar{Name: "CONTROLLER_LOG_LEVEL", Value: "debug"},
+				corev1.EnvVar{Name: controlplaneutils.EnvVarApisixAdminURL, Value: "https://apisix.example:9180"},
+			),
+			errMsg: "spec.env[1]: Forbidden: CONTROLLER_APISIX_ADMIN_URL is managed by the operator and can't be overridden",
+		},
+		{
+			name: "unchanged env and image on update",
+			controlPlane: newControlPlane(nil, &unsupportedImage,
+				corev1.EnvVar{Name: controlplaneutils.EnvVarApisixAdminURL, Value: adminURL},
+			),
+			oldControlPlane: newControlPlane(&dataplaneName, &unsupportedImage,
+				corev1.EnvVar{Name: controlplaneutils.EnvVarApisixAdminURL, Value: adminURL},
+			),
+		},
+		{
+			name: "overridden admin url on update",
+			controlPlane: newControlPlane(&dataplaneName, nil,
+				corev1.EnvVar{Name: controlplaneutils.EnvVarApisixAdminURL, Value: "https://apisix.example:9180"},
+			),
+			oldControlPlane: newControlPlane(&dataplaneName, nil,
+				corev1.EnvVar{Name: controlplaneutils.EnvVarApisixAdminURL, Value: adminURL},
+			),
+			errMsg: "spec.env[0]: Forbidden: CONTROLLER_APISIX_ADMIN_URL is managed by the operator and can't be overridden",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.ValidateControlPlane(context.Background(), *tc.controlPlane, tc.oldControlPlane)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
+
+func TestValidateAPISIXConfiguration(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(s))
+	require.NoError(t, gatewayv1alpha2.AddToScheme(s))
+
+	namespace := gatewayv1alpha2.Namespace("default")
+	parametersRef := func(name string) *gatewayv1alpha2.ParametersReference {
+		return &gatewayv1alpha2.ParametersReference{
+			Group:     gatewayv1alpha2.Group(apisixoperatorv1alpha1.SchemeGroupVersion.Group),
+			Kind:      gatewayv1alpha2.Kind(gatewayutils.APISIXConfigurationKind),
+			Name:      name,
+			Namespace: &namespace,
+		}
+	}
+
+	c := fakeclient.NewClientBuilder().WithScheme(s).WithObjects(
+		&gatewayv1alpha2.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+			Spec: gatewayv1alpha2.GatewayClassSpec{
+				ControllerName: "apisix.apache.org/apisix-operator",
+				ParametersRef:  parametersRef("used-configuration"),
+			},
+		},
+		&gatewayv1alpha2.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "gateways", Name: "test-gateway"},
+			Spec:       gatewayv1alpha2.GatewaySpec{GatewayClassName: "test-gatewayclass"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "gateways", Name: "apisix-env"},
+			Data:       map[string]string{consts.EnvVarApisixDatabase: "off"},
+		},
+	).Build()
+	v := &validator{
+		client:             c,
+		dataplaneValidator: dataplanevalidation.NewValidator(c),
+	}
+
+	unsupportedImage := "apache/apisix-ingress-controller:1.5.0"
+	baseImage := consts.DefaultControlPlaneBaseImage
+	unsupportedVersion := "1.5.0"
+	replicas := int32(3)
+	dataplaneName := "test-dataplane"
+	newAPISIXConfiguration := func(
+		name string,
+		dataplaneOpts *apisixoperatorv1alpha1.DataPlaneDeploymentOptions,
+		controlplaneOpts *apisixoperatorv1alpha1.ControlPlaneDeploymentOptions,
+	) *apisixoperatorv1alpha1.APISIXConfiguration {
+		return &apisixoperatorv1alpha1.APISIXConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: apisixoperatorv1alpha1.APISIXConfigurationSpec{
+				DataPlaneDeploymentOptions:    dataplaneOpts,
+				ControlPlaneDeploymentOptions: controlplaneOpts,
+			},
+		}
+	}
+	dataplaneEnv := func(env ...corev1.EnvVar) *apisixoperatorv1alpha1.DataPlaneDeploymentOptions {
+		return &apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+			DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{Env: env},
+		}
+	}
+	databaseFromConfigMap := corev1.EnvVar{
+		Name: consts.EnvVarApisixDatabase,
+		ValueFrom: &corev1.EnvVarSource{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "apisix-env"},
+				Key:                  consts.EnvVarApisixDatabase,
+			},
+		},
+	}
+	databaseFromMissingConfigMap := *databaseFromConfigMap.DeepCopy()
+	databaseFromMissingConfigMap.ValueFrom.ConfigMapKeyRef.Name = "missing"
+
+	for _, tc := range []struct {
+		name                   string
+		apisixConfiguration    *apisixoperatorv1alpha1.APISIXConfiguration
+		oldAPISIXConfiguration *apisixoperatorv1alpha1.APISIXConfiguration
+		errMsg                 string
+		warning                string
+	}{
+		{
+			name:                "empty configuration",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration", nil, nil),
+		},
+		{
+			name: "unsupported database",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration",
+				dataplaneEnv(corev1.EnvVar{Name: consts.EnvVarApisixDatabase, Value: "postgres"}), nil),
+			errMsg: "database backend postgres of dataplane not supported currently",
+		},
+		{
+			name: "references not resolved until used by a gateway",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration",
+				dataplaneEnv(databaseFromMissingConfigMap), nil),
+		},
+		{
+			name: "references resolved in the namespace of the gateways",
+			apisixConfiguration: newAPISIXConfiguration("used-configuration",
+				dataplaneEnv(databaseFromConfigMap), nil),
+		},
+		{
+			name: "missing reference in the namespace of a gateway",
+			apisixConfiguration: newAPISIXConfiguration("used-configuration",
+				dataplaneEnv(databaseFromMissingConfigMap), nil),
+			errMsg: "invalid for Gateway gateways/test-gateway",
+		},
+		{
+			name: "dataplane replicas set in daemonset deployment mode",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration",
+				&apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+					DeploymentMode: apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet,
+					Replicas:       &replicas,
+				}, nil),
+			errMsg: "spec.dataPlaneDeploymentOptions.deploymentMode: Invalid value: \"DaemonSet\": replicas of dataplane can't be set in DaemonSet deployment mode",
+		},
+		{
+			name: "dataplane min replicas exceeding its max replicas",
+			apisixConfiguration: newAPISIXConfiguration("used-configuration",
+				&apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+					Scaling: &apisixoperatorv1alpha1.DataPlaneScaling{
+						Horizontal: &apisixoperatorv1alpha1.HorizontalScaling{MinReplicas: &replicas, MaxReplicas: 2},
+					},
+				}, nil),
+			errMsg: "spec.dataPlaneDeploymentOptions.scaling.horizontal.minReplicas: Invalid value: 3: min replicas 3 of dataplane can't exceed its max replicas 2",
+		},
+		{
+			name: "controlplane dataplane set",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration", nil,
+				&apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{DataPlane: &dataplaneName}),
+			errMsg: "spec.controlPlaneDeploymentOptions.dataplane: Forbidden",
+		},
+		{
+			name: "controlplane unsupported image",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration", nil,
+				&apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+					DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{ContainerImage: &unsupportedImage},
+				}),
+			errMsg: "spec.controlPlaneDeploymentOptions.containerImage: Invalid value",
+		},
+		{
+			name: "controlplane unsupported image version tag",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration", nil,
+				&apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+					DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+						ContainerImage: &baseImage,
+						Version:        &unsupportedVersion,
+					},
+				}),
+			errMsg: `spec.controlPlaneDeploymentOptions.containerImage: Invalid value: "apache/apisix-ingress-controller:1.5.0"`,
+		},
+		{
+			name: "controlplane managed env",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration", nil,
+				&apisixoperatorv1alpha1.ControlPlaneDeploymentOptions{
+					DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+						Env: []corev1.EnvVar{{Name: controlplaneutils.EnvVarApisixAdminURL, Value: "https://apisix.example:9180"}},
+					},
+				}),
+			errMsg: "spec.controlPlaneDeploymentOptions.env[0]: Forbidden: CONTROLLER_APISIX_ADMIN_URL is managed by the operator and can't be set",
+		},
+		{
+			name: "update of a configuration in use",
+			apisixConfiguration: newAPISIXConfiguration("used-configuration",
+				dataplaneEnv(corev1.EnvVar{Name: "APISIX_NGINX_WORKER_PROCESSES", Value: "4"}), nil),
+			oldAPISIXConfiguration: newAPISIXConfiguration("used-configuration", nil, nil),
+			warning:                "APISIXConfiguration default/used-configuration is used by GatewayClass test-gatewayclass",
+		},
+		{
+			name: "unchanged options on update",
+			apisixConfiguration: newAPISIXConfiguration("test-configuration",
+				dataplaneEnv(corev1.EnvVar{Name: consts.EnvVarApisixDatabase, Value: "postgres"}), nil),
+			oldAPISIXConfiguration: newAPISIXConfiguration("test-configuration",
+				dataplaneEnv(corev1.EnvVar{Name: consts.EnvVarApisixDatabase, Value: "postgres"}), nil),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			warnings, err := v.ValidateAPISIXConfiguration(context.Background(), *tc.apisixConfiguration, tc.oldAPISIXConfiguration)
+			if tc.errMsg != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			if tc.warning == "" {
+				require.Empty(t, warnings)
+				return
+			}
+			require.Len(t, warnings, 1)
+			require.Contains(t, warnings[0], tc.warning)
+		})
+	}
+
+	t.Run("deletion", func(t *testing.T) {
+		err := v.ValidateAPISIXConfigurationDeletion(context.Background(), *newAPISIXConfiguration("used-configuration", nil, nil))
+		require.EqualError(t, err, "APISIXConfiguration default/used-configuration is used by GatewayClass test-gatewayclass and can't be deleted")
+		require.NoError(t, v.ValidateAPISIXConfigurationDeletion(context.Background(), *newAPISIXConfiguration("test-configuration", nil, nil)))
+	})
+}
@@ -1,5 +1,7 @@
 package consts
 
+import "time"
+
 // -----------------------------------------------------------------------------
 // Consts - Standard Kubernetes Object Labels
 // -----------------------------------------------------------------------------
@@ -20,6 +22,156 @@ const (
 	// GatewayManagedLabelValue indicates that the object's lifecycle is managed by
 	// the gateway controller.
 	GatewayManagedLabelValue = "gateway"
+
+	// EtcdManagedLabelValue indicates that an object's lifecycle is managed by
+	// the dataplane controller as part of the etcd cluster it provisions.
+	EtcdManagedLabelValue = "etcd"
+
+	// EtcdClientManagedLabelValue indicates that an object's lifecycle is
+	// managed by the dataplane controller as the client certificate the proxy
+	// of a dataplane authenticates to the etcd cluster provisioned for it with.
+	EtcdClientManagedLabelValue = "etcd-client"
+
+	// AdminKeyManagedLabelValue indicates that an object's lifecycle is managed
+	// by the dataplane controller as the Admin API key of a dataplane.
+	AdminKeyManagedLabelValue = "admin-key"
+
+	// AdminServiceManagedLabelValue indicates that an object's lifecycle is
+	// managed by the dataplane controller as the Service exposing the Admin API
+	// of a dataplane.
+	AdminServiceManagedLabelValue = "admin-service"
+)
+
+// -----------------------------------------------------------------------------
+// Consts - Gateway API
+// -----------------------------------------------------------------------------
+
+const (
+	// DefaultControllerName is the default value used to claim GatewayClasses
+	// through their .spec.controllerName field.
+	DefaultControllerName = "apisix.apache.org/apisix-operator"
+)
+
+// -----------------------------------------------------------------------------
+// Consts - mTLS
+// -----------------------------------------------------------------------------
+
+const (
+	// DefaultClusterCASecretName is the default name of the Secret holding the
+	// CA used to sign the certificates securing the communication between
+	// ControlPlanes and DataPlanes.
+	DefaultClusterCASecretName = "apisix-operator-ca"
+
+	// DefaultClusterCASecretNamespace is the default namespace of the Secret
+	// holding the cluster CA.
+	DefaultClusterCASecretNamespace = "apisix-operator-system"
+
+	// DefaultClusterCertificateLifetime is the default validity of the
+	// certificates issued to ControlPlanes and DataPlanes by the cluster CA.
+	DefaultClusterCertificateLifetime = 365 * 24 * time.Hour
+
+	// DefaultClusterCertificateRenewBefore is the default amount of time before
+	// their expiration at which the certificates issued to ControlPlanes and
+	// DataPlanes are re-issued.
+	DefaultClusterCertificateRenewBefore = 30 * 24 * time.Hour
+
+	// MTLSCertificateSignerName is the name of the signer of the certificates
+	// issued to ControlPlanes and DataPlanes by the cluster CA, used when they
+	// are requested through the CertificateSigningRequest API.
+	MTLSCertificateSignerName = "apisix-operator.apisix.apache.org/mtls"
+
+	// DefaultOperatorUsername is the default username the operator authenticates
+	// to the API server as, the one of its ServiceAccount. Only the
+	// CertificateSigningRequests made by it are approved.
+	DefaultOperatorUsername = "system:serviceaccount:apisix-operator-system:apisix-operator-controller-manager"
+
+	// CertificateSigningRequestAnnotation is the annotation of the mTLS Secrets
+	// holding the name of the CertificateSigningRequest pending for them.
+	CertificateSigningRequestAnnotation = "apisix.apache.org/certificate-signing-request"
+
+	// CertificateSecretAnnotation is the annotation of the
+	// CertificateSigningRequests submitted by the operator holding the
+	// namespace/name of the Secret holding the private key they were made with.
+	CertificateSecretAnnotation = "apisix.apache.org/certificate-secret"
+
+	// CertificateOwnerAnnotation is the annotation of the
+	// CertificateSigningRequests submitted by the operator holding the
+	// namespace/name of the ControlPlane or DataPlane they were submitted for.
+	CertificateOwnerAnnotation = "apisix.apache.org/certificate-owner"
+
+	// ClusterCertificateHashAnnotation is the pod template annotation holding
+	// the hash of the mTLS certificate mounted in the pods of ControlPlanes and
+	// DataPlanes, so that re-issuing the certificate rolls the Deployments.
+	ClusterCertificateHashAnnotation = "apisix.apache.org/cluster-certificate-hash"
+
+	// EtcdCertificateHashAnnotation is the pod template annotation holding the
+	// hash of the certificate of the etcd cluster provisioned for a DataPlane,
+	// mounted in its etcd members and proxies, so that re-issuing the
+	// certificate rolls them.
+	EtcdCertificateHashAnnotation = "apisix.apache.org/etcd-certificate-hash"
+
+	// APISIXConfigHashAnnotation is the pod template annotation holding the
+	// hash of the configuration file mounted in the proxies of a DataPlane, so
+	// that changing the configuration rolls the Deployment.
+	APISIXConfigHashAnnotation = "apisix.apache.org/config-hash"
+)
+
+// -----------------------------------------------------------------------------
+// Consts - Admin API Key
+// -----------------------------------------------------------------------------
+
+const (
+	// RotateAdminKeyAnnotation is the annotation of DataPlanes which rotates
+	// the key of their Admin API whenever its value changes. The value the key
+	// was last generated for is recorded with the same annotation on the
+	// Secret holding the key.
+	RotateAdminKeyAnnotation = "apisix.apache.org/rotate-admin-key"
+
+	// AdminKeyHashAnnotation is the pod template annotation holding the hash
+	// of the Admin API key of a DataPlane, read from the environment of its
+	// proxies and of its ControlPlane, so that rotating the key rolls them.
+	AdminKeyHashAnnotation = "apisix.apache.org/admin-key-hash"
+)
+
+// -----------------------------------------------------------------------------
+// Consts - DataPlane Services
+// -----------------------------------------------------------------------------
+
+const (
+	// LastAppliedAnnotationsAnnotation is the annotation of the ingress Service
+	// of DataPlanes holding the keys of the annotations last set on it from
+	// the DataPlane, so that they're removed once unset, while the ones set
+	// by other controllers are left untouched. It's set the same way on the pod
+	// templates of ControlPlanes and DataPlanes for the annotations of their
+	// pod template overrides.
+	LastAppliedAnnotationsAnnotation = "apisix.apache.org/last-applied-annotations"
+)
+
+// -----------------------------------------------------------------------------
+// Consts - Admission Webhook
+// -----------------------------------------------------------------------------
+
+const (
+	// DefaultWebhookCertificateSecretName is the default name of the Secret
+	// holding the CA and the serving certificate of the admission webhook, which
+	// are shared by all the replicas of the operator.
+	DefaultWebhookCertificateSecretName = "apisix-operator-webhook-certs"
+
+	// DefaultWebhookServiceName is the default name of the Service the API
+	// server reaches the admission webhook through.
+	DefaultWebhookServiceName = "apisix-operator-webhook-service"
+
+	// DefaultWebhookServiceNamespace is the default namespace of the Service
+	// the API server reaches the admission webhook through.
+	DefaultWebhookServiceNamespace = "apisix-operator-system"
+
+	// DefaultValidatingWebhookConfigurationName is the default name of the
+	// ValidatingWebhookConfiguration managed by the operator.
+	DefaultValidatingWebhookConfigurationName = "apisix-operator-validating-webhook-configuration"
+
+	// DefaultMutatingWebhookConfigurationName is the default name of the
+	// MutatingWebhookConfiguration managed by the operator.
+	DefaultMutatingWebhookConfigurationName = "apisix-operator-mutating-webhook-configuration"
 )
 
 // -----------------------------------------------------------------------------
@@ -32,6 +184,14 @@ const (
 
 	// ControlPlanePrefix is used as a name prefix to generate controlplane-owned objects' name
 	ControlPlanePrefix = "controlplane"
+
+	// EtcdPrefix is used as a name prefix to generate the name of the objects of
+	// the etcd clusters provisioned for dataplanes
+	EtcdPrefix = "etcd"
+
+	// EtcdClientPrefix is used as a name prefix to generate the name of the
+	// client certificates of the etcd clusters provisioned for dataplanes
+	EtcdClientPrefix = "etcd-client"
 )
 
 // -----------------------------------------------------------------------------
@@ -72,6 +232,38 @@ const (
 
 	// DataPlaneProxyContainerName is the name of the APISIX proxy container
 	DataPlaneProxyContainerName = "proxy"
+
+	// DefaultEtcdBaseImage is the base container image of the etcd clusters
+	// provisioned for DataPlane resources.
+	DefaultEtcdBaseImage = "quay.io/coreos/etcd"
+
+	// DefaultEtcdTag is the base container image tag of the etcd clusters
+	// provisioned for DataPlane resources.
+	DefaultEtcdTag = "v3.5.4"
+
+	// DefaultEtcdReplicas is the number of members of the etcd clusters
+	// provisioned for DataPlane resources when it's not set.
+	DefaultEtcdReplicas = 3
+
+	// EtcdContainerName is the name of the etcd container in the StatefulSet of
+	// an etcd cluster provisioned for a DataPlane
+	EtcdContainerName = "etcd"
+)
+
+// -----------------------------------------------------------------------------
+// Consts - Etcd exposed ports
+// -----------------------------------------------------------------------------
+
+const (
+	// EtcdClientPort is the port the provisioned etcd members serve clients on.
+	EtcdClientPort = 2379
+
+	// EtcdPeerPort is the port the provisioned etcd members talk to each other on.
+	EtcdPeerPort = 2380
+
+	// EtcdMetricsPort is the port the provisioned etcd members serve their
+	// metrics and health on, without TLS.
+	EtcdMetricsPort = 2381
 )
 
 // -----------------------------------------------------------------------------
@@ -98,7 +290,17 @@ const (
 
 const (
 	// EnvVarApisixDatabase is the environment variable name to specify database
-	// backend used for dataplane(APISIX gateway). Currently only DBLess mode
-	// (empty, or "off") is supported.
+	// backend used for dataplane(APISIX gateway). It must match the config
+	// center of the DataPlane: "off" in standalone mode and "etcd" in etcd mode.
 	EnvVarApisixDatabase = "APISIX_DATABASE"
+
+	// EnvVarApisixEtcdUser and EnvVarApisixEtcdPassword are the environment
+	// variable names holding the credentials the dataplane authenticates to
+	// etcd with, referenced by its configuration file.
+	EnvVarApisixEtcdUser     = "APISIX_ETCD_USER"
+	EnvVarApisixEtcdPassword = "APISIX_ETCD_PASSWORD"
+
+	// EnvVarApisixAdminKey is the environment variable name holding the key of
+	// the Admin API of the dataplane, referenced by its configuration file.
+	EnvVarApisixAdminKey = "APISIX_ADMIN_KEY"
 )
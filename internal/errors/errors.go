@@ -33,6 +33,11 @@ var ErrTooManyDataPlaneNetworkPolicies = errors.New("too many data plane network
 // .spec.ParametersRef field of the given object is nil
 var ErrObjectMissingParametersRef = errors.New("no reference to related objects")
 
+// ErrInvalidParametersRef is a custom error that must be used when the
+// .spec.ParametersRef field of a GatewayClass does not point to a valid
+// APISIXConfiguration object.
+var ErrInvalidParametersRef = errors.New("invalid parametersRef")
+
 // -----------------------------------------------------------------------------
 // Controlplane - Errors
 // -----------------------------------------------------------------------------
@@ -40,3 +45,12 @@ var ErrObjectMissingParametersRef = errors.New("no reference to related objects"
 // ErrDataPlaneNotSet is a custom error that must be used when a specific OwnerReference
 // is expected to be on an object, but it is not found.
 var ErrDataPlaneNotSet = errors.New("no dataplane name set")
+
+// -----------------------------------------------------------------------------
+// Certificates - Errors
+// -----------------------------------------------------------------------------
+
+// ErrCertificateNotIssued is an error which indicates that the request for a
+// certificate was denied or failed, hence that it won't be issued unless a new
+// request is made.
+var ErrCertificateNotIssued = errors.New("certificate not issued")
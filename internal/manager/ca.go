@@ -0,0 +1,169 @@
+// Package manager includes the routines run by the controller manager on
+// startup, before the controllers are started.
+package manager
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// -----------------------------------------------------------------------------
+// Manager - Cluster CA Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	// clusterCACommonName is the common name of the self-signed cluster CA.
+	clusterCACommonName = "APISIX Operator CA"
+
+	// clusterCAValidity is the validity of the self-signed cluster CA.
+	clusterCAValidity = 10 * 365 * 24 * time.Hour
+)
+
+// -----------------------------------------------------------------------------
+// Manager - Cluster CA
+// -----------------------------------------------------------------------------
+
+// EnsureClusterCASecret makes sure that the Secret holding the CA used to
+// sign the mTLS certificates of ControlPlanes and DataPlanes exists. If the
+// Secret is missing, a self-signed ECDSA CA is generated into it. An existing
+// Secret is never overwritten: if it doesn't hold a usable CA an error is
+// returned instead.
+func EnsureClusterCASecret(ctx context.Context, logger logr.Logger, c client.Client, name, namespace string) error {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, secret)
+	if err == nil {
+		if err := validateCASecret(secret); err != nil {
+			return fmt.Errorf("secret %s/%s does not hold a valid CA, refusing to overwrite it: %w", namespace, name, err)
+		}
+		logger.Info("using existing cluster CA secret", "namespace", namespace, "name", name)
+		return nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	logger.Info("cluster CA secret not found, generating a self-signed CA", "namespace", namespace, "name", name)
+	secret, err = generateCASecret(namespace, name)
+	if err != nil {
+		return err
+	}
+	if err := c.Create(ctx, secret); err != nil {
+		// another instance of the operator may have created it in the meantime.
+		if k8serrors.IsAlreadyExists(err) {
+			return EnsureClusterCASecret(ctx, logger, c, name, namespace)
+		}
+		return err
+	}
+	return nil
+}
+
+// generateCASecret generates a TLS Secret holding a new self-signed ECDSA CA.
+func generateCASecret(namespace, name string) (*corev1.Secret, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	pubDer, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	subjectKeyID := sha1.Sum(pubDer) //nolint:gosec
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   clusterCACommonName,
+			Organization: []string{"Apisix, Inc."},
+			Country:      []string{"US"},
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(clusterCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          subjectKeyID[:],
+		SignatureAlgorithm:    x509.ECDSAWithSHA256,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, err
+	}
+	privDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey: pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: der,
+			}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{
+				Type:  "EC PRIVATE KEY",
+				Bytes: privDer,
+			}),
+		},
+	}, nil
+}
+
+// validateCASecret checks that the provided Secret holds a CA certificate,
+// which has not expired, along with its ECDSA private key.
+func validateCASecret(secret *corev1.Secret) error {
+	certBlock, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if certBlock == nil {
+		return fmt.Errorf("%s is missing or is not PEM encoded", corev1.TLSCertKey)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", corev1.TLSCertKey, err)
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("%s is not a CA certificate", corev1.TLSCertKey)
+	}
+	if time.Now().After(cert.NotAfter) {
+		return fmt.Errorf("%s expired on %s", corev1.TLSCertKey, cert.NotAfter)
+	}
+
+	keyBlock, _ := pem.Decode(secret.Data[corev1.TLSPrivateKeyKey])
+	if keyBlock == nil {
+		return fmt.Errorf("%s is missing or is not PEM encoded", corev1.TLSPrivateKeyKey)
+	}
+	priv, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s as an ECDSA private key: %w", corev1.TLSPrivateKeyKey, err)
+	}
+	if !priv.PublicKey.Equal(cert.PublicKey) {
+		return fmt.Errorf("%s does not match the public key of %s", corev1.TLSPrivateKeyKey, corev1.TLSCertKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEnsureClusterCASecret(t *testing.T) {
+	ctx := context.Background()
+	key := client.ObjectKey{Namespace: "apisix-operator-system", Name: "apisix-operator-ca"}
+
+	t.Run("missing secret is generated", func(t *testing.T) {
+		c := fakeclient.NewClientBuilder().Build()
+		require.NoError(t, EnsureClusterCASecret(ctx, logr.Discard(), c, key.Name, key.Namespace))
+
+		secret := &corev1.Secret{}
+		require.NoError(t, c.Get(ctx, key, secret))
+		require.Equal(t, corev1.SecretTypeTLS, secret.Type)
+		require.NoError(t, validateCASecret(secret))
+	})
+
+	t.Run("existing valid secret is kept", func(t *testing.T) {
+		existing, err := generateCASecret(key.Namespace, key.Name)
+		require.NoError(t, err)
+		c := fakeclient.NewClientBuilder().WithObjects(existing).Build()
+		require.NoError(t, EnsureClusterCASecret(ctx, logr.Discard(), c, key.Name, key.Namespace))
+
+		secret := &corev1.Secret{}
+		require.NoError(t, c.Get(ctx, key, secret))
+		require.Equal(t, existing.Data, secret.Data)
+	})
+
+	t.Run("existing invalid secret is not overwritten", func(t *testing.T) {
+		existing := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Data: map[string][]byte{
+				corev1.TLSCertKey: []byte("not a certificate"),
+			},
+		}
+		c := fakeclient.NewClientBuilder().WithObjects(existing).Build()
+		require.Error(t, EnsureClusterCASecret(ctx, logr.Discard(), c, key.Name, key.Namespace))
+
+		secret := &corev1.Secret{}
+		require.NoError(t, c.Get(ctx, key, secret))
+		require.Equal(t, existing.Data, secret.Data)
+	})
+}
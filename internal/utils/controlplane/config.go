@@ -0,0 +1,182 @@
+package controlplane
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
+)
+
+// -----------------------------------------------------------------------------
+// ControlPlane Utils - Config Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	// EnvVarPodNamespace is the environment variable holding the namespace of
+	// the ingress controller pod.
+	EnvVarPodNamespace = "POD_NAMESPACE"
+
+	// EnvVarPodName is the environment variable holding the name of the
+	// ingress controller pod.
+	EnvVarPodName = "POD_NAME"
+
+	// EnvVarPublishService is the environment variable holding the
+	// namespace/name of the DataPlane Service the ingress controller publishes
+	// the addresses of.
+	EnvVarPublishService = "CONTROLLER_PUBLISH_SERVICE"
+
+	// EnvVarApisixAdminURL is the environment variable holding the URL of the
+	// Admin API of the DataPlane the ingress controller configures.
+	EnvVarApisixAdminURL = "CONTROLLER_APISIX_ADMIN_URL"
+
+	// EnvVarApisixAdminTLSClientCertFile is the environment variable holding
+	// the path of the client certificate the ingress controller authenticates
+	// to the Admin API with.
+	EnvVarApisixAdminTLSClientCertFile = "CONTROLLER_APISIX_ADMIN_TLS_CLIENT_CERT_FILE"
+
+	// EnvVarApisixAdminTLSClientKeyFile is the environment variable holding
+	// the path of the key of the client certificate.
+	EnvVarApisixAdminTLSClientKeyFile = "CONTROLLER_APISIX_ADMIN_TLS_CLIENT_KEY_FILE"
+
+	// EnvVarApisixAdminCACertFile is the environment variable holding the path
+	// of the CA the Admin API certificate is verified with.
+	EnvVarApisixAdminCACertFile = "CONTROLLER_APISIX_ADMIN_CA_CERT_FILE"
+
+	// EnvVarApisixAdminKey is the environment variable holding the key the
+	// ingress controller authenticates to the Admin API with.
+	EnvVarApisixAdminKey = "CONTROLLER_APISIX_ADMIN_KEY"
+
+	// ClusterCertificateMountPath is the path the mTLS certificate of the
+	// ControlPlane is mounted at.
+	ClusterCertificateMountPath = "/var/cluster-certificate"
+)
+
+// -----------------------------------------------------------------------------
+// ControlPlane Utils - Config
+// -----------------------------------------------------------------------------
+
+// ApisixAdminURL returns the URL of the Admin API of the provided DataPlane,
+// served behind its Admin API Service.
+func ApisixAdminURL(dataplaneName, dataplaneNamespace string) string {
+	return fmt.Sprintf("https://%s.%s.svc:%d",
+		dataplaneutils.AdminServiceName(dataplaneName), dataplaneNamespace, dataplaneutils.DefaultAPISIXAdminPort)
+}
+
+// PublishService returns the namespace/name of the provided DataPlane Service.
+func PublishService(dataplaneServiceName, dataplaneNamespace string) string {
+	return fmt.Sprintf("%s/%s", dataplaneNamespace, dataplaneServiceName)
+}
+
+// ApisixAdminKeyEnvVar returns the environment variable referencing the Admin
+// API key of the provided DataPlane.
+func ApisixAdminKeyEnvVar(dataplaneName string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: EnvVarApisixAdminKey,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: dataplaneutils.AdminKeySecretKeySelector(dataplaneName),
+		},
+	}
+}
+
+// ManagedEnv returns the environment variables the operator sets on the
+// ControlPlanes in the provided namespace, which users can't override. The
+// Admin API key and URL are only returned if the name of the DataPlane is
+// provided, and the published DataPlane Service if its name is provided.
+func ManagedEnv(namespace, dataplaneName, dataplaneServiceName string) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{
+			Name: EnvVarPodNamespace,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.namespace"},
+			},
+		},
+		{
+			Name: EnvVarPodName,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.name"},
+			},
+		},
+		{Name: EnvVarApisixAdminTLSClientCertFile, Value: path.Join(ClusterCertificateMountPath, "tls.crt")},
+		{Name: EnvVarApisixAdminTLSClientKeyFile, Value: path.Join(ClusterCertificateMountPath, "tls.key")},
+		{Name: EnvVarApisixAdminCACertFile, Value: path.Join(ClusterCertificateMountPath, "ca.crt")},
+	}
+	if dataplaneName != "" {
+		env = append(env, ApisixAdminKeyEnvVar(dataplaneName))
+		if namespace != "" {
+			env = append(env, corev1.EnvVar{Name: EnvVarApisixAdminURL, Value: ApisixAdminURL(dataplaneName, namespace)})
+		}
+	}
+	if namespace != "" && dataplaneServiceName != "" {
+		env = append(env,
+			corev1.EnvVar{Name: EnvVarPublishService, Value: PublishService(dataplaneServiceName, namespace)},
+		)
+	}
+	return env
+}
+
+// IsManagedEnvVar returns whether the provided environment variable is set
+// by the operator on ControlPlanes.
+func IsManagedEnvVar(name string) bool {
+	switch name {
+	case EnvVarPodNamespace, EnvVarPodName, EnvVarPublishService, EnvVarApisixAdminURL,
+		EnvVarApisixAdminTLSClientCertFile, EnvVarApisixAdminTLSClientKeyFile, EnvVarApisixAdminCACertFile,
+		EnvVarApisixAdminKey:
+		return true
+	}
+	return false
+}
+
+// SetControlPlaneDefaults sets the default configuration options on the
+// ControlPlane: the container image and version if unset, and the environment
+// variables managed by the operator which don't depend on the DataPlane.
+func SetControlPlaneDefaults(spec *apisixoperatorv1alpha1.ControlPlaneDeploymentOptions) {
+	SetDefaultImage(&spec.DeploymentOptions)
+	for _, envVar := range ManagedEnv("", "", "") {
+		spec.Env = SetEnvVar(spec.Env, envVar)
+	}
+}
+
+// SetDefaultImage sets the default ControlPlane image on the provided
+// deployment options if no image is set, along with the default version if no
+// version is set either. The version of a custom image is left untouched, as
+// the image may already be tagged.
+func SetDefaultImage(opts *apisixoperatorv1alpha1.DeploymentOptions) {
+	if opts.ContainerImage != nil && *opts.ContainerImage != "" {
+		return
+	}
+	image := consts.DefaultControlPlaneBaseImage
+	opts.ContainerImage = &image
+	if opts.Version == nil || *opts.Version == "" {
+		version := consts.DefaultControlPlaneTag
+		opts.Version = &version
+	}
+}
+
+// Image returns the container image of the ControlPlane deployed with the
+// provided options, tagged with their version if any, or nil if no image is set.
+func Image(opts apisixoperatorv1alpha1.DeploymentOptions) *string {
+	if opts.ContainerImage == nil {
+		return nil
+	}
+	image := *opts.ContainerImage
+	if opts.Version != nil {
+		image = fmt.Sprintf("%s:%s", image, *opts.Version)
+	}
+	return &image
+}
+
+// SetEnvVar sets the provided environment variable in env, replacing the
+// variable with the same name in place if any, and returns the updated env.
+func SetEnvVar(env []corev1.EnvVar, envVar corev1.EnvVar) []corev1.EnvVar {
+	for i := range env {
+		if env[i].Name == envVar.Name {
+			env[i] = envVar
+			return env
+		}
+	}
+	return append(env, envVar)
+}
@@ -0,0 +1,72 @@
+package dataplane
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/chever-john/apisix-operator/internal/consts"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - Admin API Key Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	// AdminKeySecretKey is the key of the Secret holding the Admin API key of
+	// a DataPlane.
+	AdminKeySecretKey = "admin-key"
+
+	// AdminKeyName is the name of the Admin API key in the APISIX
+	// configuration.
+	AdminKeyName = "admin"
+
+	// AdminKeyRole is the role of the Admin API key, granting full access.
+	AdminKeyRole = "admin"
+
+	// adminKeyLength is the number of random bytes of an Admin API key.
+	adminKeyLength = 16
+)
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - Admin API Key
+// -----------------------------------------------------------------------------
+
+// AdminKeySecretName returns the name of the Secret holding the Admin API key
+// of the provided DataPlane. It's deterministic so that ControlPlanes can
+// reference it before it's created.
+func AdminKeySecretName(dataplaneName string) string {
+	return fmt.Sprintf("%s-%s-admin-key", consts.DataPlanePrefix, dataplaneName)
+}
+
+// GenerateAdminKey returns a new random Admin API key.
+func GenerateAdminKey() (string, error) {
+	key := make([]byte, adminKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate the admin key: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// AdminKeySecretKeySelector returns the selector of the Admin API key of the
+// provided DataPlane.
+func AdminKeySecretKeySelector(dataplaneName string) *corev1.SecretKeySelector {
+	return &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: AdminKeySecretName(dataplaneName)},
+		Key:                  AdminKeySecretKey,
+	}
+}
+
+// GenerateAdminKeyEnvVar returns the environment variable holding the Admin
+// API key of the provided DataPlane, referenced by its configuration (see
+// GenerateAPISIXConfig).
+func GenerateAdminKeyEnvVar(dataplaneName string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: consts.EnvVarApisixAdminKey,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: AdminKeySecretKeySelector(dataplaneName),
+		},
+	}
+}
@@ -0,0 +1,368 @@
+package dataplane
+
+import (
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - APISIX Config Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	// ConfigFileName is the name of the APISIX configuration file, which is
+	// also the key of the ConfigMap holding it.
+	ConfigFileName = "config.yaml"
+
+	// ConfigMountPath is the path the APISIX configuration file is mounted at
+	// in the proxy container.
+	ConfigMountPath = "/usr/local/apisix/conf/" + ConfigFileName
+
+	// ClusterCertificateMountPath is the path the mTLS certificate of the
+	// DataPlane is mounted at in the proxy container.
+	ClusterCertificateMountPath = "/var/cluster-certificate"
+
+	// DefaultWorkerProcesses is the number of nginx worker processes of the
+	// APISIX proxy.
+	DefaultWorkerProcesses = 2
+
+	// StatusPort is the port the status API of the proxy is served on, which
+	// its readiness probe targets.
+	StatusPort = 8100
+
+	// StatusPath is the path of the status API of the proxy.
+	StatusPath = "/status"
+
+	// DeploymentRoleTraditional is the deployment role of the proxies, which
+	// serve both the traffic and the Admin API their ControlPlane configures
+	// them through.
+	DeploymentRoleTraditional = "traditional"
+
+	// ConfigProviderEtcd and ConfigProviderYAML are the configuration
+	// providers of the proxies loading their configuration from etcd, and of
+	// the standalone proxies keeping the configuration written to their Admin
+	// API in memory, respectively.
+	ConfigProviderEtcd = "etcd"
+	ConfigProviderYAML = "yaml"
+)
+
+// DefaultAllowAdmin are the networks allowed to reach the Admin API of the
+// proxy, which is only served over mTLS: the ControlPlane may run anywhere in
+// the cluster.
+var DefaultAllowAdmin = []string{"0.0.0.0/0", "::/0"}
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - APISIX Config Types
+// -----------------------------------------------------------------------------
+
+// APISIXConfig is the configuration of the APISIX proxy, rendered as its
+// conf/config.yaml. Only the options managed by the operator are modelled,
+// APISIX merges them with its defaults.
+type APISIXConfig struct {
+	APISIX      APISIXOptions     `json:"apisix"`
+	NginxConfig NginxConfig       `json:"nginx_config"`
+	Plugins     []string          `json:"plugins,omitempty"`
+	Deployment  DeploymentOptions `json:"deployment"`
+}
+
+// APISIXOptions are the options of the apisix section of the configuration.
+type APISIXOptions struct {
+	NodeListen  []ListenOptions     `json:"node_listen,omitempty"`
+	EnableAdmin bool                `json:"enable_admin"`
+	SSL         SSLOptions          `json:"ssl"`
+	StreamProxy *StreamProxyOptions `json:"stream_proxy,omitempty"`
+	Status      *StatusOptions      `json:"status,omitempty"`
+}
+
+// StatusOptions are the address the status API of the proxy is served on.
+type StatusOptions struct {
+	IP   string `json:"ip"`
+	Port int32  `json:"port"`
+}
+
+// ListenOptions is an address the proxy listens on.
+type ListenOptions struct {
+	IP          string `json:"ip,omitempty"`
+	Port        int32  `json:"port"`
+	EnableHTTP2 bool   `json:"enable_http2,omitempty"`
+}
+
+// SSLOptions are the options of the HTTPS listeners of the proxy.
+type SSLOptions struct {
+	Enable                bool            `json:"enable"`
+	Listen                []ListenOptions `json:"listen,omitempty"`
+	SSLTrustedCertificate string          `json:"ssl_trusted_certificate,omitempty"`
+}
+
+// StreamProxyOptions are the addresses the proxy proxies TCP, TLS and UDP
+// traffic on.
+type StreamProxyOptions struct {
+	TCP []StreamListenOptions `json:"tcp,omitempty"`
+	UDP []string              `json:"udp,omitempty"`
+}
+
+// StreamListenOptions is an address the proxy proxies TCP or TLS traffic on.
+type StreamListenOptions struct {
+	Addr string `json:"addr"`
+	TLS  bool   `json:"tls,omitempty"`
+}
+
+// NginxConfig are the options of the nginx_config section of the
+// configuration.
+type NginxConfig struct {
+	ErrorLog                       string              `json:"error_log,omitempty"`
+	ErrorLogLevel                  string              `json:"error_log_level,omitempty"`
+	WorkerProcesses                *intstr.IntOrString `json:"worker_processes,omitempty"`
+	MainConfigurationSnippet       string              `json:"main_configuration_snippet,omitempty"`
+	HTTPConfigurationSnippet       string              `json:"http_configuration_snippet,omitempty"`
+	HTTPServerConfigurationSnippet string              `json:"http_server_configuration_snippet,omitempty"`
+	HTTPAdminConfigurationSnippet  string              `json:"http_admin_configuration_snippet,omitempty"`
+	StreamConfigurationSnippet     string              `json:"stream_configuration_snippet,omitempty"`
+	HTTP                           NginxHTTPConfig     `json:"http"`
+}
+
+// NginxHTTPConfig are the options of the http block of nginx.
+type NginxHTTPConfig struct {
+	AccessLog             string   `json:"access_log,omitempty"`
+	AccessLogFormat       string   `json:"access_log_format,omitempty"`
+	AccessLogFormatEscape string   `json:"access_log_format_escape,omitempty"`
+	RealIPHeader          string   `json:"real_ip_header,omitempty"`
+	RealIPRecursive       string   `json:"real_ip_recursive,omitempty"`
+	RealIPFrom            []string `json:"real_ip_from,omitempty"`
+}
+
+// DeploymentOptions are the options of the deployment section of the
+// configuration.
+type DeploymentOptions struct {
+	Role            string        `json:"role"`
+	RoleTraditional *RoleOptions  `json:"role_traditional,omitempty"`
+	Admin           *AdminOptions `json:"admin,omitempty"`
+	Etcd            *EtcdOptions  `json:"etcd,omitempty"`
+}
+
+// RoleOptions are the options of a deployment role.
+type RoleOptions struct {
+	ConfigProvider string `json:"config_provider"`
+}
+
+// AdminOptions are the options of the Admin API of the proxy.
+type AdminOptions struct {
+	AdminKey     []AdminKey           `json:"admin_key,omitempty"`
+	AllowAdmin   []string             `json:"allow_admin,omitempty"`
+	AdminListen  ListenOptions        `json:"admin_listen"`
+	HTTPSAdmin   bool                 `json:"https_admin"`
+	AdminAPIMTLS *AdminAPIMTLSOptions `json:"admin_api_mtls,omitempty"`
+}
+
+// AdminKey is a key the Admin API of the proxy is authenticated with.
+type AdminKey struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	Role string `json:"role"`
+}
+
+// AdminAPIMTLSOptions are the certificates the Admin API of the proxy is
+// served with and verifies its clients with.
+type AdminAPIMTLSOptions struct {
+	AdminSSLCert    string `json:"admin_ssl_cert"`
+	AdminSSLCertKey string `json:"admin_ssl_cert_key"`
+	AdminSSLCACert  string `json:"admin_ssl_ca_cert"`
+}
+
+// EtcdOptions are the options of the etcd cluster the proxy loads its
+// configuration from.
+type EtcdOptions struct {
+	Host     []string        `json:"host"`
+	Prefix   string          `json:"prefix,omitempty"`
+	User     string          `json:"user,omitempty"`
+	Password string          `json:"password,omitempty"`
+	TLS      *EtcdTLSOptions `json:"tls,omitempty"`
+}
+
+// EtcdTLSOptions are the TLS options the proxy connects to etcd with.
+type EtcdTLSOptions struct {
+	Cert   string `json:"cert,omitempty"`
+	Key    string `json:"key,omitempty"`
+	Verify bool   `json:"verify"`
+	SNI    string `json:"sni,omitempty"`
+}
+
+// Render returns the configuration as the content of conf/config.yaml.
+func (c *APISIXConfig) Render() (string, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to render the APISIX configuration: %w", err)
+	}
+	return string(b), nil
+}
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - APISIX Config
+// -----------------------------------------------------------------------------
+
+// GenerateAPISIXConfig returns the configuration of the APISIX proxy of the
+// provided DataPlane: its listeners follow the ports exposed by its Service,
+// its Admin API is served over mTLS with the certificate of the DataPlane and
+// authenticated with its admin key, its configuration is loaded from its
+// config center and the options of its config are applied on top.
+func GenerateAPISIXConfig(dataplane *apisixoperatorv1alpha1.DataPlane) *APISIXConfig {
+	workerProcesses := intstr.FromInt(DefaultWorkerProcesses)
+	config := &APISIXConfig{
+		APISIX: APISIXOptions{
+			EnableAdmin: true,
+			// the readiness of the proxy is probed on its status API.
+			Status: &StatusOptions{IP: "0.0.0.0", Port: StatusPort},
+		},
+		NginxConfig: NginxConfig{
+			ErrorLog:        "/dev/stderr",
+			WorkerProcesses: &workerProcesses,
+			HTTP: NginxHTTPConfig{
+				AccessLog: "/dev/stdout",
+			},
+		},
+		Deployment: DeploymentOptions{
+			Admin: &AdminOptions{
+				// the key is read from the Secret of the DataPlane, see GenerateAdminKeyEnvVar.
+				AdminKey: []AdminKey{{
+					Name: AdminKeyName,
+					Key:  envVarReference(consts.EnvVarApisixAdminKey),
+					Role: AdminKeyRole,
+				}},
+				AllowAdmin:  DefaultAllowAdmin,
+				AdminListen: ListenOptions{IP: "0.0.0.0", Port: GetAdminPort(dataplane)},
+				HTTPSAdmin:  true,
+				AdminAPIMTLS: &AdminAPIMTLSOptions{
+					AdminSSLCert:    path.Join(ClusterCertificateMountPath, "tls.crt"),
+					AdminSSLCertKey: path.Join(ClusterCertificateMountPath, "tls.key"),
+					AdminSSLCACert:  path.Join(ClusterCertificateMountPath, "ca.crt"),
+				},
+			},
+		},
+	}
+
+	setListenConfig(config, GetServicePorts(dataplane))
+	setConfigCenterConfig(config, dataplane)
+	if dataplane.Spec.Config != nil {
+		setDataPlaneConfig(config, dataplane.Spec.Config)
+	}
+
+	return config
+}
+
+// setDataPlaneConfig applies the options of the provided DataPlane config.
+func setDataPlaneConfig(config *APISIXConfig, dataplaneConfig *apisixoperatorv1alpha1.DataPlaneConfig) {
+	config.Plugins = dataplaneConfig.Plugins
+	if dataplaneConfig.WorkerProcesses != nil {
+		config.NginxConfig.WorkerProcesses = dataplaneConfig.WorkerProcesses
+	}
+
+	if admin := dataplaneConfig.Admin; admin != nil && len(admin.AllowList) > 0 {
+		config.Deployment.Admin.AllowAdmin = admin.AllowList
+	}
+
+	if realIP := dataplaneConfig.RealIP; realIP != nil {
+		config.NginxConfig.HTTP.RealIPHeader = realIP.Header
+		config.NginxConfig.HTTP.RealIPRecursive = "off"
+		if realIP.Recursive {
+			config.NginxConfig.HTTP.RealIPRecursive = "on"
+		}
+		config.NginxConfig.HTTP.RealIPFrom = realIP.TrustedAddresses
+	}
+
+	if logging := dataplaneConfig.Logging; logging != nil {
+		config.NginxConfig.HTTP.AccessLogFormat = logging.AccessLogFormat
+		config.NginxConfig.HTTP.AccessLogFormatEscape = logging.AccessLogFormatEscape
+		config.NginxConfig.ErrorLogLevel = logging.ErrorLogLevel
+	}
+
+	if snippets := dataplaneConfig.NginxSnippets; snippets != nil {
+		config.NginxConfig.MainConfigurationSnippet = snippets.Main
+		config.NginxConfig.HTTPConfigurationSnippet = snippets.HTTP
+		config.NginxConfig.HTTPServerConfigurationSnippet = snippets.HTTPServer
+		config.NginxConfig.HTTPAdminConfigurationSnippet = snippets.HTTPAdmin
+		config.NginxConfig.StreamConfigurationSnippet = snippets.Stream
+	}
+}
+
+// setListenConfig configures the proxy to listen on the ports serving the
+// provided DataPlane ports. HTTP and HTTPS ports are served by the node
+// listeners, while TCP, TLS and UDP ports are served by the stream proxy.
+func setListenConfig(config *APISIXConfig, ports []apisixoperatorv1alpha1.DataPlaneServicePort) {
+	var streamProxy StreamProxyOptions
+	for _, port := range ports {
+		proxyPort := ProxyPortFor(port)
+		switch port.Protocol {
+		case apisixoperatorv1alpha1.ProxyProtocolHTTP:
+			config.APISIX.NodeListen = append(config.APISIX.NodeListen, ListenOptions{Port: proxyPort})
+		case apisixoperatorv1alpha1.ProxyProtocolHTTPS:
+			config.APISIX.SSL.Listen = append(config.APISIX.SSL.Listen, ListenOptions{Port: proxyPort, EnableHTTP2: true})
+		case apisixoperatorv1alpha1.ProxyProtocolTCP:
+			streamProxy.TCP = append(streamProxy.TCP, StreamListenOptions{Addr: fmt.Sprintf("0.0.0.0:%d", proxyPort)})
+		case apisixoperatorv1alpha1.ProxyProtocolTLS:
+			streamProxy.TCP = append(streamProxy.TCP, StreamListenOptions{Addr: fmt.Sprintf("0.0.0.0:%d", proxyPort), TLS: true})
+		case apisixoperatorv1alpha1.ProxyProtocolUDP:
+			streamProxy.UDP = append(streamProxy.UDP, fmt.Sprintf("0.0.0.0:%d", proxyPort))
+		}
+	}
+
+	config.APISIX.SSL.Enable = len(config.APISIX.SSL.Listen) > 0
+	if len(streamProxy.TCP) > 0 || len(streamProxy.UDP) > 0 {
+		config.APISIX.StreamProxy = &streamProxy
+	}
+}
+
+// setConfigCenterConfig configures the proxy to load its configuration from
+// the config center of the provided DataPlane. The etcd credentials are read
+// from the environment (see GenerateConfigCenterEnvVars), and the
+// certificates from the volumes returned by GenerateConfigCenterVolumes.
+//
+// The proxy keeps the traditional role in both modes, as the ControlPlane
+// configures it through its Admin API, which the data_plane role disables: in
+// standalone mode the configuration written to the Admin API is kept in memory
+// by the yaml provider.
+func setConfigCenterConfig(config *APISIXConfig, dataplane *apisixoperatorv1alpha1.DataPlane) {
+	config.Deployment.Role = DeploymentRoleTraditional
+	if GetConfigCenterMode(dataplane) != apisixoperatorv1alpha1.ConfigCenterModeEtcd ||
+		dataplane.Spec.ConfigCenter.Etcd == nil {
+		config.Deployment.RoleTraditional = &RoleOptions{ConfigProvider: ConfigProviderYAML}
+		return
+	}
+
+	config.Deployment.RoleTraditional = &RoleOptions{ConfigProvider: ConfigProviderEtcd}
+
+	etcd := dataplane.Spec.ConfigCenter.Etcd
+	config.Deployment.Etcd = &EtcdOptions{
+		Host:   etcd.Endpoints,
+		Prefix: etcd.Prefix,
+	}
+	if etcd.AuthSecretRef != nil {
+		config.Deployment.Etcd.User = envVarReference(consts.EnvVarApisixEtcdUser)
+		config.Deployment.Etcd.Password = envVarReference(consts.EnvVarApisixEtcdPassword)
+	}
+
+	if tls := etcd.TLS; tls != nil {
+		config.Deployment.Etcd.TLS = &EtcdTLSOptions{
+			Verify: !tls.InsecureSkipVerify,
+			SNI:    tls.SNI,
+		}
+		if tls.ClientCertificateSecretRef != nil {
+			config.Deployment.Etcd.TLS.Cert = path.Join(EtcdClientCertificateMountPath, "tls.crt")
+			config.Deployment.Etcd.TLS.Key = path.Join(EtcdClientCertificateMountPath, "tls.key")
+		}
+		if tls.CASecretRef != nil {
+			config.APISIX.SSL.SSLTrustedCertificate = path.Join(EtcdCAMountPath, EtcdCASecretKey)
+		}
+	}
+}
+
+// envVarReference returns the reference to the provided environment variable
+// in the APISIX configuration, which is resolved when the proxy starts.
+func envVarReference(name string) string {
+	return fmt.Sprintf("${{%s}}", name)
+}
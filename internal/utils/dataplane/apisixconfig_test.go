@@ -0,0 +1,201 @@
+package dataplane
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+)
+
+func TestGenerateAPISIXConfigListeners(t *testing.T) {
+	for _, tt := range []struct {
+		name                string
+		ports               []apisixoperatorv1alpha1.DataPlaneServicePort
+		expectedNodeListen  []ListenOptions
+		expectedSSL         SSLOptions
+		expectedStreamProxy *StreamProxyOptions
+	}{
+		{
+			name:               "default ports",
+			expectedNodeListen: []ListenOptions{{Port: 9080}},
+			expectedSSL: SSLOptions{
+				Enable: true,
+				Listen: []ListenOptions{{Port: 9443, EnableHTTP2: true}},
+			},
+		},
+		{
+			name: "stream ports",
+			ports: []apisixoperatorv1alpha1.DataPlaneServicePort{
+				{Port: 8080, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+				{Port: 5432, Protocol: apisixoperatorv1alpha1.ProxyProtocolTCP},
+				{Port: 8443, Protocol: apisixoperatorv1alpha1.ProxyProtocolTLS},
+				{Port: 53, Protocol: apisixoperatorv1alpha1.ProxyProtocolUDP},
+			},
+			expectedNodeListen: []ListenOptions{{Port: 8080}},
+			expectedStreamProxy: &StreamProxyOptions{
+				TCP: []StreamListenOptions{
+					{Addr: "0.0.0.0:5432"},
+					{Addr: "0.0.0.0:8443", TLS: true},
+				},
+				UDP: []string{"0.0.0.0:9053"},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dataplane := &apisixoperatorv1alpha1.DataPlane{}
+			if tt.ports != nil {
+				dataplane.Spec.Network.Services = &apisixoperatorv1alpha1.DataPlaneServices{
+					Ingress: &apisixoperatorv1alpha1.DataPlaneServiceOptions{Ports: tt.ports},
+				}
+			}
+			config := GenerateAPISIXConfig(dataplane)
+			require.Equal(t, tt.expectedNodeListen, config.APISIX.NodeListen)
+			require.Equal(t, tt.expectedSSL, config.APISIX.SSL)
+			require.Equal(t, tt.expectedStreamProxy, config.APISIX.StreamProxy)
+		})
+	}
+}
+
+func TestAPISIXConfigRender(t *testing.T) {
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+			ConfigCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+				Mode: apisixoperatorv1alpha1.ConfigCenterModeEtcd,
+				Etcd: &apisixoperatorv1alpha1.EtcdConfigCenter{
+					Endpoints:     []string{"https://etcd:2379"},
+					Prefix:        "/apisix",
+					AuthSecretRef: &corev1.LocalObjectReference{Name: "etcd-auth"},
+					TLS: &apisixoperatorv1alpha1.EtcdTLSConfig{
+						ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "etcd-client"},
+						CASecretRef:                &corev1.LocalObjectReference{Name: "etcd-ca"},
+						SNI:                        "etcd.example",
+					},
+				},
+			},
+		},
+	}
+
+	rendered, err := GenerateAPISIXConfig(dataplane).Render()
+	require.NoError(t, err)
+	require.Equal(t, `apisix:
+  enable_admin: true
+  node_listen:
+  - port: 9080
+  ssl:
+    enable: true
+    listen:
+    - enable_http2: true
+      port: 9443
+    ssl_trusted_certificate: /etc/apisix/etcd/ca/ca.crt
+  status:
+    ip: 0.0.0.0
+    port: 8100
+deployment:
+  admin:
+    admin_api_mtls:
+      admin_ssl_ca_cert: /var/cluster-certificate/ca.crt
+      admin_ssl_cert: /var/cluster-certificate/tls.crt
+      admin_ssl_cert_key: /var/cluster-certificate/tls.key
+    admin_key:
+    - key: ${{APISIX_ADMIN_KEY}}
+      name: admin
+      role: admin
+    admin_listen:
+      ip: 0.0.0.0
+      port: 9444
+    allow_admin:
+    - 0.0.0.0/0
+    - ::/0
+    https_admin: true
+  etcd:
+    host:
+    - https://etcd:2379
+    password: ${{APISIX_ETCD_PASSWORD}}
+    prefix: /apisix
+    tls:
+      cert: /etc/apisix/etcd/client/tls.crt
+      key: /etc/apisix/etcd/client/tls.key
+      sni: etcd.example
+      verify: true
+    user: ${{APISIX_ETCD_USER}}
+  role: traditional
+  role_traditional:
+    config_provider: etcd
+nginx_config:
+  error_log: /dev/stderr
+  http:
+    access_log: /dev/stdout
+  worker_processes: 2
+`, rendered)
+}
+
+func TestGenerateAPISIXConfigStandalone(t *testing.T) {
+	config := GenerateAPISIXConfig(&apisixoperatorv1alpha1.DataPlane{})
+	require.True(t, config.APISIX.EnableAdmin)
+	require.Equal(t, &StatusOptions{IP: "0.0.0.0", Port: StatusPort}, config.APISIX.Status)
+	require.Equal(t, DeploymentRoleTraditional, config.Deployment.Role)
+	require.Equal(t, &RoleOptions{ConfigProvider: ConfigProviderYAML}, config.Deployment.RoleTraditional)
+	require.Nil(t, config.Deployment.Etcd)
+	require.NotNil(t, config.Deployment.Admin)
+}
+
+func TestGenerateAPISIXConfigDataPlaneConfig(t *testing.T) {
+	adminPort := int32(9180)
+	targetPort := int32(8000)
+	workerProcesses := intstr.FromString("auto")
+	dataplane := &apisixoperatorv1alpha1.DataPlane{
+		Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+			Network: apisixoperatorv1alpha1.DataPlaneNetworkOptions{
+				Services: &apisixoperatorv1alpha1.DataPlaneServices{
+					Ingress: &apisixoperatorv1alpha1.DataPlaneServiceOptions{
+						Ports: []apisixoperatorv1alpha1.DataPlaneServicePort{
+							{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP, TargetPort: &targetPort},
+						},
+					},
+				},
+			},
+			Config: &apisixoperatorv1alpha1.DataPlaneConfig{
+				Plugins:         []string{"cors", "prometheus"},
+				WorkerProcesses: &workerProcesses,
+				Admin: &apisixoperatorv1alpha1.DataPlaneAdminAPIOptions{
+					Port:      &adminPort,
+					AllowList: []string{"10.0.0.0/8"},
+				},
+				RealIP: &apisixoperatorv1alpha1.DataPlaneRealIPOptions{
+					Header:           "X-Forwarded-For",
+					Recursive:        true,
+					TrustedAddresses: []string{"192.168.0.0/16"},
+				},
+				Logging: &apisixoperatorv1alpha1.DataPlaneLoggingOptions{
+					AccessLogFormat:       "$remote_addr $status",
+					AccessLogFormatEscape: "json",
+					ErrorLogLevel:         "info",
+				},
+				NginxSnippets: &apisixoperatorv1alpha1.DataPlaneNginxSnippets{
+					HTTP: "client_max_body_size 10m;",
+				},
+			},
+		},
+	}
+
+	config := GenerateAPISIXConfig(dataplane)
+	require.Equal(t, []string{"cors", "prometheus"}, config.Plugins)
+	require.Equal(t, &workerProcesses, config.NginxConfig.WorkerProcesses)
+	require.Equal(t, []ListenOptions{{Port: 8000}}, config.APISIX.NodeListen)
+	require.Equal(t, ListenOptions{IP: "0.0.0.0", Port: 9180}, config.Deployment.Admin.AdminListen)
+	require.Equal(t, []string{"10.0.0.0/8"}, config.Deployment.Admin.AllowAdmin)
+	require.Equal(t, NginxHTTPConfig{
+		AccessLog:             "/dev/stdout",
+		AccessLogFormat:       "$remote_addr $status",
+		AccessLogFormatEscape: "json",
+		RealIPHeader:          "X-Forwarded-For",
+		RealIPRecursive:       "on",
+		RealIPFrom:            []string{"192.168.0.0/16"},
+	}, config.NginxConfig.HTTP)
+	require.Equal(t, "info", config.NginxConfig.ErrorLogLevel)
+	require.Equal(t, "client_max_body_size 10m;", config.NginxConfig.HTTPConfigurationSnippet)
+}
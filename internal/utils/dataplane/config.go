@@ -1,13 +1,8 @@
 package dataplane
 
 import (
-	"fmt"
-	"sort"
-
-	corev1 "k8s.io/api/core/v1"
-
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
-	k8sutils "github.com/chever-john/apisix-operator/internal/utils/kubernetes"
+	"github.com/chever-john/apisix-operator/internal/consts"
 )
 
 // -----------------------------------------------------------------------------
@@ -24,60 +19,48 @@ const (
 	DefaultHTTPSPort = 443
 
 	// DefaultAPISIXHTTPPort is the APISIX proxy's default port used for HTTP traffic
-	DefaultAPISIXHTTPPort = 9000
+	DefaultAPISIXHTTPPort = 9080
 
 	// DefaultAPISIXHTTPSPort is the APISIX proxy's default port used for HTTPS traffic
 	DefaultAPISIXHTTPSPort = 9443
 
 	// DefaultAPISIXHTTPSPort is the default port used for APISIX Admin API traffic
 	DefaultAPISIXAdminPort = 9444
-
-	// DefaultAPISIXStatusPort is the default port used for APISIX proxy status
-	DefaultAPISIXStatusPort = 9100
 )
 
-// APISIXDefaults are the baseline APISIX proxy configuration options needed for
-// the proxy to function.
-var APISIXDefaults = map[string]string{
-	"APISIX_ADMIN_ACCESS_LOG":       "/dev/stdout",
-	"APISIX_ADMIN_ERROR_LOG":        "/dev/stderr",
-	"APISIX_ADMIN_GUI_ACCESS_LOG":   "/dev/stdout",
-	"APISIX_ADMIN_GUI_ERROR_LOG":    "/dev/stderr",
-	"APISIX_CLUSTER_LISTEN":         "off",
-	"APISIX_DATABASE":               "off",
-	"APISIX_NGINX_WORKER_PROCESSES": "2",
-	"APISIX_PLUGINS":                "bundled",
-	"APISIX_PORTAL_API_ACCESS_LOG":  "/dev/stdout",
-	"APISIX_PORTAL_API_ERROR_LOG":   "/dev/stderr",
-	"APISIX_PORT_MAPS":              "80:8000, 443:8443",
-	"APISIX_PROXY_ACCESS_LOG":       "/dev/stdout",
-	"APISIX_PROXY_ERROR_LOG":        "/dev/stderr",
-	"APISIX_PROXY_LISTEN":           fmt.Sprintf("0.0.0.0:%d reuseport backlog=16384, 0.0.0.0:%d http2 ssl reuseport backlog=16384", DefaultAPISIXHTTPPort, DefaultAPISIXHTTPSPort),
-	"APISIX_STATUS_LISTEN":          fmt.Sprintf("0.0.0.0:%d", DefaultAPISIXStatusPort),
-
-	"APISIX_ADMIN_LISTEN": fmt.Sprintf("0.0.0.0:%d ssl reuseport backlog=16384", DefaultAPISIXAdminPort),
-
-	// MTLS
-	"APISIX_ADMIN_SSL_CERT":                     "/var/cluster-certificate/tls.crt",
-	"APISIX_ADMIN_SSL_CERT_KEY":                 "/var/cluster-certificate/tls.key",
-	"APISIX_NGINX_ADMIN_SSL_CLIENT_CERTIFICATE": "/var/cluster-certificate/ca.crt",
-	"APISIX_NGINX_ADMIN_SSL_VERIFY_CLIENT":      "on",
-	"APISIX_NGINX_ADMIN_SSL_VERIFY_DEPTH":       "3",
-}
-
 // -----------------------------------------------------------------------------
 // DataPlane Utils - Config
 // -----------------------------------------------------------------------------
 
 // SetDataPlaneDefaults sets any unset default configuration options on the
-// DataPlane. No configuration is overridden. EnvVars are sorted
-// lexographically as a side effect.
+// DataPlane: the container image and version. No configuration is overridden.
+// The configuration of the APISIX proxy itself is rendered into its
+// configuration file (see GenerateAPISIXConfig).
 func SetDataPlaneDefaults(spec *apisixoperatorv1alpha1.DataPlaneDeploymentOptions) {
-	for k, v := range APISIXDefaults {
-		envVar := corev1.EnvVar{Name: k, Value: v}
-		if !k8sutils.IsEnvVarPresent(envVar, spec.Env) {
-			spec.Env = append(spec.Env, envVar)
-		}
+	SetDefaultImage(&spec.DeploymentOptions)
+}
+
+// SetDefaultImage sets the default DataPlane image on the provided deployment
+// options if no image is set, along with the default version if no version is
+// set either. The version of a custom image is left untouched, as the image
+// may already be tagged.
+func SetDefaultImage(opts *apisixoperatorv1alpha1.DeploymentOptions) {
+	if opts.ContainerImage != nil && *opts.ContainerImage != "" {
+		return
+	}
+	image := consts.DefaultDataPlaneBaseImage
+	opts.ContainerImage = &image
+	if opts.Version == nil || *opts.Version == "" {
+		version := consts.DefaultDataPlaneTag
+		opts.Version = &version
+	}
+}
+
+// GetDeploymentMode returns the deployment mode of the provided DataPlane,
+// Deployment if none is configured.
+func GetDeploymentMode(dataplane *apisixoperatorv1alpha1.DataPlane) apisixoperatorv1alpha1.DataPlaneDeploymentMode {
+	if dataplane.Spec.DeploymentMode == "" {
+		return apisixoperatorv1alpha1.DataPlaneDeploymentModeDeployment
 	}
-	sort.Sort(k8sutils.SortableEnvVars(spec.Env))
+	return dataplane.Spec.DeploymentMode
 }
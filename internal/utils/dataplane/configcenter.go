@@ -0,0 +1,151 @@
+package dataplane
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - Config Center Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	// DatabaseOff is the database of proxies running in standalone mode.
+	DatabaseOff = "off"
+
+	// DatabaseEtcd is the database of proxies loading their configuration
+	// from etcd.
+	DatabaseEtcd = "etcd"
+
+	// EtcdAuthSecretUsernameKey and EtcdAuthSecretPasswordKey are the keys of
+	// the Secret referenced by the authSecretRef of the etcd config center.
+	EtcdAuthSecretUsernameKey = "username"
+	EtcdAuthSecretPasswordKey = "password"
+
+	// EtcdCASecretKey is the key of the Secret referenced by the caSecretRef
+	// of the etcd config center.
+	EtcdCASecretKey = "ca.crt"
+
+	// EtcdClientCertificateMountPath is the path the client certificate the
+	// proxy authenticates to etcd with is mounted at.
+	EtcdClientCertificateMountPath = "/etc/apisix/etcd/client"
+
+	// EtcdCAMountPath is the path the CA the certificate of etcd is verified
+	// with is mounted at.
+	EtcdCAMountPath = "/etc/apisix/etcd/ca"
+
+	etcdClientCertificateVolumeName = "etcd-client-certificate"
+	etcdCAVolumeName                = "etcd-ca"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - Config Center
+// -----------------------------------------------------------------------------
+
+// GetConfigCenterMode returns the config center mode of the provided DataPlane,
+// standalone if none is configured.
+func GetConfigCenterMode(dataplane *apisixoperatorv1alpha1.DataPlane) apisixoperatorv1alpha1.ConfigCenterMode {
+	if dataplane.Spec.ConfigCenter == nil || dataplane.Spec.ConfigCenter.Mode == "" {
+		return apisixoperatorv1alpha1.ConfigCenterModeStandalone
+	}
+	return dataplane.Spec.ConfigCenter.Mode
+}
+
+// DatabaseForConfigCenterMode returns the database the proxy is configured
+// with in the provided config center mode.
+func DatabaseForConfigCenterMode(mode apisixoperatorv1alpha1.ConfigCenterMode) string {
+	if mode == apisixoperatorv1alpha1.ConfigCenterModeEtcd {
+		return DatabaseEtcd
+	}
+	return DatabaseOff
+}
+
+// GenerateConfigCenterEnvVars returns the environment variables holding the
+// credentials the APISIX proxy authenticates to the etcd config center of the
+// provided DataPlane with, read from the referenced Secret. They are
+// referenced by its configuration (see GenerateAPISIXConfig), which cannot
+// hold them.
+func GenerateConfigCenterEnvVars(dataplane *apisixoperatorv1alpha1.DataPlane) []corev1.EnvVar {
+	if GetConfigCenterMode(dataplane) != apisixoperatorv1alpha1.ConfigCenterModeEtcd ||
+		dataplane.Spec.ConfigCenter.Etcd == nil || dataplane.Spec.ConfigCenter.Etcd.AuthSecretRef == nil {
+		return nil
+	}
+
+	authSecretRef := dataplane.Spec.ConfigCenter.Etcd.AuthSecretRef
+	return []corev1.EnvVar{
+		{
+			Name: consts.EnvVarApisixEtcdUser,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: *authSecretRef,
+					Key:                  EtcdAuthSecretUsernameKey,
+				},
+			},
+		},
+		{
+			Name: consts.EnvVarApisixEtcdPassword,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: *authSecretRef,
+					Key:                  EtcdAuthSecretPasswordKey,
+				},
+			},
+		},
+	}
+}
+
+// GenerateConfigCenterVolumes returns the volumes holding the certificates
+// referenced by the config center of the provided DataPlane, along with the
+// mounts of those volumes in the proxy container.
+func GenerateConfigCenterVolumes(dataplane *apisixoperatorv1alpha1.DataPlane) ([]corev1.Volume, []corev1.VolumeMount) {
+	if GetConfigCenterMode(dataplane) != apisixoperatorv1alpha1.ConfigCenterModeEtcd ||
+		dataplane.Spec.ConfigCenter.Etcd == nil || dataplane.Spec.ConfigCenter.Etcd.TLS == nil {
+		return nil, nil
+	}
+	tls := dataplane.Spec.ConfigCenter.Etcd.TLS
+
+	var (
+		volumes      []corev1.Volume
+		volumeMounts []corev1.VolumeMount
+	)
+	if tls.ClientCertificateSecretRef != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: etcdClientCertificateVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: tls.ClientCertificateSecretRef.Name,
+					Items: []corev1.KeyToPath{
+						{Key: corev1.TLSCertKey, Path: corev1.TLSCertKey},
+						{Key: corev1.TLSPrivateKeyKey, Path: corev1.TLSPrivateKeyKey},
+					},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      etcdClientCertificateVolumeName,
+			ReadOnly:  true,
+			MountPath: EtcdClientCertificateMountPath,
+		})
+	}
+	if tls.CASecretRef != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: etcdCAVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: tls.CASecretRef.Name,
+					Items: []corev1.KeyToPath{
+						{Key: EtcdCASecretKey, Path: EtcdCASecretKey},
+					},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      etcdCAVolumeName,
+			ReadOnly:  true,
+			MountPath: EtcdCAMountPath,
+		})
+	}
+	return volumes, volumeMounts
+}
@@ -0,0 +1,89 @@
+package dataplane
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+)
+
+func TestGenerateConfigCenterEnvVars(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		configCenter    *apisixoperatorv1alpha1.DataPlaneConfigCenter
+		expected        []corev1.EnvVar
+		expectedVolumes []string
+	}{
+		{
+			name: "no config center",
+		},
+		{
+			name: "standalone",
+			configCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+				Mode: apisixoperatorv1alpha1.ConfigCenterModeStandalone,
+			},
+		},
+		{
+			name: "etcd",
+			configCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+				Mode: apisixoperatorv1alpha1.ConfigCenterModeEtcd,
+				Etcd: &apisixoperatorv1alpha1.EtcdConfigCenter{
+					Endpoints: []string{"http://etcd-0:2379", "http://etcd-1:2379"},
+					Prefix:    "/apisix",
+				},
+			},
+		},
+		{
+			name: "etcd with TLS and auth",
+			configCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+				Mode: apisixoperatorv1alpha1.ConfigCenterModeEtcd,
+				Etcd: &apisixoperatorv1alpha1.EtcdConfigCenter{
+					Endpoints:     []string{"https://etcd:2379"},
+					AuthSecretRef: &corev1.LocalObjectReference{Name: "etcd-auth"},
+					TLS: &apisixoperatorv1alpha1.EtcdTLSConfig{
+						ClientCertificateSecretRef: &corev1.LocalObjectReference{Name: "etcd-client"},
+						CASecretRef:                &corev1.LocalObjectReference{Name: "etcd-ca"},
+						SNI:                        "etcd.example",
+						InsecureSkipVerify:         true,
+					},
+				},
+			},
+			expected: []corev1.EnvVar{
+				{
+					Name: consts.EnvVarApisixEtcdUser,
+					ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "etcd-auth"},
+						Key:                  EtcdAuthSecretUsernameKey,
+					}},
+				},
+				{
+					Name: consts.EnvVarApisixEtcdPassword,
+					ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "etcd-auth"},
+						Key:                  EtcdAuthSecretPasswordKey,
+					}},
+				},
+			},
+			expectedVolumes: []string{"etcd-client", "etcd-ca"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dataplane := &apisixoperatorv1alpha1.DataPlane{
+				Spec: apisixoperatorv1alpha1.DataPlaneSpec{ConfigCenter: tt.configCenter},
+			}
+			require.Equal(t, tt.expected, GenerateConfigCenterEnvVars(dataplane))
+
+			volumes, volumeMounts := GenerateConfigCenterVolumes(dataplane)
+			require.Len(t, volumes, len(tt.expectedVolumes))
+			require.Len(t, volumeMounts, len(tt.expectedVolumes))
+			for i, secretName := range tt.expectedVolumes {
+				require.Equal(t, secretName, volumes[i].Secret.SecretName)
+				require.Equal(t, volumes[i].Name, volumeMounts[i].Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package dataplane
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - Ports Vars & Consts
+// -----------------------------------------------------------------------------
+
+const (
+	// proxyPortOffset is added to privileged ports exposed by the DataPlane
+	// Service to obtain the port the APISIX proxy listens on, so that the
+	// proxy does not need to run as root (80 -> 9080, 443 -> 9443).
+	proxyPortOffset = 9000
+
+	// privilegedPortsUpperBound is the first port which is not privileged.
+	privilegedPortsUpperBound = 1024
+)
+
+// DefaultServicePorts are the ports exposed by a DataPlane which doesn't
+// configure any port explicitly.
+var DefaultServicePorts = []apisixoperatorv1alpha1.DataPlaneServicePort{
+	{Port: DefaultHTTPPort, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+	{Port: DefaultHTTPSPort, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTPS},
+}
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - Ports
+// -----------------------------------------------------------------------------
+
+// GetServicePorts returns the ports exposed by the Service of the provided
+// DataPlane, falling back to DefaultServicePorts if none is configured.
+func GetServicePorts(dataplane *apisixoperatorv1alpha1.DataPlane) []apisixoperatorv1alpha1.DataPlaneServicePort {
+	services := dataplane.Spec.Network.Services
+	if services == nil || services.Ingress == nil || len(services.Ingress.Ports) == 0 {
+		return DefaultServicePorts
+	}
+	return services.Ingress.Ports
+}
+
+// ProxyPortForServicePort returns the port the APISIX proxy listens on to
+// serve the provided Service port.
+func ProxyPortForServicePort(port int32) int32 {
+	if port < privilegedPortsUpperBound {
+		return port + proxyPortOffset
+	}
+	return port
+}
+
+// ProxyPortFor returns the port the APISIX proxy listens on to serve the
+// provided DataPlane port: its target port if set, the Service port offset
+// for privileged ports otherwise.
+func ProxyPortFor(port apisixoperatorv1alpha1.DataPlaneServicePort) int32 {
+	if port.TargetPort != nil {
+		return *port.TargetPort
+	}
+	return ProxyPortForServicePort(port.Port)
+}
+
+// GetAdminPort returns the port the Admin API of the proxy of the provided
+// DataPlane listens on, DefaultAPISIXAdminPort if none is configured.
+func GetAdminPort(dataplane *apisixoperatorv1alpha1.DataPlane) int32 {
+	config := dataplane.Spec.Config
+	if config == nil || config.Admin == nil || config.Admin.Port == nil {
+		return DefaultAPISIXAdminPort
+	}
+	return *config.Admin.Port
+}
+
+// IsReservedProxyPort returns true if the provided port is used by the APISIX
+// proxy of the provided DataPlane for purposes other than proxying traffic: by
+// its Admin API or its status API.
+func IsReservedProxyPort(dataplane *apisixoperatorv1alpha1.DataPlane, port int32) bool {
+	return port == GetAdminPort(dataplane) || port == StatusPort
+}
+
+// ServicePortName returns the name used for the Service and container ports
+// serving the provided DataPlane port, e.g. "https-443".
+func ServicePortName(port apisixoperatorv1alpha1.DataPlaneServicePort) string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(string(port.Protocol)), port.Port)
+}
+
+// TransportProtocolFor returns the transport protocol used by the provided
+// proxy protocol.
+func TransportProtocolFor(protocol apisixoperatorv1alpha1.ProxyProtocol) corev1.Protocol {
+	if protocol == apisixoperatorv1alpha1.ProxyProtocolUDP {
+		return corev1.ProtocolUDP
+	}
+	return corev1.ProtocolTCP
+}
@@ -0,0 +1,42 @@
+package dataplane
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	"github.com/chever-john/apisix-operator/internal/consts"
+)
+
+// -----------------------------------------------------------------------------
+// DataPlane Utils - Services
+// -----------------------------------------------------------------------------
+
+// AdminServiceName returns the name of the ClusterIP Service exposing the
+// Admin API of the provided DataPlane to its ControlPlane. It's deterministic
+// so that ControlPlanes can address it before it's created.
+func AdminServiceName(dataplaneName string) string {
+	return fmt.Sprintf("%s-%s-admin", consts.DataPlanePrefix, dataplaneName)
+}
+
+// GetIngressServiceOptions returns the options of the Service exposing the
+// proxy of the provided DataPlane to ingress traffic, empty if none are
+// configured.
+func GetIngressServiceOptions(dataplane *apisixoperatorv1alpha1.DataPlane) apisixoperatorv1alpha1.DataPlaneServiceOptions {
+	services := dataplane.Spec.Network.Services
+	if services == nil || services.Ingress == nil {
+		return apisixoperatorv1alpha1.DataPlaneServiceOptions{}
+	}
+	return *services.Ingress
+}
+
+// GetIngressServiceType returns the type of the Service exposing the proxy of
+// the provided DataPlane to ingress traffic, LoadBalancer if none is
+// configured.
+func GetIngressServiceType(dataplane *apisixoperatorv1alpha1.DataPlane) corev1.ServiceType {
+	if serviceType := GetIngressServiceOptions(dataplane).Type; serviceType != "" {
+		return serviceType
+	}
+	return corev1.ServiceTypeLoadBalancer
+}
@@ -0,0 +1,82 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+)
+
+// -----------------------------------------------------------------------------
+// Gateway Utils - GatewayClass
+// -----------------------------------------------------------------------------
+
+// APISIXConfigurationKind is the kind that a GatewayClass parametersRef must
+// use to be accepted by this operator.
+const APISIXConfigurationKind = "APISIXConfiguration"
+
+// IsGatewayClassControlled indicates whether or not the provided GatewayClass
+// is meant to be handled by the controller with the given name.
+func IsGatewayClassControlled(gatewayClass *gatewayv1alpha2.GatewayClass, controllerName string) bool {
+	return string(gatewayClass.Spec.ControllerName) == controllerName
+}
+
+// IsParametersRefForAPISIXConfiguration indicates whether or not the provided
+// parametersRef points to the given APISIXConfiguration object.
+func IsParametersRefForAPISIXConfiguration(
+	ref *gatewayv1alpha2.ParametersReference,
+	apisixConfiguration *apisixoperatorv1alpha1.APISIXConfiguration,
+) bool {
+	return ref != nil &&
+		string(ref.Group) == apisixoperatorv1alpha1.SchemeGroupVersion.Group &&
+		string(ref.Kind) == APISIXConfigurationKind &&
+		ref.Namespace != nil && string(*ref.Namespace) == apisixConfiguration.Namespace &&
+		ref.Name == apisixConfiguration.Name
+}
+
+// GetAPISIXConfigurationForGatewayClass retrieves the APISIXConfiguration object
+// referenced by the parametersRef of a GatewayClass. It returns an error wrapping
+// ErrObjectMissingParametersRef if the GatewayClass has no parametersRef, and an
+// error wrapping ErrInvalidParametersRef if the parametersRef does not point to
+// an existing APISIXConfiguration.
+func GetAPISIXConfigurationForGatewayClass(
+	ctx context.Context,
+	c client.Client,
+	gatewayClass *gatewayv1alpha2.GatewayClass,
+) (*apisixoperatorv1alpha1.APISIXConfiguration, error) {
+	ref := gatewayClass.Spec.ParametersRef
+	if ref == nil {
+		return nil, fmt.Errorf("%w, gatewayclass = %s", operatorerrors.ErrObjectMissingParametersRef, gatewayClass.Name)
+	}
+
+	if string(ref.Group) != apisixoperatorv1alpha1.SchemeGroupVersion.Group || string(ref.Kind) != APISIXConfigurationKind {
+		return nil, fmt.Errorf("%w: expected %s/%s, found %s/%s",
+			operatorerrors.ErrInvalidParametersRef,
+			apisixoperatorv1alpha1.SchemeGroupVersion.Group, APISIXConfigurationKind,
+			ref.Group, ref.Kind,
+		)
+	}
+
+	if ref.Namespace == nil || *ref.Namespace == "" {
+		return nil, fmt.Errorf("%w: namespace must be set for %s %s",
+			operatorerrors.ErrInvalidParametersRef, APISIXConfigurationKind, ref.Name)
+	}
+
+	apisixConfiguration := &apisixoperatorv1alpha1.APISIXConfiguration{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: string(*ref.Namespace), Name: ref.Name}, apisixConfiguration)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %s %s/%s not found",
+				operatorerrors.ErrInvalidParametersRef, APISIXConfigurationKind, *ref.Namespace, ref.Name)
+		}
+		return nil, err
+	}
+
+	return apisixConfiguration, nil
+}
@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
+	operatorerrors "github.com/chever-john/apisix-operator/internal/errors"
+)
+
+func TestGetAPISIXConfigurationForGatewayClass(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, apisixoperatorv1alpha1.AddToScheme(scheme))
+	c := fakeclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(&apisixoperatorv1alpha1.APISIXConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test-config"},
+		}).
+		Build()
+
+	namespace := gatewayv1alpha2.Namespace("default")
+	otherNamespace := gatewayv1alpha2.Namespace("other")
+	group := gatewayv1alpha2.Group(apisixoperatorv1alpha1.SchemeGroupVersion.Group)
+
+	for _, tt := range []struct {
+		name        string
+		ref         *gatewayv1alpha2.ParametersReference
+		expectedErr error
+	}{
+		{
+			name:        "gatewayclass without parametersRef",
+			expectedErr: operatorerrors.ErrObjectMissingParametersRef,
+		},
+		{
+			name: "parametersRef pointing at an existing APISIXConfiguration",
+			ref: &gatewayv1alpha2.ParametersReference{
+				Group:     group,
+				Kind:      APISIXConfigurationKind,
+				Name:      "test-config",
+				Namespace: &namespace,
+			},
+		},
+		{
+			name: "parametersRef with an unsupported kind",
+			ref: &gatewayv1alpha2.ParametersReference{
+				Group:     group,
+				Kind:      "ConfigMap",
+				Name:      "test-config",
+				Namespace: &namespace,
+			},
+			expectedErr: operatorerrors.ErrInvalidParametersRef,
+		},
+		{
+			name: "parametersRef with an unsupported group",
+			ref: &gatewayv1alpha2.ParametersReference{
+				Group:     "example.com",
+				Kind:      APISIXConfigurationKind,
+				Name:      "test-config",
+				Namespace: &namespace,
+			},
+			expectedErr: operatorerrors.ErrInvalidParametersRef,
+		},
+		{
+			name: "parametersRef without namespace",
+			ref: &gatewayv1alpha2.ParametersReference{
+				Group: group,
+				Kind:  APISIXConfigurationKind,
+				Name:  "test-config",
+			},
+			expectedErr: operatorerrors.ErrInvalidParametersRef,
+		},
+		{
+			name: "parametersRef pointing at a missing APISIXConfiguration",
+			ref: &gatewayv1alpha2.ParametersReference{
+				Group:     group,
+				Kind:      APISIXConfigurationKind,
+				Name:      "test-config",
+				Namespace: &otherNamespace,
+			},
+			expectedErr: operatorerrors.ErrInvalidParametersRef,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			gatewayClass := &gatewayv1alpha2.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-gatewayclass"},
+				Spec: gatewayv1alpha2.GatewayClassSpec{
+					ControllerName: "apisix.apache.org/apisix-operator",
+					ParametersRef:  tt.ref,
+				},
+			}
+			apisixConfiguration, err := GetAPISIXConfigurationForGatewayClass(context.Background(), c, gatewayClass)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "test-config", apisixConfiguration.Name)
+			require.True(t, IsParametersRefForAPISIXConfiguration(tt.ref, apisixConfiguration))
+		})
+	}
+}
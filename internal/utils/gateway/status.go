@@ -36,3 +36,15 @@ func IsReady(gateway *gatewayv1alpha2.Gateway) bool {
 	}
 	return false
 }
+
+// IsAccepted indicates whether or not the provided GatewayClass object was
+// marked as accepted by the controller.
+func IsAccepted(gatewayClass *gatewayv1alpha2.GatewayClass) bool {
+	for _, cond := range gatewayClass.Status.Conditions {
+		if cond.Type == string(gatewayv1alpha2.GatewayClassConditionStatusAccepted) &&
+			cond.Status == metav1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
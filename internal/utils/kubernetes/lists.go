@@ -4,7 +4,9 @@ import (
 	"context"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,6 +45,72 @@ func ListDeploymentsForOwner(
 	return deployments, nil
 }
 
+// ListDaemonSetsForOwner is a helper function to map a list of DaemonSets
+// by label and reduce by OwnerReference UID and namespace to efficiently list
+// only the objects owned by the provided UID.
+func ListDaemonSetsForOwner(
+	ctx context.Context,
+	c client.Client,
+	requiredLabel string,
+	requiredValue string,
+	namespace string,
+	uid types.UID,
+) ([]appsv1.DaemonSet, error) {
+	daemonSetList := &appsv1.DaemonSetList{}
+
+	err := c.List(
+		ctx,
+		daemonSetList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{requiredLabel: requiredValue},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	daemonSets := make([]appsv1.DaemonSet, 0)
+	for _, daemonSet := range daemonSetList.Items {
+		if IsOwnedByRefUID(&daemonSet.ObjectMeta, uid) {
+			daemonSets = append(daemonSets, daemonSet)
+		}
+	}
+
+	return daemonSets, nil
+}
+
+// ListStatefulSetsForOwner is a helper function to map a list of StatefulSets
+// by label and reduce by OwnerReference UID and namespace to efficiently list
+// only the objects owned by the provided UID.
+func ListStatefulSetsForOwner(
+	ctx context.Context,
+	c client.Client,
+	requiredLabel string,
+	requiredValue string,
+	namespace string,
+	uid types.UID,
+) ([]appsv1.StatefulSet, error) {
+	statefulSetList := &appsv1.StatefulSetList{}
+
+	err := c.List(
+		ctx,
+		statefulSetList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{requiredLabel: requiredValue},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	statefulSets := make([]appsv1.StatefulSet, 0)
+	for _, statefulSet := range statefulSetList.Items {
+		if IsOwnedByRefUID(&statefulSet.ObjectMeta, uid) {
+			statefulSets = append(statefulSets, statefulSet)
+		}
+	}
+
+	return statefulSets, nil
+}
+
 // ListServicesForOwner is a helper function to map a list of Services
 // by label and reduce by OwnerReference UID and namespace to efficiently list
 // only the objects owned by the provided UID.
@@ -76,6 +144,105 @@ func ListServicesForOwner(
 	return services, nil
 }
 
+// ListConfigMapsForOwner is a helper function to map a list of ConfigMaps
+// by label and reduce by OwnerReference UID and namespace to efficiently list
+// only the objects owned by the provided UID.
+func ListConfigMapsForOwner(
+	ctx context.Context,
+	c client.Client,
+	requiredLabel string,
+	requiredValue string,
+	namespace string,
+	uid types.UID,
+) ([]corev1.ConfigMap, error) {
+	configMapList := &corev1.ConfigMapList{}
+
+	err := c.List(
+		ctx,
+		configMapList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{requiredLabel: requiredValue},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	configMaps := make([]corev1.ConfigMap, 0)
+	for _, configMap := range configMapList.Items {
+		if IsOwnedByRefUID(&configMap.ObjectMeta, uid) {
+			configMaps = append(configMaps, configMap)
+		}
+	}
+
+	return configMaps, nil
+}
+
+// ListHorizontalPodAutoscalersForOwner is a helper function to map a list of
+// HorizontalPodAutoscalers by label and reduce by OwnerReference UID and
+// namespace to efficiently list only the objects owned by the provided UID.
+func ListHorizontalPodAutoscalersForOwner(
+	ctx context.Context,
+	c client.Client,
+	requiredLabel string,
+	requiredValue string,
+	namespace string,
+	uid types.UID,
+) ([]autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpaList := &autoscalingv2.HorizontalPodAutoscalerList{}
+
+	err := c.List(
+		ctx,
+		hpaList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{requiredLabel: requiredValue},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hpas := make([]autoscalingv2.HorizontalPodAutoscaler, 0)
+	for _, hpa := range hpaList.Items {
+		if IsOwnedByRefUID(&hpa.ObjectMeta, uid) {
+			hpas = append(hpas, hpa)
+		}
+	}
+
+	return hpas, nil
+}
+
+// ListPodDisruptionBudgetsForOwner is a helper function to map a list of
+// PodDisruptionBudgets by label and reduce by OwnerReference UID and namespace
+// to efficiently list only the objects owned by the provided UID.
+func ListPodDisruptionBudgetsForOwner(
+	ctx context.Context,
+	c client.Client,
+	requiredLabel string,
+	requiredValue string,
+	namespace string,
+	uid types.UID,
+) ([]policyv1.PodDisruptionBudget, error) {
+	pdbList := &policyv1.PodDisruptionBudgetList{}
+
+	err := c.List(
+		ctx,
+		pdbList,
+		client.InNamespace(namespace),
+		client.MatchingLabels{requiredLabel: requiredValue},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pdbs := make([]policyv1.PodDisruptionBudget, 0)
+	for _, pdb := range pdbList.Items {
+		if IsOwnedByRefUID(&pdb.ObjectMeta, uid) {
+			pdbs = append(pdbs, pdb)
+		}
+	}
+
+	return pdbs, nil
+}
+
 // ListServiceAccountsForOwner is a helper function to map a list of ServiceAccounts
 // by label and reduce by OwnerReference UID and namespace to efficiently list
 // only the objects owned by the provided UID.
@@ -0,0 +1,77 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// -----------------------------------------------------------------------------
+// Kubernetes Utils - PodTemplateSpec
+// -----------------------------------------------------------------------------
+
+// StrategicMergePatchPodTemplateSpec returns the provided base pod template
+// with the provided patch strategically merged over it: containers, volumes
+// and the other keyed lists are merged by key, e.g. by name for containers.
+// The base is returned untouched if the patch is nil.
+func StrategicMergePatchPodTemplateSpec(base, patch *corev1.PodTemplateSpec) (*corev1.PodTemplateSpec, error) {
+	if patch == nil {
+		return base, nil
+	}
+
+	baseJSON, err := json.Marshal(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the pod template: %w", err)
+	}
+	patchJSON, err := marshalPatch(patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the pod template patch: %w", err)
+	}
+
+	mergedJSON, err := strategicpatch.StrategicMergePatch(baseJSON, patchJSON, corev1.PodTemplateSpec{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge the pod template patch: %w", err)
+	}
+
+	merged := &corev1.PodTemplateSpec{}
+	if err := json.Unmarshal(mergedJSON, merged); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the merged pod template: %w", err)
+	}
+	return merged, nil
+}
+
+// marshalPatch marshals the provided pod template as a patch. The fields
+// marshalled as null despite being unset (e.g. the containers of a patch only
+// setting the node selector) are dropped, as they'd delete the patched fields.
+func marshalPatch(patch *corev1.PodTemplateSpec) ([]byte, error) {
+	patchJSON, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(patchJSON, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(dropNullFields(fields))
+}
+
+func dropNullFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if field == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = dropNullFields(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = dropNullFields(v[i])
+		}
+	}
+	return value
+}
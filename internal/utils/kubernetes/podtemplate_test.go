@@ -0,0 +1,103 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStrategicMergePatchPodTemplateSpec(t *testing.T) {
+	base := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"app": "test"},
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{Name: "config"}},
+			Containers: []corev1.Container{{
+				Name:  "proxy",
+				Image: "apache/apisix:3.0.0-debian",
+				Env:   []corev1.EnvVar{{Name: "A", Value: "a"}},
+			}},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		patch    *corev1.PodTemplateSpec
+		expected func() *corev1.PodTemplateSpec
+	}{
+		{
+			name:     "no patch",
+			expected: base.DeepCopy,
+		},
+		{
+			name: "pod fields are set without dropping the containers",
+			patch: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeSelector:      map[string]string{"zone": "a"},
+					PriorityClassName: "high",
+				},
+			},
+			expected: func() *corev1.PodTemplateSpec {
+				expected := base.DeepCopy()
+				expected.Spec.NodeSelector = map[string]string{"zone": "a"}
+				expected.Spec.PriorityClassName = "high"
+				return expected
+			},
+		},
+		{
+			name: "containers are merged by name and sidecars are added",
+			patch: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"team": "gateway"},
+				},
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{{Name: "logs"}},
+					Containers: []corev1.Container{
+						{
+							Name: "proxy",
+							Env:  []corev1.EnvVar{{Name: "B", Value: "b"}},
+							Resources: corev1.ResourceRequirements{
+								Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+							},
+						},
+						{Name: "logger", Image: "busybox"},
+					},
+				},
+			},
+			expected: func() *corev1.PodTemplateSpec {
+				expected := base.DeepCopy()
+				expected.Labels["team"] = "gateway"
+				expected.Spec.Volumes = []corev1.Volume{{Name: "logs"}, {Name: "config"}}
+				expected.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "B", Value: "b"}, {Name: "A", Value: "a"}}
+				expected.Spec.Containers[0].Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+				expected.Spec.Containers = append(expected.Spec.Containers, corev1.Container{Name: "logger", Image: "busybox"})
+				return expected
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			merged, err := StrategicMergePatchPodTemplateSpec(base, tc.patch)
+			require.NoError(t, err)
+			expected := tc.expected()
+			assert.Equal(t, expected.Labels, merged.Labels)
+			assert.Equal(t, expected.Spec.NodeSelector, merged.Spec.NodeSelector)
+			assert.Equal(t, expected.Spec.PriorityClassName, merged.Spec.PriorityClassName)
+			assert.Equal(t, expected.Spec.Volumes, merged.Spec.Volumes)
+			require.Len(t, merged.Spec.Containers, len(expected.Spec.Containers))
+			for i, container := range expected.Spec.Containers {
+				assert.Equal(t, container.Name, merged.Spec.Containers[i].Name)
+				assert.Equal(t, container.Image, merged.Spec.Containers[i].Image)
+				assert.Equal(t, container.Env, merged.Spec.Containers[i].Env)
+				assert.True(t, container.Resources.Limits.Cpu().Equal(*merged.Spec.Containers[i].Resources.Limits.Cpu()))
+			}
+		})
+	}
+}
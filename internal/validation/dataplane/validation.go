@@ -4,14 +4,18 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net"
+	"net/url"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 	"github.com/chever-john/apisix-operator/internal/consts"
+	dataplaneutils "github.com/chever-john/apisix-operator/internal/utils/dataplane"
 )
 
 // Validator validates DataPlane objects.
@@ -26,16 +30,316 @@ func NewValidator(c client.Client) *Validator {
 
 // Validate validates a DataPlane object and return the first validation error found.
 func (v *Validator) Validate(dataplane *apisixoperatorv1alpha1.DataPlane) error {
-	err := v.ValidateDeployOptions(dataplane.Namespace, &dataplane.Spec.DeploymentOptions)
-	if err != nil {
+	if err := v.ValidateConfigCenter(dataplane.Namespace, dataplane.Spec.ConfigCenter); err != nil {
+		return err
+	}
+	if err := v.ValidateConfig(dataplane); err != nil {
+		return err
+	}
+	if err := v.ValidateScaling(dataplane.Spec.Scaling); err != nil {
+		return err
+	}
+	if err := v.ValidateDeploymentMode(&dataplane.Spec.DataPlaneDeploymentOptions); err != nil {
 		return err
 	}
-	// prepared for more validations
+	if err := v.ValidateIngressService(dataplane); err != nil {
+		return err
+	}
+	mode := dataplaneutils.GetConfigCenterMode(dataplane)
+	return v.validateDeployOptions(dataplane.Namespace, &dataplane.Spec.DeploymentOptions, mode)
+}
+
+// ValidateUpdate validates a DataPlane object being updated from the provided
+// old DataPlane object and return the first validation error found.
+func (v *Validator) ValidateUpdate(dataplane, oldDataplane *apisixoperatorv1alpha1.DataPlane) error {
+	if err := v.Validate(dataplane); err != nil {
+		return err
+	}
+	return v.ValidateManagedEtcdUpdate(dataplane.Spec.ConfigCenter, oldDataplane.Spec.ConfigCenter)
+}
+
+// ValidateManagedEtcdUpdate validates the update of the managed etcd cluster
+// of a DataPlane object: its members are bootstrapped statically, hence their
+// number can't be changed once the cluster is provisioned.
+func (v *Validator) ValidateManagedEtcdUpdate(configCenter, oldConfigCenter *apisixoperatorv1alpha1.DataPlaneConfigCenter) error {
+	if configCenter == nil || configCenter.Managed == nil ||
+		oldConfigCenter == nil || oldConfigCenter.Managed == nil {
+		return nil
+	}
+
+	replicas, oldReplicas := managedEtcdReplicas(configCenter.Managed), managedEtcdReplicas(oldConfigCenter.Managed)
+	if replicas != oldReplicas {
+		return fmt.Errorf("managed etcd cluster members of dataplane can't be changed from %d to %d", oldReplicas, replicas)
+	}
 	return nil
 }
 
-// ValidateDeployOptions validates the DeploymentOptions field of DataPlane object.
+// ValidateDeployOptions validates the DeploymentOptions field of DataPlane object
+// running in standalone mode.
 func (v *Validator) ValidateDeployOptions(namespace string, opts *apisixoperatorv1alpha1.DeploymentOptions) error {
+	return v.validateDeployOptions(namespace, opts, apisixoperatorv1alpha1.ConfigCenterModeStandalone)
+}
+
+// ValidateConfigCenter validates the config center of a DataPlane object: etcd
+// mode requires either the etcd endpoints or a managed etcd cluster, and the
+// Secrets it references must hold the expected keys.
+func (v *Validator) ValidateConfigCenter(namespace string, configCenter *apisixoperatorv1alpha1.DataPlaneConfigCenter) error {
+	if configCenter == nil {
+		return nil
+	}
+
+	switch configCenter.Mode {
+	case "", apisixoperatorv1alpha1.ConfigCenterModeStandalone:
+		if configCenter.Etcd != nil || configCenter.Managed != nil {
+			return fmt.Errorf("etcd can't be configured in %s config center mode", apisixoperatorv1alpha1.ConfigCenterModeStandalone)
+		}
+		return nil
+	case apisixoperatorv1alpha1.ConfigCenterModeEtcd:
+	default:
+		return fmt.Errorf("config center mode %s of dataplane not supported", configCenter.Mode)
+	}
+
+	if configCenter.Managed != nil {
+		if configCenter.Etcd != nil {
+			return fmt.Errorf("etcd endpoints can't be configured along with a managed etcd cluster")
+		}
+		if replicas := configCenter.Managed.Replicas; replicas != nil && *replicas < 1 {
+			return fmt.Errorf("managed etcd cluster requires at least 1 member, got %d", *replicas)
+		}
+		return nil
+	}
+
+	etcd := configCenter.Etcd
+	if etcd == nil || len(etcd.Endpoints) == 0 {
+		return fmt.Errorf("etcd endpoints or a managed etcd cluster are required in %s config center mode",
+			apisixoperatorv1alpha1.ConfigCenterModeEtcd)
+	}
+	for _, endpoint := range etcd.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("etcd endpoint %s must be an http or https URL", endpoint)
+		}
+		if etcd.TLS != nil && u.Scheme != "https" {
+			return fmt.Errorf("etcd endpoint %s must use https when TLS is configured", endpoint)
+		}
+	}
+
+	if etcd.AuthSecretRef != nil {
+		if err := v.validateSecretKeys(namespace, etcd.AuthSecretRef.Name,
+			dataplaneutils.EtcdAuthSecretUsernameKey, dataplaneutils.EtcdAuthSecretPasswordKey); err != nil {
+			return err
+		}
+	}
+	if etcd.TLS != nil {
+		if ref := etcd.TLS.ClientCertificateSecretRef; ref != nil {
+			if err := v.validateSecretKeys(namespace, ref.Name, corev1.TLSCertKey, corev1.TLSPrivateKeyKey); err != nil {
+				return err
+			}
+		}
+		if ref := etcd.TLS.CASecretRef; ref != nil {
+			if err := v.validateSecretKeys(namespace, ref.Name, dataplaneutils.EtcdCASecretKey); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateConfig validates the APISIX config of a DataPlane object: the
+// number of worker processes, the addresses of the allow and trusted lists,
+// and the ports the proxy listens on (see validatePorts).
+func (v *Validator) ValidateConfig(dataplane *apisixoperatorv1alpha1.DataPlane) error {
+	if config := dataplane.Spec.Config; config != nil {
+		for _, plugin := range config.Plugins {
+			if strings.TrimSpace(plugin) == "" {
+				return fmt.Errorf("plugin names of dataplane can't be empty")
+			}
+		}
+
+		if workerProcesses := config.WorkerProcesses; workerProcesses != nil {
+			if workerProcesses.Type == intstr.String && workerProcesses.StrVal != "auto" ||
+				workerProcesses.Type == intstr.Int && workerProcesses.IntVal < 1 {
+				return fmt.Errorf("worker processes of dataplane must be a positive number or auto, got %s", workerProcesses.String())
+			}
+		}
+
+		if config.Admin != nil {
+			if err := validateAddresses("admin API allow list", config.Admin.AllowList); err != nil {
+				return err
+			}
+		}
+		if config.RealIP != nil {
+			if err := validateAddresses("real IP trusted addresses", config.RealIP.TrustedAddresses); err != nil {
+				return err
+			}
+		}
+	}
+
+	return validatePorts(dataplane)
+}
+
+// ValidateScaling validates the scaling options of a DataPlane object: the
+// lower limit of the number of pods scaled horizontally can't exceed their
+// upper limit.
+func (v *Validator) ValidateScaling(scaling *apisixoperatorv1alpha1.DataPlaneScaling) error {
+	if scaling == nil || scaling.Horizontal == nil {
+		return nil
+	}
+
+	horizontal := scaling.Horizontal
+	if horizontal.MinReplicas != nil && *horizontal.MinReplicas > horizontal.MaxReplicas {
+		return fmt.Errorf("min replicas %d of dataplane can't exceed its max replicas %d",
+			*horizontal.MinReplicas, horizontal.MaxReplicas)
+	}
+	return nil
+}
+
+// ValidateDeploymentMode validates the deployment mode of a DataPlane object:
+// the pods deployed by a DaemonSet follow the nodes, hence they can't be
+// scaled.
+func (v *Validator) ValidateDeploymentMode(opts *apisixoperatorv1alpha1.DataPlaneDeploymentOptions) error {
+	if opts.DeploymentMode != apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet {
+		return nil
+	}
+
+	if opts.Replicas != nil {
+		return fmt.Errorf("replicas of dataplane can't be set in %s deployment mode", opts.DeploymentMode)
+	}
+	if opts.Scaling != nil && opts.Scaling.Horizontal != nil {
+		return fmt.Errorf("dataplane can't be scaled horizontally in %s deployment mode", opts.DeploymentMode)
+	}
+	return nil
+}
+
+// ValidateIngressService validates the options of the Service exposing the
+// proxy of a DataPlane object: the node ports, the external traffic policy and
+// the load balancer source ranges only apply to the Service types exposing it
+// outside of the cluster.
+func (v *Validator) ValidateIngressService(dataplane *apisixoperatorv1alpha1.DataPlane) error {
+	opts := dataplaneutils.GetIngressServiceOptions(dataplane)
+	serviceType := dataplaneutils.GetIngressServiceType(dataplane)
+
+	if serviceType == corev1.ServiceTypeClusterIP {
+		for _, port := range opts.Ports {
+			if port.NodePort != nil {
+				return fmt.Errorf("node port %d of dataplane port %d can't be set on a %s service",
+					*port.NodePort, port.Port, serviceType)
+			}
+		}
+		if opts.ExternalTrafficPolicy != "" {
+			return fmt.Errorf("external traffic policy of dataplane can't be set on a %s service", serviceType)
+		}
+	}
+
+	// a node port can only be shared by ports of different transport protocols.
+	type nodePortKey struct {
+		nodePort  int32
+		transport corev1.Protocol
+	}
+	nodePorts := make(map[nodePortKey]int32, len(opts.Ports))
+	for _, port := range opts.Ports {
+		if port.NodePort == nil {
+			continue
+		}
+		key := nodePortKey{*port.NodePort, dataplaneutils.TransportProtocolFor(port.Protocol)}
+		if other, ok := nodePorts[key]; ok {
+			return fmt.Errorf("node port %d of dataplane port %d is already used by its port %d",
+				*port.NodePort, port.Port, other)
+		}
+		nodePorts[key] = port.Port
+	}
+
+	if len(opts.LoadBalancerSourceRanges) > 0 {
+		if serviceType != corev1.ServiceTypeLoadBalancer {
+			return fmt.Errorf("load balancer source ranges of dataplane can't be set on a %s service", serviceType)
+		}
+		for _, sourceRange := range opts.LoadBalancerSourceRanges {
+			if _, _, err := net.ParseCIDR(sourceRange); err != nil {
+				return fmt.Errorf("load balancer source ranges of dataplane must be CIDRs, got %s", sourceRange)
+			}
+		}
+	}
+	return nil
+}
+
+// managedEtcdReplicas returns the number of members of the provided managed
+// etcd cluster.
+func managedEtcdReplicas(managed *apisixoperatorv1alpha1.ManagedEtcdConfigCenter) int32 {
+	if managed.Replicas != nil {
+		return *managed.Replicas
+	}
+	return consts.DefaultEtcdReplicas
+}
+
+// validatePorts checks the ports the proxy of the provided DataPlane listens
+// on don't collide: the port of the Admin API must not be reserved, and every
+// DataPlane port must be served on its own port, which is neither the port of
+// the Admin API nor a reserved one. The ports of different transport protocols
+// can be served on the same port.
+func validatePorts(dataplane *apisixoperatorv1alpha1.DataPlane) error {
+	adminPort := dataplaneutils.GetAdminPort(dataplane)
+	if adminPort == dataplaneutils.StatusPort {
+		return fmt.Errorf("admin API port %d of dataplane is reserved", adminPort)
+	}
+
+	type proxyPortKey struct {
+		proxyPort int32
+		transport corev1.Protocol
+	}
+	proxyPorts := make(map[proxyPortKey]int32)
+	for _, port := range dataplaneutils.GetServicePorts(dataplane) {
+		proxyPort := dataplaneutils.ProxyPortFor(port)
+		if proxyPort == adminPort {
+			return fmt.Errorf("admin API port %d of dataplane is already used by its %s port %d", adminPort, port.Protocol, port.Port)
+		}
+		if dataplaneutils.IsReservedProxyPort(dataplane, proxyPort) {
+			return fmt.Errorf("%s port %d of dataplane is served on port %d, which is reserved", port.Protocol, port.Port, proxyPort)
+		}
+		key := proxyPortKey{proxyPort, dataplaneutils.TransportProtocolFor(port.Protocol)}
+		if other, ok := proxyPorts[key]; ok {
+			return fmt.Errorf("%s port %d of dataplane is served on port %d, which is already used by its port %d",
+				port.Protocol, port.Port, proxyPort, other)
+		}
+		proxyPorts[key] = port.Port
+	}
+	return nil
+}
+
+// validateAddresses checks the provided addresses are IP addresses or CIDRs.
+func validateAddresses(field string, addresses []string) error {
+	for _, address := range addresses {
+		if net.ParseIP(address) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(address); err != nil {
+			return fmt.Errorf("%s of dataplane must be IP addresses or CIDRs, got %s", field, address)
+		}
+	}
+	return nil
+}
+
+// validateSecretKeys checks the provided Secret exists and holds the provided keys.
+func (v *Validator) validateSecretKeys(namespace, name string, keys ...string) error {
+	secret := &corev1.Secret{}
+	namespacedName := k8stypes.NamespacedName{Namespace: namespace, Name: name}
+	if err := v.c.Get(context.Background(), namespacedName, secret); err != nil {
+		return fmt.Errorf("failed to get secret %s referenced by the config center: %w", name, err)
+	}
+	for _, key := range keys {
+		if _, ok := secret.Data[key]; !ok {
+			return fmt.Errorf("secret %s referenced by the config center has no %s key", name, key)
+		}
+	}
+	return nil
+}
+
+// validateDeployOptions validates the DeploymentOptions field of DataPlane
+// object running in the provided config center mode.
+func (v *Validator) validateDeployOptions(
+	namespace string,
+	opts *apisixoperatorv1alpha1.DeploymentOptions,
+	mode apisixoperatorv1alpha1.ConfigCenterMode,
+) error {
 
 	// validate db mode.
 	dbMode, dbModeFound, err := v.getDBModeFromEnv(namespace, opts.Env)
@@ -51,10 +355,17 @@ func (v *Validator) ValidateDeployOptions(namespace string, opts *apisixoperator
 		}
 	}
 
-	// only support dbless mode.
-	if dbMode != "" && dbMode != "off" {
+	// only dbless and etcd are supported, and the database must match the config center.
+	if dbMode == "" {
+		return nil
+	}
+	if dbMode != dataplaneutils.DatabaseOff && dbMode != dataplaneutils.DatabaseEtcd {
 		return fmt.Errorf("database backend %s of dataplane not supported currently", dbMode)
 	}
+	if expected := dataplaneutils.DatabaseForConfigCenterMode(mode); dbMode != expected {
+		return fmt.Errorf("database backend %s of dataplane does not match its %s config center mode, expected %s",
+			dbMode, mode, expected)
+	}
 	return nil
 }
 
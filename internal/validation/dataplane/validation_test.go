@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
@@ -264,3 +265,602 @@ func TestValidateDeployOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateConfigCenter(t *testing.T) {
+	b := fakeclient.NewClientBuilder()
+	b.WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "etcd-auth"},
+			Data: map[string][]byte{
+				"username": []byte("root"),
+				"password": []byte("secret"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "etcd-ca"},
+			Data: map[string][]byte{
+				"tls.crt": []byte("cert"),
+			},
+		},
+	)
+
+	etcdDataPlane := func(etcd *apisixoperatorv1alpha1.EtcdConfigCenter, env ...corev1.EnvVar) *apisixoperatorv1alpha1.DataPlane {
+		return &apisixoperatorv1alpha1.DataPlane{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-etcd",
+				Namespace: "default",
+			},
+			Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+				DataPlaneDeploymentOptions: apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+					DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+						Env: env,
+					},
+				},
+				ConfigCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+					Mode: apisixoperatorv1alpha1.ConfigCenterModeEtcd,
+					Etcd: etcd,
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		msg       string
+		dataplane *apisixoperatorv1alpha1.DataPlane
+		hasError  bool
+		errMsg    string
+	}{
+		{
+			msg: "dataplane in etcd mode with dbmode=etcd should be valid",
+			dataplane: etcdDataPlane(
+				&apisixoperatorv1alpha1.EtcdConfigCenter{
+					Endpoints:     []string{"http://etcd:2379"},
+					AuthSecretRef: &corev1.LocalObjectReference{Name: "etcd-auth"},
+				},
+				corev1.EnvVar{Name: consts.EnvVarApisixDatabase, Value: "etcd"},
+			),
+			hasError: false,
+		},
+		{
+			msg: "dataplane in etcd mode with dbmode=off should be invalid",
+			dataplane: etcdDataPlane(
+				&apisixoperatorv1alpha1.EtcdConfigCenter{Endpoints: []string{"http://etcd:2379"}},
+				corev1.EnvVar{Name: consts.EnvVarApisixDatabase, Value: "off"},
+			),
+			hasError: true,
+			errMsg:   "database backend off of dataplane does not match its etcd config center mode",
+		},
+		{
+			msg:       "dataplane in etcd mode without endpoints should be invalid",
+			dataplane: etcdDataPlane(nil),
+			hasError:  true,
+			errMsg:    "etcd endpoints or a managed etcd cluster are required in etcd config center mode",
+		},
+		{
+			msg: "dataplane with a malformed etcd endpoint should be invalid",
+			dataplane: etcdDataPlane(&apisixoperatorv1alpha1.EtcdConfigCenter{
+				Endpoints: []string{"etcd:2379"},
+			}),
+			hasError: true,
+			errMsg:   "etcd endpoint etcd:2379 must be an http or https URL",
+		},
+		{
+			msg: "dataplane with TLS and an http etcd endpoint should be invalid",
+			dataplane: etcdDataPlane(&apisixoperatorv1alpha1.EtcdConfigCenter{
+				Endpoints: []string{"http://etcd:2379"},
+				TLS:       &apisixoperatorv1alpha1.EtcdTLSConfig{InsecureSkipVerify: true},
+			}),
+			hasError: true,
+			errMsg:   "etcd endpoint http://etcd:2379 must use https when TLS is configured",
+		},
+		{
+			msg: "dataplane referencing a missing secret should be invalid",
+			dataplane: etcdDataPlane(&apisixoperatorv1alpha1.EtcdConfigCenter{
+				Endpoints:     []string{"http://etcd:2379"},
+				AuthSecretRef: &corev1.LocalObjectReference{Name: "missing"},
+			}),
+			hasError: true,
+			errMsg:   "failed to get secret missing referenced by the config center",
+		},
+		{
+			msg: "dataplane referencing a secret without the expected key should be invalid",
+			dataplane: etcdDataPlane(&apisixoperatorv1alpha1.EtcdConfigCenter{
+				Endpoints: []string{"https://etcd:2379"},
+				TLS: &apisixoperatorv1alpha1.EtcdTLSConfig{
+					CASecretRef: &corev1.LocalObjectReference{Name: "etcd-ca"},
+				},
+			}),
+			hasError: true,
+			errMsg:   "secret etcd-ca referenced by the config center has no ca.crt key",
+		},
+		{
+			msg: "dataplane with a managed etcd cluster should be valid",
+			dataplane: &apisixoperatorv1alpha1.DataPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-managed",
+					Namespace: "default",
+				},
+				Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+					ConfigCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+						Mode:    apisixoperatorv1alpha1.ConfigCenterModeEtcd,
+						Managed: &apisixoperatorv1alpha1.ManagedEtcdConfigCenter{},
+					},
+				},
+			},
+			hasError: false,
+		},
+		{
+			msg: "dataplane with both etcd endpoints and a managed etcd cluster should be invalid",
+			dataplane: &apisixoperatorv1alpha1.DataPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-managed",
+					Namespace: "default",
+				},
+				Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+					ConfigCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+						Mode:    apisixoperatorv1alpha1.ConfigCenterModeEtcd,
+						Etcd:    &apisixoperatorv1alpha1.EtcdConfigCenter{Endpoints: []string{"http://etcd:2379"}},
+						Managed: &apisixoperatorv1alpha1.ManagedEtcdConfigCenter{},
+					},
+				},
+			},
+			hasError: true,
+			errMsg:   "etcd endpoints can't be configured along with a managed etcd cluster",
+		},
+		{
+			msg: "dataplane in standalone mode with etcd should be invalid",
+			dataplane: &apisixoperatorv1alpha1.DataPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-standalone",
+					Namespace: "default",
+				},
+				Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+					ConfigCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+						Mode: apisixoperatorv1alpha1.ConfigCenterModeStandalone,
+						Etcd: &apisixoperatorv1alpha1.EtcdConfigCenter{Endpoints: []string{"http://etcd:2379"}},
+					},
+				},
+			},
+			hasError: true,
+			errMsg:   "etcd can't be configured in standalone config center mode",
+		},
+		{
+			msg: "dataplane in standalone mode with dbmode=etcd should be invalid",
+			dataplane: &apisixoperatorv1alpha1.DataPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-standalone",
+					Namespace: "default",
+				},
+				Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+					DataPlaneDeploymentOptions: apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+						DeploymentOptions: apisixoperatorv1alpha1.DeploymentOptions{
+							Env: []corev1.EnvVar{{Name: consts.EnvVarApisixDatabase, Value: "etcd"}},
+						},
+					},
+				},
+			},
+			hasError: true,
+			errMsg:   "database backend etcd of dataplane does not match its standalone config center mode",
+		},
+	}
+
+	for _, tc := range testCases {
+		v := &Validator{
+			c: b.Build(),
+		}
+		err := v.Validate(tc.dataplane)
+		if !tc.hasError {
+			require.NoErrorf(t, err, tc.msg)
+		} else {
+			require.ErrorContainsf(t, err, tc.errMsg, tc.msg)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	adminPort := int32(9080)
+	statusPort := int32(8100)
+	workerProcesses := intstr.FromString("auto")
+	invalidWorkerProcesses := intstr.FromInt(0)
+	configDataPlane := func(config *apisixoperatorv1alpha1.DataPlaneConfig) *apisixoperatorv1alpha1.DataPlane {
+		return &apisixoperatorv1alpha1.DataPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+			Spec:       apisixoperatorv1alpha1.DataPlaneSpec{Config: config},
+		}
+	}
+	portsDataPlane := func(ports ...apisixoperatorv1alpha1.DataPlaneServicePort) *apisixoperatorv1alpha1.DataPlane {
+		dataplane := configDataPlane(nil)
+		dataplane.Spec.Network.Services = &apisixoperatorv1alpha1.DataPlaneServices{
+			Ingress: &apisixoperatorv1alpha1.DataPlaneServiceOptions{Ports: ports},
+		}
+		return dataplane
+	}
+	withAdminPort := func(dataplane *apisixoperatorv1alpha1.DataPlane, port int32) *apisixoperatorv1alpha1.DataPlane {
+		dataplane.Spec.Config = &apisixoperatorv1alpha1.DataPlaneConfig{
+			Admin: &apisixoperatorv1alpha1.DataPlaneAdminAPIOptions{Port: &port},
+		}
+		return dataplane
+	}
+	defaultAdminPort := int32(9444)
+
+	testCases := []struct {
+		msg       string
+		dataplane *apisixoperatorv1alpha1.DataPlane
+		hasError  bool
+		errMsg    string
+	}{
+		{
+			msg: "dataplane with a valid config should be valid",
+			dataplane: configDataPlane(&apisixoperatorv1alpha1.DataPlaneConfig{
+				Plugins:         []string{"cors", "prometheus"},
+				WorkerProcesses: &workerProcesses,
+				Admin:           &apisixoperatorv1alpha1.DataPlaneAdminAPIOptions{AllowList: []string{"10.0.0.0/8"}},
+				RealIP: &apisixoperatorv1alpha1.DataPlaneRealIPOptions{
+					Header:           "X-Forwarded-For",
+					TrustedAddresses: []string{"192.168.0.1", "fd00::/8"},
+				},
+			}),
+			hasError: false,
+		},
+		{
+			msg: "dataplane with no worker processes should be invalid",
+			dataplane: configDataPlane(&apisixoperatorv1alpha1.DataPlaneConfig{
+				WorkerProcesses: &invalidWorkerProcesses,
+			}),
+			hasError: true,
+			errMsg:   "worker processes of dataplane must be a positive number or auto, got 0",
+		},
+		{
+			msg: "dataplane with a malformed admin API allow list should be invalid",
+			dataplane: configDataPlane(&apisixoperatorv1alpha1.DataPlaneConfig{
+				Admin: &apisixoperatorv1alpha1.DataPlaneAdminAPIOptions{AllowList: []string{"10.0.0.0/33"}},
+			}),
+			hasError: true,
+			errMsg:   "admin API allow list of dataplane must be IP addresses or CIDRs, got 10.0.0.0/33",
+		},
+		{
+			msg: "dataplane with an admin API port used by a proxy listener should be invalid",
+			dataplane: configDataPlane(&apisixoperatorv1alpha1.DataPlaneConfig{
+				Admin: &apisixoperatorv1alpha1.DataPlaneAdminAPIOptions{Port: &adminPort},
+			}),
+			hasError: true,
+			errMsg:   "admin API port 9080 of dataplane is already used by its HTTP port 80",
+		},
+		{
+			msg: "dataplane with an admin API port used by the status API should be invalid",
+			dataplane: configDataPlane(&apisixoperatorv1alpha1.DataPlaneConfig{
+				Admin: &apisixoperatorv1alpha1.DataPlaneAdminAPIOptions{Port: &statusPort},
+			}),
+			hasError: true,
+			errMsg:   "admin API port 8100 of dataplane is reserved",
+		},
+		{
+			msg: "dataplane with a port served on the default admin API port should be invalid",
+			dataplane: portsDataPlane(
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 9444, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTPS},
+			),
+			hasError: true,
+			errMsg:   "admin API port 9444 of dataplane is already used by its HTTPS port 9444",
+		},
+		{
+			msg: "dataplane with a port targeting the default admin API port should be invalid",
+			dataplane: portsDataPlane(
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 80, TargetPort: &defaultAdminPort, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+			),
+			hasError: true,
+			errMsg:   "admin API port 9444 of dataplane is already used by its HTTP port 80",
+		},
+		{
+			msg: "dataplane with a port served on the status API port should be invalid",
+			dataplane: portsDataPlane(
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 8100, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+			),
+			hasError: true,
+			errMsg:   "HTTP port 8100 of dataplane is served on port 8100, which is reserved",
+		},
+		{
+			msg: "dataplane with two ports served on the same port should be invalid",
+			dataplane: portsDataPlane(
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 9080, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+			),
+			hasError: true,
+			errMsg:   "HTTP port 9080 of dataplane is served on port 9080, which is already used by its port 80",
+		},
+		{
+			msg: "dataplane with ports of different transport protocols served on the same port should be valid",
+			dataplane: portsDataPlane(
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 53, Protocol: apisixoperatorv1alpha1.ProxyProtocolTCP},
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 53, Protocol: apisixoperatorv1alpha1.ProxyProtocolUDP},
+			),
+			hasError: false,
+		},
+		{
+			msg: "dataplane with ports on the default admin API ports while its admin API listens on another port should be valid",
+			dataplane: withAdminPort(portsDataPlane(
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 9444, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+				apisixoperatorv1alpha1.DataPlaneServicePort{Port: 9180, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP},
+			), 9500),
+			hasError: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		v := &Validator{
+			c: fakeclient.NewClientBuilder().Build(),
+		}
+		err := v.Validate(tc.dataplane)
+		if !tc.hasError {
+			require.NoErrorf(t, err, tc.msg)
+		} else {
+			require.ErrorContainsf(t, err, tc.errMsg, tc.msg)
+		}
+	}
+}
+
+func TestValidateScaling(t *testing.T) {
+	minReplicas := int32(3)
+	scalingDataPlane := func(horizontal *apisixoperatorv1alpha1.HorizontalScaling) *apisixoperatorv1alpha1.DataPlane {
+		return &apisixoperatorv1alpha1.DataPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-scaling", Namespace: "default"},
+			Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+				DataPlaneDeploymentOptions: apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+					Scaling: &apisixoperatorv1alpha1.DataPlaneScaling{Horizontal: horizontal},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		msg       string
+		dataplane *apisixoperatorv1alpha1.DataPlane
+		hasError  bool
+		errMsg    string
+	}{
+		{
+			msg:       "dataplane scaled horizontally within valid limits should be valid",
+			dataplane: scalingDataPlane(&apisixoperatorv1alpha1.HorizontalScaling{MinReplicas: &minReplicas, MaxReplicas: 3}),
+			hasError:  false,
+		},
+		{
+			msg:       "dataplane scaled horizontally with no lower limit should be valid",
+			dataplane: scalingDataPlane(&apisixoperatorv1alpha1.HorizontalScaling{MaxReplicas: 1}),
+			hasError:  false,
+		},
+		{
+			msg:       "dataplane scaled horizontally with a lower limit exceeding its upper limit should be invalid",
+			dataplane: scalingDataPlane(&apisixoperatorv1alpha1.HorizontalScaling{MinReplicas: &minReplicas, MaxReplicas: 2}),
+			hasError:  true,
+			errMsg:    "min replicas 3 of dataplane can't exceed its max replicas 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		v := &Validator{
+			c: fakeclient.NewClientBuilder().Build(),
+		}
+		err := v.Validate(tc.dataplane)
+		if !tc.hasError {
+			require.NoErrorf(t, err, tc.msg)
+		} else {
+			require.ErrorContainsf(t, err, tc.errMsg, tc.msg)
+		}
+	}
+}
+
+func TestValidateDeploymentMode(t *testing.T) {
+	replicas := int32(2)
+	deploymentModeDataPlane := func(opts apisixoperatorv1alpha1.DataPlaneDeploymentOptions) *apisixoperatorv1alpha1.DataPlane {
+		return &apisixoperatorv1alpha1.DataPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-deployment-mode", Namespace: "default"},
+			Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+				DataPlaneDeploymentOptions: opts,
+			},
+		}
+	}
+
+	testCases := []struct {
+		msg       string
+		dataplane *apisixoperatorv1alpha1.DataPlane
+		hasError  bool
+		errMsg    string
+	}{
+		{
+			msg: "dataplane deployed by a Deployment with replicas should be valid",
+			dataplane: deploymentModeDataPlane(apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+				DeploymentMode: apisixoperatorv1alpha1.DataPlaneDeploymentModeDeployment,
+				Replicas:       &replicas,
+			}),
+			hasError: false,
+		},
+		{
+			msg: "dataplane deployed by a DaemonSet should be valid",
+			dataplane: deploymentModeDataPlane(apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+				DeploymentMode: apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet,
+			}),
+			hasError: false,
+		},
+		{
+			msg: "dataplane deployed by a DaemonSet with replicas should be invalid",
+			dataplane: deploymentModeDataPlane(apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+				DeploymentMode: apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet,
+				Replicas:       &replicas,
+			}),
+			hasError: true,
+			errMsg:   "replicas of dataplane can't be set in DaemonSet deployment mode",
+		},
+		{
+			msg: "dataplane deployed by a DaemonSet scaled horizontally should be invalid",
+			dataplane: deploymentModeDataPlane(apisixoperatorv1alpha1.DataPlaneDeploymentOptions{
+				DeploymentMode: apisixoperatorv1alpha1.DataPlaneDeploymentModeDaemonSet,
+				Scaling: &apisixoperatorv1alpha1.DataPlaneScaling{
+					Horizontal: &apisixoperatorv1alpha1.HorizontalScaling{MaxReplicas: 3},
+				},
+			}),
+			hasError: true,
+			errMsg:   "dataplane can't be scaled horizontally in DaemonSet deployment mode",
+		},
+	}
+
+	for _, tc := range testCases {
+		v := &Validator{
+			c: fakeclient.NewClientBuilder().Build(),
+		}
+		err := v.Validate(tc.dataplane)
+		if !tc.hasError {
+			require.NoErrorf(t, err, tc.msg)
+		} else {
+			require.ErrorContainsf(t, err, tc.errMsg, tc.msg)
+		}
+	}
+}
+
+func TestValidateIngressService(t *testing.T) {
+	nodePort := int32(30080)
+	ingressDataPlane := func(opts apisixoperatorv1alpha1.DataPlaneServiceOptions) *apisixoperatorv1alpha1.DataPlane {
+		return &apisixoperatorv1alpha1.DataPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ingress-service", Namespace: "default"},
+			Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+				Network: apisixoperatorv1alpha1.DataPlaneNetworkOptions{
+					Services: &apisixoperatorv1alpha1.DataPlaneServices{Ingress: &opts},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		msg       string
+		dataplane *apisixoperatorv1alpha1.DataPlane
+		hasError  bool
+		errMsg    string
+	}{
+		{
+			msg: "dataplane with a LoadBalancer service exposed on explicit node ports should be valid",
+			dataplane: ingressDataPlane(apisixoperatorv1alpha1.DataPlaneServiceOptions{
+				Ports: []apisixoperatorv1alpha1.DataPlaneServicePort{
+					{Port: 53, Protocol: apisixoperatorv1alpha1.ProxyProtocolTCP, NodePort: &nodePort},
+					{Port: 53, Protocol: apisixoperatorv1alpha1.ProxyProtocolUDP, NodePort: &nodePort},
+				},
+				ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyTypeLocal,
+				LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+			}),
+			hasError: false,
+		},
+		{
+			msg: "dataplane with a ClusterIP service exposed on a node port should be invalid",
+			dataplane: ingressDataPlane(apisixoperatorv1alpha1.DataPlaneServiceOptions{
+				Type: corev1.ServiceTypeClusterIP,
+				Ports: []apisixoperatorv1alpha1.DataPlaneServicePort{
+					{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP, NodePort: &nodePort},
+				},
+			}),
+			hasError: true,
+			errMsg:   "node port 30080 of dataplane port 80 can't be set on a ClusterIP service",
+		},
+		{
+			msg: "dataplane with a ClusterIP service with an external traffic policy should be invalid",
+			dataplane: ingressDataPlane(apisixoperatorv1alpha1.DataPlaneServiceOptions{
+				Type:                  corev1.ServiceTypeClusterIP,
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+			}),
+			hasError: true,
+			errMsg:   "external traffic policy of dataplane can't be set on a ClusterIP service",
+		},
+		{
+			msg: "dataplane with a node port used by two ports should be invalid",
+			dataplane: ingressDataPlane(apisixoperatorv1alpha1.DataPlaneServiceOptions{
+				Type: corev1.ServiceTypeNodePort,
+				Ports: []apisixoperatorv1alpha1.DataPlaneServicePort{
+					{Port: 80, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTP, NodePort: &nodePort},
+					{Port: 443, Protocol: apisixoperatorv1alpha1.ProxyProtocolHTTPS, NodePort: &nodePort},
+				},
+			}),
+			hasError: true,
+			errMsg:   "node port 30080 of dataplane port 443 is already used by its port 80",
+		},
+		{
+			msg: "dataplane with a NodePort service with load balancer source ranges should be invalid",
+			dataplane: ingressDataPlane(apisixoperatorv1alpha1.DataPlaneServiceOptions{
+				Type:                     corev1.ServiceTypeNodePort,
+				LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+			}),
+			hasError: true,
+			errMsg:   "load balancer source ranges of dataplane can't be set on a NodePort service",
+		},
+		{
+			msg: "dataplane with load balancer source ranges which aren't CIDRs should be invalid",
+			dataplane: ingressDataPlane(apisixoperatorv1alpha1.DataPlaneServiceOptions{
+				LoadBalancerSourceRanges: []string{"10.0.0.1"},
+			}),
+			hasError: true,
+			errMsg:   "load balancer source ranges of dataplane must be CIDRs, got 10.0.0.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		v := &Validator{
+			c: fakeclient.NewClientBuilder().Build(),
+		}
+		err := v.Validate(tc.dataplane)
+		if !tc.hasError {
+			require.NoErrorf(t, err, tc.msg)
+		} else {
+			require.ErrorContainsf(t, err, tc.errMsg, tc.msg)
+		}
+	}
+}
+
+func TestValidateManagedEtcdUpdate(t *testing.T) {
+	managedDataPlane := func(replicas *int32) *apisixoperatorv1alpha1.DataPlane {
+		return &apisixoperatorv1alpha1.DataPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-managed-etcd", Namespace: "default"},
+			Spec: apisixoperatorv1alpha1.DataPlaneSpec{
+				ConfigCenter: &apisixoperatorv1alpha1.DataPlaneConfigCenter{
+					Mode:    apisixoperatorv1alpha1.ConfigCenterModeEtcd,
+					Managed: &apisixoperatorv1alpha1.ManagedEtcdConfigCenter{Replicas: replicas},
+				},
+			},
+		}
+	}
+	three, five := int32(3), int32(5)
+
+	testCases := []struct {
+		msg          string
+		dataplane    *apisixoperatorv1alpha1.DataPlane
+		oldDataplane *apisixoperatorv1alpha1.DataPlane
+		hasError     bool
+		errMsg       string
+	}{
+		{
+			msg:          "dataplane keeping the members of its managed etcd cluster should be valid",
+			dataplane:    managedDataPlane(&three),
+			oldDataplane: managedDataPlane(nil),
+			hasError:     false,
+		},
+		{
+			msg:          "dataplane enabling a managed etcd cluster should be valid",
+			dataplane:    managedDataPlane(&five),
+			oldDataplane: &apisixoperatorv1alpha1.DataPlane{},
+			hasError:     false,
+		},
+		{
+			msg:          "dataplane changing the members of its managed etcd cluster should be invalid",
+			dataplane:    managedDataPlane(&five),
+			oldDataplane: managedDataPlane(&three),
+			hasError:     true,
+			errMsg:       "managed etcd cluster members of dataplane can't be changed from 3 to 5",
+		},
+	}
+
+	for _, tc := range testCases {
+		v := &Validator{
+			c: fakeclient.NewClientBuilder().Build(),
+		}
+		err := v.ValidateUpdate(tc.dataplane, tc.oldDataplane)
+		if !tc.hasError {
+			require.NoErrorf(t, err, tc.msg)
+		} else {
+			require.ErrorContainsf(t, err, tc.errMsg, tc.msg)
+		}
+	}
+}
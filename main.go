@@ -17,8 +17,11 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -26,13 +29,19 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	apisixoperatorv1alpha1 "github.com/chever-john/apisix-operator/apis/v1alpha1"
 	"github.com/chever-john/apisix-operator/controllers"
+	"github.com/chever-john/apisix-operator/internal/admission"
+	"github.com/chever-john/apisix-operator/internal/consts"
+	"github.com/chever-john/apisix-operator/internal/manager"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -45,6 +54,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(apisixoperatorv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1alpha2.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -52,11 +62,80 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var controllerName string
+	var clusterCASecretName string
+	var clusterCASecretNamespace string
+	var clusterCertificateLifetime time.Duration
+	var clusterCertificateRenewBefore time.Duration
+	var certManagerIssuerName string
+	var certManagerIssuerKind string
+	var certManagerIssuerGroup string
+	var useCertificateSigningRequests bool
+	var operatorUsername string
+	var enableValidatingWebhook bool
+	var enableDefaultingWebhook bool
+	var webhookPort int
+	var webhookCertDir string
+	var webhookCertificateSecretName string
+	var webhookServiceName string
+	var webhookServiceNamespace string
+	var webhookServicePort int
+	var validatingWebhookConfigurationName string
+	var mutatingWebhookConfigurationName string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&controllerName, "controller-name", consts.DefaultControllerName,
+		"The controller name used to claim GatewayClasses through their spec.controllerName field.")
+	flag.StringVar(&clusterCASecretName, "cluster-ca-secret", consts.DefaultClusterCASecretName,
+		"The name of the Secret holding the CA used to sign the mTLS certificates of ControlPlanes and DataPlanes. "+
+			"A self-signed CA is generated into it if it doesn't exist.")
+	flag.StringVar(&clusterCASecretNamespace, "cluster-ca-secret-namespace", consts.DefaultClusterCASecretNamespace,
+		"The namespace of the Secret holding the cluster CA.")
+	flag.DurationVar(&clusterCertificateLifetime, "cluster-certificate-lifetime", consts.DefaultClusterCertificateLifetime,
+		"The validity of the mTLS certificates issued to ControlPlanes and DataPlanes by the cluster CA.")
+	flag.DurationVar(&clusterCertificateRenewBefore, "cluster-certificate-renew-before", consts.DefaultClusterCertificateRenewBefore,
+		"How long before their expiration the mTLS certificates issued to ControlPlanes and DataPlanes are re-issued. "+
+			"It must be shorter than the certificates lifetime.")
+	flag.StringVar(&certManagerIssuerName, "cert-manager-issuer", "",
+		"The name of the cert-manager issuer of the mTLS certificates of ControlPlanes and DataPlanes. "+
+			"If set, the certificates are issued by cert-manager instead of being signed by the cluster CA.")
+	flag.StringVar(&certManagerIssuerKind, "cert-manager-issuer-kind", controllers.CertManagerClusterIssuerKind,
+		"The kind of the cert-manager issuer, either ClusterIssuer or Issuer.")
+	flag.StringVar(&certManagerIssuerGroup, "cert-manager-issuer-group", controllers.CertManagerGroup,
+		"The API group of the cert-manager issuer.")
+	flag.BoolVar(&useCertificateSigningRequests, "use-certificate-signing-requests", false,
+		"Request the mTLS certificates of ControlPlanes and DataPlanes through the CertificateSigningRequest API, "+
+			"for the operator to approve and sign them with the cluster CA, instead of signing them in memory.")
+	flag.StringVar(&operatorUsername, "operator-username", consts.DefaultOperatorUsername,
+		"The username the operator authenticates to the API server as. Only the CertificateSigningRequests "+
+			"made by it are approved.")
+	flag.BoolVar(&enableValidatingWebhook, "enable-validating-webhook", false,
+		"Serve the admission webhook validating ControlPlanes and DataPlanes, and manage its certificate "+
+			"and ValidatingWebhookConfiguration.")
+	flag.BoolVar(&enableDefaultingWebhook, "enable-defaulting-webhook", false,
+		"Serve the admission webhook setting the defaults of ControlPlanes and DataPlanes, and manage its "+
+			"certificate and MutatingWebhookConfiguration.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the admission webhook server binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs",
+		"The directory the serving certificate of the admission webhook is written to.")
+	flag.StringVar(&webhookCertificateSecretName, "webhook-certificate-secret", consts.DefaultWebhookCertificateSecretName,
+		"The name of the Secret holding the CA and the serving certificate of the admission webhook, "+
+			"in the namespace of the webhook Service.")
+	flag.StringVar(&webhookServiceName, "webhook-service-name", consts.DefaultWebhookServiceName,
+		"The name of the Service the API server reaches the admission webhook through.")
+	flag.StringVar(&webhookServiceNamespace, "webhook-service-namespace", consts.DefaultWebhookServiceNamespace,
+		"The namespace of the Service the API server reaches the admission webhook through.")
+	flag.IntVar(&webhookServicePort, "webhook-service-port", 443,
+		"The port of the Service the API server reaches the admission webhook through.")
+	flag.StringVar(&validatingWebhookConfigurationName, "validating-webhook-configuration-name",
+		consts.DefaultValidatingWebhookConfigurationName,
+		"The name of the ValidatingWebhookConfiguration managed by the operator.")
+	flag.StringVar(&mutatingWebhookConfigurationName, "mutating-webhook-configuration-name",
+		consts.DefaultMutatingWebhookConfigurationName,
+		"The name of the MutatingWebhookConfiguration managed by the operator.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -65,10 +144,18 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	if clusterCertificateLifetime <= 0 || clusterCertificateRenewBefore <= 0 || clusterCertificateRenewBefore >= clusterCertificateLifetime {
+		setupLog.Error(fmt.Errorf("invalid certificate lifetime %s and renewal %s", clusterCertificateLifetime, clusterCertificateRenewBefore),
+			"--cluster-certificate-renew-before must be positive and shorter than --cluster-certificate-lifetime")
+		os.Exit(1)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
-		Port:                   9443,
+		Port:                   webhookPort,
+		CertDir:                webhookCertDir,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "23d56171.apisix-operator.apisix.apache.org",
@@ -89,29 +176,142 @@ func main() {
 		os.Exit(1)
 	}
 
+	// the manager client is backed by a cache which isn't started yet, so what
+	// has to be set up before starting the controllers and the webhook server
+	// is done with a direct client.
+	directClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+
+	var certManagerIssuer *controllers.CertManagerIssuerRef
+	if certManagerIssuerName != "" {
+		if useCertificateSigningRequests {
+			setupLog.Error(fmt.Errorf("conflicting certificate issuers"),
+				"--cert-manager-issuer and --use-certificate-signing-requests are mutually exclusive")
+			os.Exit(1)
+		}
+		if certManagerIssuerKind != controllers.CertManagerClusterIssuerKind && certManagerIssuerKind != controllers.CertManagerIssuerKind {
+			setupLog.Error(fmt.Errorf("invalid cert-manager issuer kind %q", certManagerIssuerKind),
+				"--cert-manager-issuer-kind must be either ClusterIssuer or Issuer")
+			os.Exit(1)
+		}
+		certManagerIssuer = &controllers.CertManagerIssuerRef{
+			Name:  certManagerIssuerName,
+			Kind:  certManagerIssuerKind,
+			Group: certManagerIssuerGroup,
+		}
+		setupLog.Info("mTLS certificates are issued by cert-manager", "issuer", certManagerIssuerName, "kind", certManagerIssuerKind)
+	} else {
+		// the cluster CA is bootstrapped before starting the controllers which rely on it.
+		setupLog.Info("ensuring cluster CA secret", "namespace", clusterCASecretNamespace, "name", clusterCASecretName)
+		if err := manager.EnsureClusterCASecret(context.Background(), setupLog, directClient, clusterCASecretName, clusterCASecretNamespace); err != nil {
+			setupLog.Error(err, "unable to ensure cluster CA secret")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&controllers.APISIXConfigurationReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		ControllerName: controllerName,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "APISIXConfiguration")
 		os.Exit(1)
 	}
 	if err = (&controllers.DataPlaneReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                        mgr.GetClient(),
+		Scheme:                        mgr.GetScheme(),
+		ClusterCASecretName:           clusterCASecretName,
+		ClusterCASecretNamespace:      clusterCASecretNamespace,
+		ClusterCertificateLifetime:    clusterCertificateLifetime,
+		ClusterCertificateRenewBefore: clusterCertificateRenewBefore,
+		CertManagerIssuer:             certManagerIssuer,
+		UseCertificateSigningRequests: useCertificateSigningRequests,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DataPlane")
 		os.Exit(1)
 	}
 	if err = (&controllers.ControlPlaneReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                        mgr.GetClient(),
+		Scheme:                        mgr.GetScheme(),
+		ClusterCASecretName:           clusterCASecretName,
+		ClusterCASecretNamespace:      clusterCASecretNamespace,
+		ClusterCertificateLifetime:    clusterCertificateLifetime,
+		ClusterCertificateRenewBefore: clusterCertificateRenewBefore,
+		CertManagerIssuer:             certManagerIssuer,
+		UseCertificateSigningRequests: useCertificateSigningRequests,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ControlPlane")
 		os.Exit(1)
 	}
+	if useCertificateSigningRequests {
+		clientset, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to create clientset")
+			os.Exit(1)
+		}
+		if err = (&controllers.CertificateSigningRequestReconciler{
+			Client:                   mgr.GetClient(),
+			Scheme:                   mgr.GetScheme(),
+			CertificatesClient:       clientset.CertificatesV1().CertificateSigningRequests(),
+			ClusterCASecretName:      clusterCASecretName,
+			ClusterCASecretNamespace: clusterCASecretNamespace,
+			OperatorUsername:         operatorUsername,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CertificateSigningRequest")
+			os.Exit(1)
+		}
+	}
+	if err = (&controllers.GatewayClassReconciler{
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		ControllerName: controllerName,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GatewayClass")
+		os.Exit(1)
+	}
+	if err = (&controllers.GatewayReconciler{
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		ControllerName: controllerName,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Gateway")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
+	if enableValidatingWebhook || enableDefaultingWebhook {
+		certificateManager := &admission.CertificateManager{
+			Client:           directClient,
+			Logger:           ctrl.Log.WithName("webhook-certificates"),
+			SecretName:       webhookCertificateSecretName,
+			SecretNamespace:  webhookServiceNamespace,
+			ServiceName:      webhookServiceName,
+			ServiceNamespace: webhookServiceNamespace,
+			ServicePort:      int32(webhookServicePort),
+			CertDir:          webhookCertDir,
+		}
+		if enableValidatingWebhook {
+			certificateManager.ValidatingWebhookConfigurationName = validatingWebhookConfigurationName
+		}
+		if enableDefaultingWebhook {
+			certificateManager.MutatingWebhookConfigurationName = mutatingWebhookConfigurationName
+		}
+		// the webhook server requires its certificate to be present when it starts.
+		setupLog.Info("ensuring admission webhook certificate", "namespace", webhookServiceNamespace, "name", webhookCertificateSecretName)
+		if err := certificateManager.Ensure(context.Background()); err != nil {
+			setupLog.Error(err, "unable to ensure admission webhook certificate")
+			os.Exit(1)
+		}
+		if err := mgr.Add(certificateManager); err != nil {
+			setupLog.Error(err, "unable to set up admission webhook certificate rotation")
+			os.Exit(1)
+		}
+		admission.NewWebhookServerFromManager(mgr, ctrl.Log.WithName("webhook"), enableDefaultingWebhook)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)